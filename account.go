@@ -0,0 +1,124 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/model"
+	"context"
+	"errors"
+)
+
+// givenNamesAttribute is the session attribute caching the given names read from
+// the account, by speaker key since the people of a household may take turns in a session
+const givenNamesAttribute = "givenNames"
+
+// accountGivenName returns the given name of the speaker of request, read from
+// their profile or linked account once per session and cached in attributes.
+// A speaker who agreed to it has their name remembered across sessions, so it
+// is then only read again after they asked the skill to forget it
+func accountGivenName(ctx context.Context, request alexa.Request, attributes map[string]interface{}) (string, error) {
+	speaker := speakerKey(request)
+	if name := cachedGivenNames(attributes)[speaker]; name != "" {
+		return name, nil
+	}
+	prefs := userPreferences(ctx, request, attributes)
+	if prefs.RememberName && prefs.GivenName != "" {
+		cacheGivenName(attributes, speaker, prefs.GivenName)
+		return prefs.GivenName, nil
+	}
+
+	name, err := providersFrom(ctx).identity.GivenName(ctx, request)
+	if err != nil || name == "" {
+		return name, err
+	}
+	cacheGivenName(attributes, speaker, name)
+	if prefs.RememberName {
+		prefs.GivenName = name
+		savePreferences(ctx, request, attributes, prefs)
+	}
+	return name, nil
+}
+
+// cachedGivenNames returns the given names cached in attributes by speaker key.
+// Attributes decoded from a request hold them as a generic json object
+func cachedGivenNames(attributes map[string]interface{}) map[string]string {
+	names := map[string]string{}
+	switch saved := attributes[givenNamesAttribute].(type) {
+	case map[string]string:
+		for speaker, name := range saved {
+			names[speaker] = name
+		}
+	case map[string]interface{}:
+		for speaker, name := range saved {
+			if name, ok := name.(string); ok {
+				names[speaker] = name
+			}
+		}
+	}
+	return names
+}
+
+// cacheGivenName caches the given name of speaker in attributes, deleting it when
+// name is empty, along with the attribute once no speaker has a name cached
+func cacheGivenName(attributes map[string]interface{}, speaker string, name string) {
+	names := cachedGivenNames(attributes)
+	if name == "" {
+		delete(names, speaker)
+	} else {
+		names[speaker] = name
+	}
+	if len(names) == 0 {
+		delete(attributes, givenNamesAttribute)
+		return
+	}
+	attributes[givenNamesAttribute] = names
+}
+
+// nameConsentIntentModels declare the intents about remembering the name in the interaction model
+var nameConsentIntentModels = []model.Intent{
+	{Name: "RememberNameIntent", Samples: []string{"remember my name", "keep my name"}},
+	{Name: "ForgetNameIntent", Samples: []string{"forget my name", "stop remembering my name"}},
+}
+
+// HandleRememberNameIntent lets the skill keep the given name of the speaker
+// across sessions, so it isn't read from their account every time.
+// A user can say:
+// Alexa, ask nationality guesser to remember my name
+func HandleRememberNameIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	name, err := accountGivenName(ctx, request, attributes)
+	if errors.Is(err, errNoPermission) {
+		return alexa.NewSimpleResponse(templates.Render("title.preferences"), templates.Render("guess.permission")).
+			WithCard(alexa.NewAskForPermissionsConsentCard(alexa.GivenNamePermission))
+	}
+	if err != nil {
+		return respondWithError(ctx, templates, "title.preferences", err)
+	}
+
+	prefs := userPreferences(ctx, request, attributes)
+	prefs.RememberName, prefs.GivenName = true, name
+	savePreferences(ctx, request, attributes, prefs)
+	return alexa.NewSimpleResponse(templates.Render("title.preferences"), templates.Render("preferences.name_remembered", "name", name)).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// HandleForgetNameIntent withdraws the consent given to HandleRememberNameIntent,
+// deleting the name kept so far.
+// A user can say:
+// Alexa, ask nationality guesser to forget my name
+func HandleForgetNameIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	prefs := userPreferences(ctx, request, attributes)
+	prefs.RememberName, prefs.GivenName = false, ""
+	savePreferences(ctx, request, attributes, prefs)
+	cacheGivenName(attributes, speakerKey(request), "")
+	return alexa.NewSimpleResponse(templates.Render("title.preferences"), templates.Render("preferences.name_forgotten")).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
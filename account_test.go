@@ -0,0 +1,64 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/preferences"
+	"context"
+	"strings"
+	"testing"
+)
+
+// householdIdentity is an identity provider answering with the name of each person by id
+type householdIdentity map[string]string
+
+func (identity householdIdentity) GivenName(ctx context.Context, request alexa.Request) (string, error) {
+	return identity[request.Context.System.Person.PersonID], nil
+}
+
+// household is the context of a session where Anna and Ben take turns
+var household = withProviders(context.Background(), providers{identity: householdIdentity{
+	"amzn1.ask.person.anna": "Anna",
+	"amzn1.ask.person.ben":  "Ben",
+}})
+
+func TestGivenNameIsKeptPerSpeaker(t *testing.T) {
+	anna := HandleRememberNameIntent(household, speakerRequest(t, "amzn1.ask.person.anna", "RememberNameIntent", nil, nil))
+	if speech := spoken(anna); !strings.Contains(speech, "Anna") {
+		t.Fatalf("first speaker heard %q, want their name", speech)
+	}
+
+	ben := speakerRequest(t, "amzn1.ask.person.ben", "RememberNameIntent", nil, &anna)
+	response := HandleRememberNameIntent(household, ben)
+	if speech := spoken(response); !strings.Contains(speech, "Ben") || strings.Contains(speech, "Anna") {
+		t.Errorf("second speaker heard %q, want their own name", speech)
+	}
+	if prefs, _ := preferences.Cached(response.SessionAttributes, speakerKey(ben)); prefs.GivenName != "Ben" {
+		t.Errorf("second speaker's preferences remember %q, want Ben", prefs.GivenName)
+	}
+
+	again := speakerRequest(t, "amzn1.ask.person.anna", "RememberNameIntent", nil, &response)
+	if name, err := accountGivenName(household, again, again.Session.Attributes); err != nil || name != "Anna" {
+		t.Errorf("accountGivenName = %q, %v for the first speaker, want Anna", name, err)
+	}
+}
+
+func TestForgettingNameKeepsOtherSpeakers(t *testing.T) {
+	anna := HandleRememberNameIntent(household, speakerRequest(t, "amzn1.ask.person.anna", "RememberNameIntent", nil, nil))
+	ben := HandleRememberNameIntent(household, speakerRequest(t, "amzn1.ask.person.ben", "RememberNameIntent", nil, &anna))
+	forgotten := HandleForgetNameIntent(household, speakerRequest(t, "amzn1.ask.person.ben", "ForgetNameIntent", nil, &ben))
+
+	names := cachedGivenNames(forgotten.SessionAttributes)
+	annaKey := speakerKey(speakerRequest(t, "amzn1.ask.person.anna", "", nil, nil))
+	if len(names) != 1 || names[annaKey] != "Anna" {
+		t.Errorf("cached names %v, want only Anna's left", names)
+	}
+}
+
+func TestForgettingTheLastNameDropsTheAttribute(t *testing.T) {
+	anna := HandleRememberNameIntent(household, speakerRequest(t, "amzn1.ask.person.anna", "RememberNameIntent", nil, nil))
+	forgotten := HandleForgetNameIntent(household, speakerRequest(t, "amzn1.ask.person.anna", "ForgetNameIntent", nil, &anna))
+
+	if _, ok := forgotten.SessionAttributes[givenNamesAttribute]; ok {
+		t.Errorf("session attributes %v still have the given names", forgotten.SessionAttributes)
+	}
+}
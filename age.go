@@ -0,0 +1,53 @@
+package main
+
+import (
+	"alexa-skill-test/src/age"
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"context"
+	"strconv"
+)
+
+// guessAgeIntentModel declares GuessAgeIntent in the interaction model
+var guessAgeIntentModel = model.Intent{
+	Name:    "GuessAgeIntent",
+	Slots:   []model.Slot{{Name: "first_name", Type: "FirstName"}},
+	Samples: []string{"how old people named {first_name} are", "how old is {first_name}", "guess the age of {first_name}"},
+}
+
+// HandleGuessAgeIntent estimates how old people having a first name usually are.
+// A user can say:
+// Alexa, ask nationality guesser how old people named Ethan are
+func HandleGuessAgeIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	firstName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(firstName) {
+		logging.FromContext(ctx).Info("refusing to guess age of blocked name", logging.NameKey, firstName)
+		return alexa.NewSimpleResponse(templates.Render("title.age"), templates.Render("age.blocked"))
+	}
+
+	logging.FromContext(ctx).Info("guessing age", logging.NameKey, firstName)
+	var response age.Response
+	if err := fetchJSON(ctx, withQuery(cfg.AgifyURL, nameQuery(firstName)), &response); err != nil {
+		logging.FromContext(ctx).Error("age guess failed", "error", err)
+		return respondWithError(ctx, templates, "title.age", err)
+	}
+	return alexa.NewSSMLResponse(templates.Render("title.age"), buildAgeResponse(templates, firstName, response)).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// buildAgeResponse speaks the age typical of people named firstName,
+// e.g. "People named Ethan are typically around 27."
+func buildAgeResponse(templates messages.Set, firstName string, response age.Response) string {
+	var builder alexa.SSMLBuilder
+	if response.Age <= 0 {
+		builder.Say(templates.Render("age.unknown", "name", firstName))
+		return builder.Build()
+	}
+	builder.Say(templates.Render("age.result", "name", firstName, "age", strconv.Itoa(response.Age)))
+	return builder.Build()
+}
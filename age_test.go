@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// guessAge returns the response to asking how old people named name are,
+// agify being a server that runs handler
+func guessAge(t *testing.T, name string, handler http.HandlerFunc) string {
+	keepConfig(t)
+	cfg.AgifyURL = serveAPI(t, handler)
+	return spoken(dispatchIntent(testContext(&fakeNationality{}), intentRequest("GuessAgeIntent", map[string]string{"first_name": name})))
+}
+
+func TestGuessAge(t *testing.T) {
+	speech := guessAge(t, "Hans", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("name"); got != "Hans" {
+			t.Errorf("name sent as %q", got)
+		}
+		w.Write([]byte(`{"name":"Hans","age":61,"count":23456}`))
+	})
+	if !strings.Contains(speech, "people named hans are typically around 61") {
+		t.Errorf("speech %q doesn't give the age of Hans", speech)
+	}
+}
+
+func TestGuessAgeUnknown(t *testing.T) {
+	speech := guessAge(t, "Xyzzy", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Xyzzy","age":null,"count":0}`))
+	})
+	if !strings.Contains(speech, "how old people named xyzzy usually are") {
+		t.Errorf("speech %q, want the age unknown", speech)
+	}
+}
+
+func TestGuessAgeFailures(t *testing.T) {
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		want    string
+	}{
+		"throttled": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTooManyRequests) },
+			want:    "too many questions today",
+		},
+		"rejected": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnprocessableEntity) },
+			want:    "try a different one",
+		},
+		"unavailable": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			want:    "couldn&apos;t reach the guessing service",
+		},
+		"malformed": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"age":`)) },
+			want:    "couldn&apos;t reach the guessing service",
+		},
+	}
+	for failure, test := range tests {
+		t.Run(failure, func(t *testing.T) {
+			if speech := guessAge(t, "Hans", test.handler); !strings.Contains(speech, test.want) {
+				t.Errorf("speech %q doesn't say %q", speech, test.want)
+			}
+		})
+	}
+}
+
+func TestGuessAgeRefusesBlockedName(t *testing.T) {
+	var requests atomic.Int32
+	speech := guessAge(t, "bitch", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	})
+	if !strings.Contains(speech, "rather not guess") {
+		t.Errorf("speech %q doesn't refuse the name", speech)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("agify was called %d times for a blocked name", got)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"alexa-skill-test/src/alerting"
+	"alexa-skill-test/src/failure"
+	"alexa-skill-test/src/logging"
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// providerMonitor alerts the operator when a nationality provider keeps
+// failing, nil when no topic is configured
+var providerMonitor = newProviderMonitor()
+
+// newProviderMonitor returns a monitor publishing alerts to the configured topic
+func newProviderMonitor() *alerting.Monitor {
+	if cfg.AlertTopicARN == "" {
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("alerting disabled, aws configuration failed", "error", err)
+		return nil
+	}
+	return &alerting.Monitor{
+		Client:      sns.NewFromConfig(config),
+		Topic:       cfg.AlertTopicARN,
+		FailureRate: cfg.AlertFailureRate,
+		After:       cfg.AlertAfter,
+		Repeat:      cfg.AlertRepeat,
+		Timeout:     cfg.AlertTimeout,
+		Failed: func(alert alerting.Alert, err error) {
+			slog.Warn("publishing provider alert failed", "provider", alert.Provider, "error", err)
+		},
+	}
+}
+
+// observeProvider hands the outcome of a lookup of the provider called name
+// to providerMonitor. Alerts are published in the background, even once the
+// request gave up, since providers failing by timing out are what they are about
+func observeProvider(ctx context.Context, name string, failureRate float64, err error) {
+	var class string
+	if err != nil {
+		class = string(failure.Classify(err))
+	}
+	if err := providerMonitor.Observe(name, failureRate, class); err != nil {
+		logging.FromContext(ctx).Warn("dropping provider alert", "provider", name, "error", err)
+	}
+}
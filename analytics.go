@@ -0,0 +1,66 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/analytics"
+	"alexa-skill-test/src/middleware"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+)
+
+// analyticsBuffer is the number of events waiting to be sent before more are dropped
+const analyticsBuffer = 1000
+
+// analyticsEmitter sends an anonymized event about every request to Firehose,
+// nil when analytics are switched off
+var analyticsEmitter = newAnalyticsEmitter()
+
+// newAnalyticsEmitter returns an emitter sending the events to the configured
+// stream. Analytics stay off without a hash key, since names hashed without
+// one are easily recovered by hashing the common ones
+func newAnalyticsEmitter() *analytics.Emitter {
+	if !cfg.AnalyticsEnabled || cfg.AnalyticsStream == "" {
+		return nil
+	}
+	if cfg.AnalyticsHashKey == "" {
+		slog.Warn("analytics disabled, no hash key configured")
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("analytics disabled, aws configuration failed", "error", err)
+		return nil
+	}
+	return analytics.New(firehose.NewFromConfig(config), cfg.AnalyticsStream, []byte(cfg.AnalyticsHashKey), cfg.AnalyticsInterval, analyticsBuffer)
+}
+
+// analyticsMiddleware emits an event about every request once it was
+// answered, which the handlers fill in through analytics.Annotate
+func analyticsMiddleware(emitter *analytics.Emitter) middleware.Middleware {
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+			if emitter == nil {
+				return next(ctx, request)
+			}
+			start := time.Now()
+			event := &analytics.Event{
+				Time:    start.UTC(),
+				Type:    request.Body.Type,
+				Intent:  request.Body.Intent.Name,
+				Locale:  request.Body.Locale,
+				User:    request.UserID(),
+				Outcome: analytics.OK,
+			}
+			response, err := next(analytics.WithEvent(ctx, event), request)
+			event.LatencyMs = time.Since(start).Milliseconds()
+			if err != nil {
+				event.Outcome = analytics.Rejected
+			}
+			emitter.Emit(*event)
+			return response, err
+		}
+	}
+}
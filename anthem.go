@@ -0,0 +1,78 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/session"
+	"context"
+	"strings"
+)
+
+// anthemURL returns the url of the national anthem of the country having
+// code, from the hosted source configured in cfg.AnthemURL
+func anthemURL(code string) string {
+	return strings.ReplaceAll(cfg.AnthemURL, "{code}", strings.ToLower(code))
+}
+
+// anthemIntentModels declare the intents playing the anthem in the interaction model
+var anthemIntentModels = []model.Intent{
+	{Name: "AnthemIntent", Samples: []string{"play the anthem", "play the national anthem"}},
+	{Name: "AMAZON.PauseIntent"},
+	{Name: "AMAZON.ResumeIntent"},
+}
+
+// HandleAnthemIntent streams the national anthem of the top country of
+// the last guess.
+// A user can say:
+// Alexa, play the anthem
+func HandleAnthemIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	last, _ := session.Load(request.Session.Attributes).LastGuess()
+	code := last.Country
+	if code == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.anthem"), templates.Render("anthem.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+	if cfg.AnthemURL == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.anthem"), templates.Render("anthem.unavailable"))
+	}
+
+	// The session ends so the device can play the stream
+	speech := templates.Render("anthem.playing", "country", findCountryName(templates, nil, code))
+	return alexa.NewSimpleResponse(templates.Render("title.anthem"), speech).
+		WithDirectives(alexa.NewPlayDirective(alexa.PlayReplaceAll, code, anthemURL(code), 0))
+}
+
+// HandlePauseIntent stops the anthem being played, keeping its
+// position so it can be resumed
+func HandlePauseIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	return alexa.NewAudioPlayerResponse(alexa.NewStopDirective())
+}
+
+// HandleResumeIntent plays the anthem that was paused from where it stopped
+func HandleResumeIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	player := request.Context.AudioPlayer
+	if player == nil || player.Token == "" || cfg.AnthemURL == "" {
+		templates := messagesFor(ctx, request)
+		return alexa.NewSimpleResponse(templates.Render("title.anthem"), templates.Render("anthem.none"))
+	}
+	return alexa.NewAudioPlayerResponse(
+		alexa.NewPlayDirective(alexa.PlayReplaceAll, player.Token, anthemURL(player.Token), player.OffsetInMilliseconds),
+	)
+}
+
+// HandleAudioPlayerRequest acknowledges the playback updates of an anthem.
+// Only one anthem is played at a time, so nothing is enqueued when it nearly finishes
+func HandleAudioPlayerRequest(ctx context.Context, request alexa.Request) alexa.Response {
+	switch request.Body.Type {
+	case alexa.PlaybackFailed:
+		logging.FromContext(ctx).Warn("playback of anthem failed", "token", request.Body.Token)
+		return alexa.NewAudioPlayerResponse(alexa.NewClearQueueDirective(alexa.ClearAll))
+	case alexa.PlaybackFinished:
+		return alexa.NewAudioPlayerResponse(alexa.NewClearQueueDirective(alexa.ClearEnqueued))
+	default:
+		return alexa.NewAudioPlayerResponse()
+	}
+}
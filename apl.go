@@ -0,0 +1,51 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/apl"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/nationality"
+)
+
+// buildGuessDirective binds the guesses to the APL document, laid out
+// for the screen described by viewport, so they can be shown alongside
+// the spoken response
+func buildGuessDirective(viewport *alexa.Viewport, fetched countries.Country, predictionsResponse nationality.Response) alexa.Directives {
+	profile := apl.ProfileOf(viewport)
+
+	var items []map[string]interface{}
+	for _, v := range sortPredictions(predictionsResponse.Predictions) {
+		item := map[string]interface{}{
+			"country": "Unknown",
+			"flag":    countries.FlagURL(v.Country_id, profile.FlagWidth()),
+			"emoji":   countries.FlagEmoji(v.Country_id),
+			"percent": int(v.Probability * 100),
+		}
+		if country, ok := findCountryInfo(fetched, v.Country_id); ok {
+			item["country"] = country.Name
+		}
+		items = append(items, item)
+	}
+
+	datasources := map[string]interface{}{
+		"guesses": map[string]interface{}{
+			"title":   "Where you might be from",
+			"profile": profile,
+			"items":   items,
+		},
+	}
+	return alexa.NewAPLDirective("nationalityGuess", apl.GuessDocument, datasources)
+}
+
+// buildGuessAudioDirective binds the spoken guesses, the background music
+// and the sound effect to the APL for Audio document
+func buildGuessAudioDirective(speech string) alexa.Directives {
+	datasources := map[string]interface{}{
+		"guess": map[string]interface{}{
+			"ssml":       speech,
+			"background": cfg.BackgroundAudioURL,
+			"effect":     cfg.GuessSoundURL,
+		},
+	}
+	return alexa.NewAPLADirective("nationalityGuessAudio", apl.GuessAudioDocument, datasources)
+}
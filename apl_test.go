@@ -0,0 +1,54 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/nationality"
+	"testing"
+)
+
+// aplDirectives returns the directives of response rendering an APL document
+func aplDirectives(response alexa.Response) []alexa.Directives {
+	var found []alexa.Directives
+	for _, directive := range response.Body.Directives {
+		if directive.Type == "Alexa.Presentation.APL.RenderDocument" {
+			found = append(found, directive)
+		}
+	}
+	return found
+}
+
+func TestGuessRendersAPLOnScreens(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+	request := intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})
+	request.Context.System.Device.SupportedInterfaces = map[string]interface{}{alexa.APLInterface: map[string]interface{}{"runtime": map[string]interface{}{"maxVersion": "2023.3"}}}
+	request.Context.Viewport = &alexa.Viewport{Shape: "RECTANGLE", PixelWidth: 1280}
+
+	directives := aplDirectives(dispatchIntent(testContext(provider), request))
+	if len(directives) != 1 {
+		t.Fatalf("response has %d APL directives, want 1", len(directives))
+	}
+	guesses := directives[0].Datasources.(map[string]interface{})["guesses"].(map[string]interface{})
+	items := guesses["items"].([]map[string]interface{})
+	if len(items) != 3 || items[0]["country"] != "Germany" || items[0]["percent"] != 45 {
+		t.Errorf("APL items %v, want Germany first at 45 percent", items)
+	}
+}
+
+func TestGuessLeavesAPLOutOnVoiceOnlyDevices(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+	request := intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})
+
+	if directives := aplDirectives(dispatchIntent(testContext(provider), request)); len(directives) != 0 {
+		t.Errorf("voice only device got %d APL directives", len(directives))
+	}
+}
+
+func TestGuessLeavesAPLOutWithoutGuesses(t *testing.T) {
+	provider := &fakeNationality{}
+	request := intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})
+	request.Context.System.Device.SupportedInterfaces = map[string]interface{}{alexa.APLInterface: map[string]interface{}{}}
+
+	if directives := aplDirectives(dispatchIntent(testContext(provider), request)); len(directives) != 0 {
+		t.Errorf("response without guesses got %d APL directives", len(directives))
+	}
+}
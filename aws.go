@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// awsConfig is loaded the first time a store needs it, guarded by awsConfigOnce
+var (
+	awsConfigOnce sync.Once
+	awsConfig     aws.Config
+	awsConfigErr  error
+)
+
+// loadAWSConfig returns the aws configuration of the lambda function,
+// taken from its environment and role, shared by every aws client
+func loadAWSConfig() (aws.Config, error) {
+	awsConfigOnce.Do(func() {
+		awsConfig, awsConfigErr = awsconfig.LoadDefaultConfig(context.Background())
+	})
+	return awsConfig, awsConfigErr
+}
@@ -0,0 +1,136 @@
+// Command golden feeds the canned request envelopes of testdata/golden through
+// a built skill binary and compares the shape of each response to the golden
+// response recorded next to it. TestGolden already replays the cases with go
+// test, so the command is for checking a binary end to end and for recording
+// the golden responses again:
+//
+//	go build -o skill . && go run ./cmd/golden -skill ./skill
+//
+// A case is a pair of files, NAME.request.json holding the envelope Alexa would
+// send and NAME.response.json the expected response. -update records the current
+// responses as the golden ones, to be reviewed like any other change.
+//
+// The skill guesses from its embedded datasets so nothing depends on the upstream
+// apis, unless -fixtures names a directory of upstream responses it replays, which
+// -record fills by sending the requests upstream:
+//
+//	go run ./cmd/golden -skill ./skill -fixtures testdata/fixtures -record -update
+//
+// Only the shape of responses is compared, see package golden
+package main
+
+import (
+	"alexa-skill-test/src/golden"
+	"alexa-skill-test/src/localskill"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	skill := flag.String("skill", "", "skill binary the requests are sent to")
+	dir := flag.String("dir", filepath.Join("testdata", "golden"), "directory holding the cases")
+	run := flag.String("run", "", "only run the cases whose name contains this")
+	update := flag.Bool("update", false, "record the current responses as the golden ones")
+	fixtures := flag.String("fixtures", "", "directory of recorded upstream responses replayed instead of the embedded datasets")
+	record := flag.Bool("record", false, "record the upstream responses to -fixtures instead of replaying them")
+	verbose := flag.Bool("v", false, "show the logs of the skill")
+	flag.Parse()
+	if *skill == "" {
+		fail(fmt.Errorf("-skill is required"))
+	}
+
+	requests, err := filepath.Glob(filepath.Join(*dir, "*.request.json"))
+	if err != nil {
+		fail(err)
+	}
+	if len(requests) == 0 {
+		fail(fmt.Errorf("no cases in %s", *dir))
+	}
+
+	var logs io.Writer
+	if *verbose {
+		logs = os.Stderr
+	}
+	env := localskill.OfflineEnv
+	if *fixtures != "" {
+		absolute, err := filepath.Abs(*fixtures)
+		if err != nil {
+			fail(err)
+		}
+		mode := "replay"
+		if *record {
+			mode = "record"
+		}
+		env = []string{"UPSTREAM_FIXTURES=" + absolute, "UPSTREAM_FIXTURES_MODE=" + mode}
+	}
+	started, err := localskill.Start(*skill, env, logs)
+	if err != nil {
+		fail(err)
+	}
+	defer started.Stop()
+
+	failed := 0
+	for _, request := range requests {
+		name := strings.TrimSuffix(filepath.Base(request), ".request.json")
+		if !strings.Contains(name, *run) {
+			continue
+		}
+		if err := runCase(started.Endpoint, request, strings.TrimSuffix(request, ".request.json")+".response.json", *update); err != nil {
+			failed++
+			fmt.Printf("FAIL %s\n%s\n", name, indent(err.Error()))
+			continue
+		}
+		fmt.Printf("ok   %s\n", name)
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d cases failed\n", failed, len(requests))
+		started.Stop()
+		os.Exit(1)
+	}
+}
+
+// fail reports err and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "golden:", err)
+	os.Exit(1)
+}
+
+// runCase sends the envelope in requestFile to the skill and compares the shape of
+// its response to the golden one in responseFile, or records it there on update
+func runCase(endpoint string, requestFile string, responseFile string, update bool) error {
+	envelope, err := os.ReadFile(requestFile)
+	if err != nil {
+		return err
+	}
+	body, err := localskill.Post(endpoint, envelope)
+	if err != nil {
+		return err
+	}
+	if !update {
+		return golden.Check(responseFile, body)
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return fmt.Errorf("malformed response: %v", err)
+	}
+	var pretty bytes.Buffer
+	encoder := json.NewEncoder(&pretty)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(actual); err != nil {
+		return err
+	}
+	return os.WriteFile(responseFile, pretty.Bytes(), 0o644)
+}
+
+// indent indents every line of text
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
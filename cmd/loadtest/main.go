@@ -0,0 +1,255 @@
+// Command loadtest sends synthetic Alexa traffic to the skill at a steady
+// rate and reports the latency percentiles and the error rates seen, to
+// validate caching and concurrency changes before they reach users:
+//
+//	go run ./cmd/loadtest -target http://localhost:8080/alexa -rate 50 -duration 1m
+//
+// The target is the alexa endpoint of the skill running as an http server or
+// the url of its Lambda function. Either must accept unsigned requests, i.e.
+// run with VERIFY_REQUESTS=false, so never point it at the production skill.
+//
+// Most requests ask to guess a common name, the others open the skill, ask for
+// help or for a surprise, each from one of -users made up users. Responses
+// apologizing for a failure are counted under the support code they carry
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/names"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// scenario is a kind of request sent, picked in proportion to its weight
+type scenario struct {
+	name   string
+	weight int
+	build  func(rng *rand.Rand) alexa.ReqBody
+}
+
+// scenarios is the traffic mix, close to the one seen in production
+var scenarios = []scenario{
+	{name: "guess", weight: 80, build: func(rng *rand.Rand) alexa.ReqBody {
+		return intentBody("GuessIntent", map[string]string{"first_name": names.RandomCommon(rng)})
+	}},
+	{name: "launch", weight: 10, build: func(rng *rand.Rand) alexa.ReqBody {
+		return alexa.ReqBody{Type: alexa.LaunchRequest}
+	}},
+	{name: "surprise", weight: 5, build: func(rng *rand.Rand) alexa.ReqBody {
+		return intentBody("SurpriseIntent", nil)
+	}},
+	{name: "help", weight: 5, build: func(rng *rand.Rand) alexa.ReqBody {
+		return intentBody(alexa.HelpIntent, nil)
+	}},
+}
+
+// errorCode matches the support code failures are reported with
+var errorCode = regexp.MustCompile(`Error code (\d+)`)
+
+// result is the outcome of a single request
+type result struct {
+	scenario string
+	latency  time.Duration
+	// failure is why the request failed, empty when it succeeded
+	failure string
+}
+
+func main() {
+	target := flag.String("target", "", "alexa endpoint or Lambda function url of the skill")
+	rate := flag.Float64("rate", 10, "requests sent per second")
+	duration := flag.Duration("duration", 30*time.Second, "how long requests are sent for")
+	concurrency := flag.Int("concurrency", 100, "most requests in flight, requests over it are skipped and reported")
+	users := flag.Int("users", 1000, "number of made up users sending requests")
+	locale := flag.String("locale", "en-US", "locale of the requests, error codes are only recognized in english")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each request")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the traffic generated")
+	flag.Parse()
+	if *target == "" || *rate <= 0 {
+		fmt.Fprintln(os.Stderr, "loadtest: -target and a positive -rate are required")
+		os.Exit(2)
+	}
+
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency, IdleConnTimeout: 90 * time.Second},
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	inFlight := make(chan struct{}, *concurrency)
+	results := make(chan result, *concurrency)
+	var wg sync.WaitGroup
+
+	var collected []result
+	done := make(chan struct{})
+	go func() {
+		for r := range results {
+			collected = append(collected, r)
+		}
+		close(done)
+	}()
+
+	skipped := 0
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+	defer ticker.Stop()
+	started := time.Now()
+	for sent := 0; time.Since(started) < *duration; sent++ {
+		<-ticker.C
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			skipped++
+			continue
+		}
+		picked := pick(rng)
+		envelope := buildEnvelope(picked.build(rng), *locale, fmt.Sprintf("loadtest.user.%d", rng.Intn(*users)), sent)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			results <- send(client, *target, picked.name, envelope)
+		}()
+	}
+	wg.Wait()
+	close(results)
+	<-done
+
+	report(os.Stdout, collected, skipped, time.Since(started))
+}
+
+// intentBody returns the body of a request for intent with slots
+func intentBody(intent string, slots map[string]string) alexa.ReqBody {
+	body := alexa.ReqBody{Type: "IntentRequest", Intent: alexa.Intent{Name: intent, Slots: map[string]alexa.Slot{}}}
+	for name, value := range slots {
+		body.Intent.Slots[name] = alexa.Slot{Name: name, Value: value, ConfirmationStatus: alexa.ConfirmationNone}
+	}
+	return body
+}
+
+// pick returns a scenario at random, in proportion to their weights
+func pick(rng *rand.Rand) scenario {
+	total := 0
+	for _, s := range scenarios {
+		total += s.weight
+	}
+	n := rng.Intn(total)
+	for _, s := range scenarios {
+		if n < s.weight {
+			return s
+		}
+		n -= s.weight
+	}
+	return scenarios[0]
+}
+
+// buildEnvelope returns the json of a request of a new session of userID
+func buildEnvelope(body alexa.ReqBody, locale string, userID string, n int) []byte {
+	var request alexa.Request
+	request.Version = "1.0"
+	request.Session.New = true
+	request.Session.SessionID = "loadtest.session." + strconv.Itoa(n)
+	request.Session.User.UserID = userID
+	request.Context.System.User.UserID = userID
+	request.Context.System.Device.DeviceID = "loadtest.device"
+	request.Body = body
+	request.Body.RequestID = "loadtest.request." + strconv.Itoa(n)
+	request.Body.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	request.Body.Locale = locale
+	envelope, _ := json.Marshal(request)
+	return envelope
+}
+
+// send posts envelope to target and classifies the outcome
+func send(client *http.Client, target string, name string, envelope []byte) result {
+	start := time.Now()
+	outcome := result{scenario: name}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(envelope))
+	if err != nil {
+		outcome.latency = time.Since(start)
+		outcome.failure = "transport"
+		return outcome
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	outcome.latency = time.Since(start)
+	switch {
+	case err != nil:
+		outcome.failure = "transport"
+	case resp.StatusCode != http.StatusOK:
+		outcome.failure = "http " + strconv.Itoa(resp.StatusCode)
+	default:
+		var response alexa.Response
+		if err := json.Unmarshal(body, &response); err != nil {
+			outcome.failure = "malformed response"
+		} else if card := response.Body.Card; card != nil {
+			if match := errorCode.FindStringSubmatch(card.Content); match != nil {
+				outcome.failure = "error code " + match[1]
+			}
+		}
+	}
+	return outcome
+}
+
+// report writes the latency percentiles of every scenario and the failures to w
+func report(w io.Writer, results []result, skipped int, elapsed time.Duration) {
+	fmt.Fprintf(w, "%d requests in %s (%.1f per second), %d skipped over the concurrency limit\n\n",
+		len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds(), skipped)
+
+	byScenario := map[string][]time.Duration{"all": nil}
+	failures := map[string]int{}
+	for _, r := range results {
+		byScenario[r.scenario] = append(byScenario[r.scenario], r.latency)
+		byScenario["all"] = append(byScenario["all"], r.latency)
+		if r.failure != "" {
+			failures[r.failure]++
+		}
+	}
+
+	var keys []string
+	for key := range byScenario {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "%-10s %8s %8s %8s %8s %8s\n", "scenario", "count", "p50", "p90", "p99", "max")
+	for _, key := range keys {
+		latencies := byScenario[key]
+		if len(latencies) == 0 {
+			continue
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Fprintf(w, "%-10s %8d %8s %8s %8s %8s\n", key, len(latencies),
+			percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), latencies[len(latencies)-1].Round(time.Millisecond))
+	}
+
+	fmt.Fprintln(w)
+	if len(failures) == 0 {
+		fmt.Fprintln(w, "no failures")
+		return
+	}
+	var kinds []string
+	for kind := range failures {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "%-20s %6d  %5.1f%%\n", kind, failures[kind], float64(failures[kind])*100/float64(len(results)))
+	}
+}
+
+// percentile returns the p-th percentile of the sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index].Round(time.Millisecond)
+}
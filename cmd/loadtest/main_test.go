@@ -0,0 +1,148 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScenariosBuildValidEnvelopes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n, s := range scenarios {
+		envelope := buildEnvelope(s.build(rng), "en-US", "loadtest.user.7", n)
+
+		// the fields the skill relies on must be there under their Alexa names
+		var raw map[string]interface{}
+		if err := json.Unmarshal(envelope, &raw); err != nil {
+			t.Fatalf("%s: envelope isn't json: %v", s.name, err)
+		}
+		for _, path := range [][2]string{{"session", "sessionId"}, {"session", "user"}, {"session", "new"}, {"request", "type"}, {"request", "requestId"}, {"request", "timestamp"}, {"request", "locale"}, {"context", "System"}} {
+			section, _ := raw[path[0]].(map[string]interface{})
+			if _, ok := section[path[1]]; !ok {
+				t.Errorf("%s: envelope lacks %s.%s: %s", s.name, path[0], path[1], envelope)
+			}
+		}
+
+		var request alexa.Request
+		if err := json.Unmarshal(envelope, &request); err != nil {
+			t.Fatalf("%s: envelope isn't an Alexa request: %v", s.name, err)
+		}
+		if request.Version != "1.0" || !request.Session.New || request.Body.Locale != "en-US" {
+			t.Errorf("%s: request %+v", s.name, request)
+		}
+		if request.Session.User.UserID != "loadtest.user.7" || request.Context.System.User.UserID != "loadtest.user.7" {
+			t.Errorf("%s: users %q and %q", s.name, request.Session.User.UserID, request.Context.System.User.UserID)
+		}
+		if _, err := time.Parse(time.RFC3339, request.Body.Timestamp); err != nil {
+			t.Errorf("%s: timestamp %q: %v", s.name, request.Body.Timestamp, err)
+		}
+		switch request.Body.Type {
+		case alexa.LaunchRequest:
+		case "IntentRequest":
+			if request.Body.Intent.Name == "" {
+				t.Errorf("%s: intent request without an intent", s.name)
+			}
+		default:
+			t.Errorf("%s: request type %q", s.name, request.Body.Type)
+		}
+	}
+}
+
+func TestGuessScenarioAsksAboutAName(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	body := scenarios[0].build(rng)
+	if slot := body.Intent.Slots["first_name"]; body.Intent.Name != "GuessIntent" || slot.Name != "first_name" || slot.Value == "" {
+		t.Errorf("guess body %+v", body)
+	}
+}
+
+func TestEnvelopesAreToldApart(t *testing.T) {
+	first := buildEnvelope(intentBody(alexa.HelpIntent, nil), "en-US", "loadtest.user.1", 1)
+	second := buildEnvelope(intentBody(alexa.HelpIntent, nil), "en-US", "loadtest.user.1", 2)
+	var a, b alexa.Request
+	json.Unmarshal(first, &a)
+	json.Unmarshal(second, &b)
+	if a.Session.SessionID == b.Session.SessionID || a.Body.RequestID == b.Body.RequestID {
+		t.Errorf("two envelopes share their session or request id")
+	}
+}
+
+func TestPickFollowsWeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[pick(rng).name]++
+	}
+	for _, s := range scenarios {
+		if want := s.weight * 100; counts[s.name] < want*8/10 || counts[s.name] > want*12/10 {
+			t.Errorf("%s picked %d times of 10000, want about %d", s.name, counts[s.name], want)
+		}
+	}
+}
+
+func TestSendClassifiesOutcomes(t *testing.T) {
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		want    string
+	}{
+		"success": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"version":"1.0","response":{"outputSpeech":{"type":"SSML","ssml":"<speak>hi</speak>"}}}`))
+			},
+		},
+		"error code": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"version":"1.0","response":{"card":{"type":"Simple","title":"Guess","content":"Sorry. Error code 102."}}}`))
+			},
+			want: "error code 102",
+		},
+		"status": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadRequest) },
+			want:    "http 400",
+		},
+		"malformed": {
+			handler: func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`<html>`)) },
+			want:    "malformed response",
+		},
+	}
+	envelope := buildEnvelope(intentBody(alexa.HelpIntent, nil), "en-US", "loadtest.user.1", 1)
+	for name, test := range tests {
+		server := httptest.NewServer(test.handler)
+		outcome := send(server.Client(), server.URL, "help", envelope)
+		server.Close()
+		if outcome.failure != test.want || outcome.scenario != "help" {
+			t.Errorf("%s: outcome %+v, want failure %q", name, outcome, test.want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	results := []result{
+		{scenario: "guess", latency: 10 * time.Millisecond},
+		{scenario: "guess", latency: 30 * time.Millisecond, failure: "error code 101"},
+		{scenario: "help", latency: 20 * time.Millisecond},
+	}
+	var out bytes.Buffer
+	report(&out, results, 2, time.Second)
+	for _, want := range []string{"3 requests in 1s", "2 skipped", "guess", "help", "error code 101"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report doesn't mention %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}
+	tests := map[int]time.Duration{50: 2 * time.Millisecond, 90: 4 * time.Millisecond, 99: 4 * time.Millisecond, 0: 1 * time.Millisecond}
+	for p, want := range tests {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile %d = %s, want %s", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Command model generates the interaction model of the skill from the
+// model.Intent and model.SlotType declarations next to its handlers,
+// to be imported in the Alexa developer console or deployed with the ask cli:
+//
+//	go run ./cmd/model -o models/en-US.json
+//
+// The declarations are read from the source of the skill without running it,
+// so they must be plain literals: strings, slices of strings and nested model
+// literals. Constants and function calls aren't evaluated and fail the generation.
+// The FirstName and Demonym slot types are too large to declare that way, so
+// they are generated from the catalog of the names package and the embedded
+// countries instead
+package main
+
+import (
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// modelPackage is the import path of the package the declarations are typed with
+const modelPackage = "alexa-skill-test/src/model"
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the skill source holding the declarations")
+	invocation := flag.String("invocation", "nationality guesser", "invocation name of the skill")
+	output := flag.String("o", "", "file the model is written to, standard output when empty")
+	flag.Parse()
+
+	intents, types, err := readDeclarations(*dir)
+	if err != nil {
+		fail(err)
+	}
+	types = append(types, catalogSlotType(), demonymSlotType())
+	if err := model.Check(intents, types); err != nil {
+		fail(err)
+	}
+	data, err := json.MarshalIndent(model.Build(*invocation, intents, types), "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+// fail reports err and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "model:", err)
+	os.Exit(1)
+}
+
+// catalogSlotType returns the slot type of first names, each resolved from
+// its synonyms so entity resolution gives the spelling the catalog knows
+func catalogSlotType() model.SlotType {
+	slotType := model.SlotType{Name: names.SlotType}
+	for _, entry := range names.Catalog {
+		slotType.Values = append(slotType.Values, model.Value{ID: entry.Name, Name: entry.Name, Synonyms: entry.Synonyms})
+	}
+	return slotType
+}
+
+// demonymSlotType returns the slot type of the words for the people of
+// every country, each value its own id
+func demonymSlotType() model.SlotType {
+	slotType := model.SlotType{Name: countries.DemonymSlotType}
+	for _, demonym := range countries.Embedded.Demonyms() {
+		slotType.Values = append(slotType.Values, model.Value{ID: demonym, Name: demonym})
+	}
+	return slotType
+}
+
+// readDeclarations returns the intents and slot types declared
+// by the package level variables of the go files in dir
+func readDeclarations(dir string) ([]model.Intent, []model.SlotType, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+	fset := token.NewFileSet()
+	var intents []model.Intent
+	var types []model.SlotType
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		alias, ok := importName(file)
+		if !ok {
+			continue
+		}
+		reader := literalReader{fset: fset, alias: alias}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, value := range spec.(*ast.ValueSpec).Values {
+					switch reader.typeName(value) {
+					case "Intent", "[]Intent":
+						target := reflect.New(reflect.TypeOf(intents)).Elem()
+						if err := reader.readInto(value, target); err != nil {
+							return nil, nil, err
+						}
+						intents = append(intents, target.Interface().([]model.Intent)...)
+					case "SlotType", "[]SlotType":
+						target := reflect.New(reflect.TypeOf(types)).Elem()
+						if err := reader.readInto(value, target); err != nil {
+							return nil, nil, err
+						}
+						types = append(types, target.Interface().([]model.SlotType)...)
+					}
+				}
+			}
+		}
+	}
+	return intents, types, nil
+}
+
+// importName returns the name file refers to the model package by, if it imports it
+func importName(file *ast.File) (string, bool) {
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == modelPackage {
+			if spec.Name != nil {
+				return spec.Name.Name, true
+			}
+			return "model", true
+		}
+	}
+	return "", false
+}
+
+// literalReader decodes the literals of a file importing the model package as alias
+type literalReader struct {
+	fset  *token.FileSet
+	alias string
+}
+
+// typeName returns the name of the model type expr is a literal of, e.g. "Intent"
+// or "[]Intent", or an empty string when it isn't a literal of a model type
+func (reader literalReader) typeName(expr ast.Expr) string {
+	literal, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return ""
+	}
+	prefix := ""
+	typ := literal.Type
+	if array, ok := typ.(*ast.ArrayType); ok && array.Len == nil {
+		prefix, typ = "[]", array.Elt
+	}
+	selector, ok := typ.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if pkg, ok := selector.X.(*ast.Ident); !ok || pkg.Name != reader.alias {
+		return ""
+	}
+	return prefix + selector.Sel.Name
+}
+
+// readInto decodes the literal expr into target. A single literal read into
+// a slice is appended to it, so single declarations and lists read alike
+func (reader literalReader) readInto(expr ast.Expr, target reflect.Value) error {
+	switch target.Kind() {
+	case reflect.String:
+		literal, ok := expr.(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			return reader.errorf(expr, "expected a string literal")
+		}
+		value, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			return reader.errorf(expr, "%v", err)
+		}
+		target.SetString(value)
+		return nil
+
+	case reflect.Slice:
+		literal, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return reader.errorf(expr, "expected a literal")
+		}
+		elements := literal.Elts
+		if _, isArray := literal.Type.(*ast.ArrayType); literal.Type != nil && !isArray {
+			elements = []ast.Expr{literal}
+		}
+		for _, element := range elements {
+			item := reflect.New(target.Type().Elem()).Elem()
+			if err := reader.readInto(element, item); err != nil {
+				return err
+			}
+			target.Set(reflect.Append(target, item))
+		}
+		return nil
+
+	case reflect.Struct:
+		literal, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return reader.errorf(expr, "expected a literal")
+		}
+		for _, element := range literal.Elts {
+			pair, ok := element.(*ast.KeyValueExpr)
+			if !ok {
+				return reader.errorf(element, "fields must be named")
+			}
+			key, ok := pair.Key.(*ast.Ident)
+			if !ok {
+				return reader.errorf(pair.Key, "expected a field name")
+			}
+			field := target.FieldByName(key.Name)
+			if !field.IsValid() {
+				return reader.errorf(pair.Key, "%s has no field %s", target.Type().Name(), key.Name)
+			}
+			if err := reader.readInto(pair.Value, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return reader.errorf(expr, "unsupported %s field", target.Kind())
+}
+
+// errorf returns an error located at expr
+func (reader literalReader) errorf(expr ast.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", reader.fset.Position(expr.Pos()), fmt.Sprintf(format, args...))
+}
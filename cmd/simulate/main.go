@@ -0,0 +1,198 @@
+// Command simulate sends a single intent to the skill and prints what Alexa
+// would say and show in response, so changes can be tried out without
+// deploying the skill or using the Alexa developer console.
+//
+// The skill is started from the binary given with -skill, serving http on a
+// free local port, or an already running one is called at -addr:
+//
+//	go build -o skill . && go run ./cmd/simulate -skill ./skill -slot first_name=Ethan
+//	go run ./cmd/simulate -addr http://localhost:8080/alexa -intent SurnameIntent -slot last_name=Okafor
+//
+// -offline makes a started skill guess from its embedded datasets instead of the
+// real upstream apis. The intent can be read from a json file instead of flags:
+//
+//	{"intent": "GuessIntent", "slots": {"first_name": "Ethan"}, "locale": "de-DE"}
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/localskill"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Simulation is the intent sent to the skill, as read from a json file
+type Simulation struct {
+	// Intent is the name of the intent, a launch request is sent when empty
+	Intent string            `json:"intent"`
+	Slots  map[string]string `json:"slots"`
+	Locale string            `json:"locale"`
+	// Attributes are the session attributes, e.g. to continue a dialog
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// slotFlags collects the name=value pairs of the repeated -slot flag
+type slotFlags map[string]string
+
+func (slots slotFlags) String() string {
+	var pairs []string
+	for name, value := range slots {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (slots slotFlags) Set(pair string) error {
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("slot %q isn't of the form name=value", pair)
+	}
+	slots[name] = value
+	return nil
+}
+
+func main() {
+	slots := slotFlags{}
+	intent := flag.String("intent", "GuessIntent", "name of the intent sent, a launch request is sent when empty")
+	flag.Var(slots, "slot", "slot of the intent as name=value, may be repeated")
+	locale := flag.String("locale", "en-US", "locale of the request")
+	file := flag.String("file", "", "json file holding the intent, slots and locale, replacing the flags")
+	addr := flag.String("addr", "http://localhost:8080/alexa", "alexa endpoint of a running skill, used when -skill isn't given")
+	skill := flag.String("skill", "", "skill binary started to handle the request")
+	offline := flag.Bool("offline", false, "make the started skill use its embedded datasets instead of the upstream apis")
+	verbose := flag.Bool("v", false, "show the logs of the started skill")
+	raw := flag.Bool("json", false, "print the whole json response")
+	flag.Parse()
+
+	simulation := Simulation{Intent: *intent, Slots: slots, Locale: *locale}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			fail(err)
+		}
+		simulation = Simulation{Locale: *locale}
+		if err := json.Unmarshal(data, &simulation); err != nil {
+			fail(fmt.Errorf("reading %s: %w", *file, err))
+		}
+	}
+
+	endpoint := *addr
+	if *skill != "" {
+		var env []string
+		if *offline {
+			env = localskill.OfflineEnv
+		}
+		var logs io.Writer
+		if *verbose {
+			logs = os.Stderr
+		}
+		started, err := localskill.Start(*skill, env, logs)
+		if err != nil {
+			fail(err)
+		}
+		defer started.Stop()
+		endpoint = started.Endpoint
+	}
+
+	envelope, err := json.Marshal(buildRequest(simulation))
+	if err != nil {
+		fail(err)
+	}
+	body, err := localskill.Post(endpoint, envelope)
+	if err != nil {
+		fail(err)
+	}
+	if *raw {
+		os.Stdout.Write(body)
+		fmt.Println()
+		return
+	}
+	var response alexa.Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		fail(err)
+	}
+	printResponse(os.Stdout, response)
+}
+
+// fail reports err and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "simulate:", err)
+	os.Exit(1)
+}
+
+// buildRequest returns the request Alexa would send for simulation,
+// from a new session of a made up user
+func buildRequest(simulation Simulation) alexa.Request {
+	var request alexa.Request
+	request.Version = "1.0"
+	request.Session.New = len(simulation.Attributes) == 0
+	request.Session.SessionID = "simulate.session"
+	request.Session.Attributes = simulation.Attributes
+	request.Session.User.UserID = "simulate.user"
+	request.Context.System.User.UserID = "simulate.user"
+	request.Context.System.Device.DeviceID = "simulate.device"
+	request.Body.RequestID = fmt.Sprintf("simulate.%d", time.Now().UnixNano())
+	request.Body.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	request.Body.Locale = simulation.Locale
+
+	if simulation.Intent == "" {
+		request.Body.Type = alexa.LaunchRequest
+		return request
+	}
+	request.Body.Type = "IntentRequest"
+	request.Body.Intent = alexa.Intent{Name: simulation.Intent, ConfirmationStatus: alexa.ConfirmationNone, Slots: map[string]alexa.Slot{}}
+	for name, value := range simulation.Slots {
+		request.Body.Intent.Slots[name] = alexa.Slot{Name: name, Value: value, ConfirmationStatus: alexa.ConfirmationNone}
+	}
+	return request
+}
+
+// printResponse writes what the user would hear and see to w
+func printResponse(w io.Writer, response alexa.Response) {
+	if speech := response.Body.OutputSpeech; speech != nil {
+		fmt.Fprintf(w, "speech:    %s\n", spoken(*speech))
+	}
+	if reprompt := response.Body.Reprompt; reprompt != nil {
+		fmt.Fprintf(w, "reprompt:  %s\n", spoken(reprompt.OutputSpeech))
+	}
+	if card := response.Body.Card; card != nil {
+		content := card.Content
+		if content == "" {
+			content = card.Text
+		}
+		if len(card.Permissions) > 0 {
+			content = "asks for " + strings.Join(card.Permissions, ", ")
+		}
+		fmt.Fprintf(w, "card:      %s: %s\n", card.Title, strings.ReplaceAll(content, "\n", "; "))
+	}
+	for _, directive := range response.Body.Directives {
+		fmt.Fprintf(w, "directive: %s\n", directive.Type)
+	}
+	if len(response.SessionAttributes) > 0 {
+		var keys []string
+		for key := range response.SessionAttributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "session:   %s\n", strings.Join(keys, ", "))
+	}
+	if response.Body.ShouldEndSession {
+		fmt.Fprintln(w, "ends the session")
+	} else {
+		fmt.Fprintln(w, "keeps the session open")
+	}
+}
+
+// spoken returns the ssml or the plain text of speech
+func spoken(speech alexa.Payload) string {
+	if speech.Type == "SSML" {
+		return speech.SSML
+	}
+	return speech.Text
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/session"
+	"context"
+	"sort"
+	"strconv"
+)
+
+// whichContinentIntentModel declares WhichContinentIntent in the interaction model
+var whichContinentIntentModel = model.Intent{
+	Name: "WhichContinentIntent",
+	Samples: []string{
+		"so which continent am I probably from",
+		"which continent am I from",
+		"which continent is that",
+		"what continent is it from",
+	},
+}
+
+// continentShare is the summed probability of the guesses on a continent
+type continentShare struct {
+	Continent   string
+	Probability float64
+}
+
+// HandleWhichContinentIntent follows up on the last guess, summing the
+// probabilities of its countries by continent. The guesses were looked up
+// moments ago, so they are usually answered from the lookup cache.
+// A user can say:
+// Alexa, so which continent am I probably from?
+func HandleWhichContinentIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	last, _ := session.Load(request.Session.Attributes).LastGuess()
+	if last.Name == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.continent"), templates.Render("continent.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+
+	predictionsResponse, fetched, err := fetchGuesses(ctx, last.Name)
+	if err != nil {
+		logging.FromContext(ctx).Error("nationality guess failed", logging.NameKey, last.Name, "error", err)
+		return respondWithError(ctx, templates, "title.continent", err)
+	}
+
+	var builder alexa.SSMLBuilder
+	builder.Say(buildContinentSummary(templates, formatsFor(request), last.Name, sumByContinent(fetched, predictionsResponse.Predictions)))
+	builder.Pause("500")
+	builder.Say(templates.Render("guess.followup"))
+	return alexa.NewSSMLResponse(templates.Render("title.continent"), builder.Build()).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// sumByContinent returns the summed probability of the predictions on each
+// continent, from the most to the least likely. Countries of unknown
+// continents are left out
+func sumByContinent(fetched countries.Country, predictions []nationality.Prediction) []continentShare {
+	sums := map[string]float64{}
+	for _, v := range predictions {
+		country, ok := findCountryInfo(fetched, v.Country_id)
+		if !ok || country.Continent() == "" {
+			continue
+		}
+		sums[country.Continent()] += v.Probability
+	}
+
+	shares := make([]continentShare, 0, len(sums))
+	for continent, probability := range sums {
+		shares = append(shares, continentShare{Continent: continent, Probability: probability})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Probability != shares[j].Probability {
+			return shares[i].Probability > shares[j].Probability
+		}
+		return shares[i].Continent < shares[j].Continent
+	})
+	return shares
+}
+
+// buildContinentSummary announces the most likely continent of name,
+// followed by the others
+func buildContinentSummary(templates messages.Set, formats format.Formatter, name string, shares []continentShare) string {
+	if len(shares) == 0 {
+		return templates.Render("continent.unknown", "name", name)
+	}
+	summary := templates.Render("continent.result",
+		"name", name,
+		"continent", templates.Render("continent."+shares[0].Continent),
+		"percent", strconv.Itoa(int(shares[0].Probability*100)))
+	if len(shares) == 1 {
+		return summary
+	}
+
+	var others []string
+	for _, share := range shares[1:] {
+		others = append(others, templates.Render("continent.item",
+			"continent", templates.Render("continent."+share.Continent),
+			"percent", strconv.Itoa(int(share.Probability*100))))
+	}
+	return summary + " " + templates.Render("continent.others", "others", formats.List(others))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/analytics"
+	"alexa-skill-test/src/failure"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/names"
+	"context"
+)
+
+// guessNationalityAPI is the name of the api the Alexa Conversations model
+// declares for guessing nationalities, taking the name as its "name" argument
+const guessNationalityAPI = "GuessNationality"
+
+// guessAPIResult is what the GuessNationality api returns to the response
+// templates of the dialog
+type guessAPIResult struct {
+	Name string `json:"name"`
+	// Guessed reports whether any country was guessed for the name
+	Guessed   bool              `json:"guessed"`
+	Countries []guessAPICountry `json:"countries"`
+	// Error is the kind of failure keeping the name from being guessed, if any
+	Error string `json:"error,omitempty"`
+}
+
+// guessAPICountry is one country guessed, the most likely first
+type guessAPICountry struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Demonym string `json:"demonym"`
+	Percent int    `json:"percent"`
+}
+
+// HandleAPIInvocation answers the apis invoked by the Alexa Conversations
+// dialogs of the skill. The dialogs speak the results through their own
+// response templates, so nothing is spoken here
+func HandleAPIInvocation(ctx context.Context, request alexa.Request) alexa.Response {
+	api := *request.Body.APIRequest
+	switch api.Name {
+	case guessNationalityAPI:
+		return alexa.NewAPIResponse(invokeGuessAPI(ctx, request, api.Argument("name"))).
+			WithSessionAttributes(copySessionAttributes(request))
+	default:
+		logging.FromContext(ctx).Warn("unknown conversations api", "api", api.Name)
+		return alexa.NewAPIResponse(nil)
+	}
+}
+
+// invokeGuessAPI guesses the nationality of name the way HandleGuessIntent
+// does, returning the guesses as data rather than speech
+func invokeGuessAPI(ctx context.Context, request alexa.Request, name string) guessAPIResult {
+	name = names.Sanitize(name)
+	result := guessAPIResult{Name: name, Countries: []guessAPICountry{}}
+	if name == "" {
+		result.Error = string(failure.NameNotRecognized)
+		return result
+	}
+	if blocklist.Contains(name) {
+		logging.FromContext(ctx).Info("refusing to guess blocked name", logging.NameKey, name)
+		result.Error = "blocked"
+		return result
+	}
+
+	analytics.Annotate(ctx, func(event *analytics.Event) { event.Name = name })
+	predictionsResponse, fetched, err := fetchGuesses(ctx, name)
+	if err != nil {
+		kind := failure.Classify(err)
+		logging.FromContext(ctx).Warn("conversations nationality guess failed", "kind", string(kind), "error", err)
+		analytics.Annotate(ctx, func(event *analytics.Event) { event.Outcome = string(kind) })
+		result.Error = string(kind)
+		return result
+	}
+	predictions, _ := adjustPredictions(ctx, request, predictionsResponse.Predictions)
+	saveGuess(ctx, request, name, predictions)
+
+	templates := messagesFor(ctx, request)
+	for _, v := range sortPredictions(predictions) {
+		result.Countries = append(result.Countries, guessAPICountry{
+			Code:    v.Country_id,
+			Name:    findCountryName(templates, fetched, v.Country_id),
+			Demonym: findCountryOfCode(fetched, v.Country_id),
+			Percent: int(v.Probability * 100),
+		})
+	}
+	result.Guessed = len(result.Countries) > 0
+	if result.Guessed {
+		top := result.Countries[0].Code
+		analytics.Annotate(ctx, func(event *analytics.Event) { event.TopCountry = top })
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// debugIntentModel declares DebugIntent in the interaction model
+var debugIntentModel = model.Intent{
+	Name:    "DebugIntent",
+	Samples: []string{"run diagnostics", "debug report"},
+}
+
+// isDebugUser reports whether the speaker of request may hear the debug report
+func isDebugUser(request alexa.Request) bool {
+	for _, id := range cfg.DebugUserIDs {
+		if id != "" && id == request.UserID() {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleDebugIntent reports the build running, the health of the providers,
+// the hit rate of the lookup cache and a summary of the configuration, so live
+// issues can be diagnosed from a device. Only the developer accounts listed in
+// cfg.DebugUserIDs get the report, everyone else is answered as for an unknown
+// intent. The report is meant for developers so it isn't localized.
+// A developer can say:
+// Alexa, ask nationality guesser to run diagnostics
+func HandleDebugIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	if !isDebugUser(request) {
+		return HandleAboutIntent(ctx, request)
+	}
+
+	version, commit := buildVersion()
+	speech := []string{fmt.Sprintf("Version %s, commit %s.", version, shortCommit(commit))}
+	card := []string{"Version: " + version, "Commit: " + commit}
+
+	health := providerHealth(ctx)
+	if len(health) == 0 {
+		speech = append(speech, "Provider health isn't tracked with a single provider.")
+	}
+	var providers []string
+	for name := range health {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	for _, name := range providers {
+		percent := int(health[name] * 100)
+		speech = append(speech, fmt.Sprintf("%s fails %d percent of the time.", name, percent))
+		card = append(card, fmt.Sprintf("Failure rate of %s: %d%%", name, percent))
+	}
+	if remaining, known := nationalizeQuota.Remaining(); known {
+		speech = append(speech, fmt.Sprintf("%d nationalize requests left today.", remaining))
+		card = append(card, fmt.Sprintf("Nationalize quota left: %d", remaining))
+	}
+
+	hits, misses := lookupCache.Stats()
+	if lookups := hits + misses; lookups > 0 {
+		rate := int(hits * 100 / lookups)
+		speech = append(speech, fmt.Sprintf("The cache answered %d percent of %d lookups.", rate, lookups))
+		card = append(card, fmt.Sprintf("Cache hit rate: %d%% of %d lookups", rate, lookups))
+	} else {
+		speech = append(speech, "Nothing was looked up yet.")
+	}
+
+	card = append(card, configSummary()...)
+	return alexa.NewSimpleResponse("Diagnostics", strings.Join(speech, " ")).
+		WithCard(alexa.NewSimpleCard("Diagnostics", strings.Join(card, "\n"))).
+		WithShouldEndSession(false)
+}
+
+// buildVersion returns the version of the module and the commit it was built from,
+// as recorded by the go toolchain, or "unknown" when they weren't
+func buildVersion() (string, string) {
+	version, commit := "unknown", "unknown"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version, commit
+	}
+	if info.Main.Version != "" {
+		version = info.Main.Version
+	}
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			commit = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if modified {
+		commit += " (modified)"
+	}
+	return version, commit
+}
+
+// shortCommit returns the first characters of commit, which are enough to find it
+func shortCommit(commit string) string {
+	if len(commit) > 7 && !strings.HasPrefix(commit, "unknown") {
+		return commit[:7]
+	}
+	return commit
+}
+
+// providerHealth returns the recent failure rate of each nationality
+// provider, empty when a single provider is used and none is tracked
+func providerHealth(ctx context.Context) map[string]float64 {
+	provider := providersFrom(ctx).nationality
+	if shared, ok := provider.(*nationality.Shared); ok {
+		provider = shared.Provider
+	}
+	if fallback, ok := provider.(*nationality.Fallback); ok {
+		return fallback.Health()
+	}
+	return nil
+}
+
+// configSummary returns the settings most likely to explain a live issue, one per line
+func configSummary() []string {
+	orNone := func(value string) string {
+		if value == "" {
+			return "none"
+		}
+		return value
+	}
+	return []string{
+		"Nationality provider: " + cfg.NationalityProvider,
+		"Fallback provider: " + orNone(cfg.NationalityFallback),
+		"Ensemble: " + orNone(strings.Join(cfg.EnsembleProviders, ", ")),
+		fmt.Sprintf("Offline fallback: %t", cfg.OfflineFallback),
+		fmt.Sprintf("Countries api disabled: %t", cfg.DisableCountryAPI),
+		fmt.Sprintf("Upstream timeout: %s, %d attempts", cfg.UpstreamTimeout, cfg.RetryAttempts),
+		"Lookup cache ttl: " + cfg.LookupCacheTTL.String(),
+		"History table: " + orNone(cfg.HistoryTable),
+		"Preferences table: " + orNone(cfg.PreferencesTable),
+		"Friends table: " + orNone(cfg.FriendsTable),
+		"Leaderboard table: " + orNone(cfg.LeaderboardTable),
+		"Log level: " + cfg.LogLevel,
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/flags"
+	"alexa-skill-test/src/model"
+	"context"
+)
+
+// demonymIntentModel declares DemonymIntent in the interaction model
+var demonymIntentModel = model.Intent{
+	Name:  "DemonymIntent",
+	Slots: []model.Slot{{Name: "demonym", Type: "Demonym"}},
+	Samples: []string{
+		"which country are {demonym} people from",
+		"which country are {demonym} from",
+		"where are {demonym} people from",
+		"where do {demonym} people come from",
+		"what country do {demonym} people come from",
+	},
+}
+
+// HandleDemonymIntent tells which country the people called by a demonym
+// come from, searching the embedded countries, followed by a fact about it.
+// A user can say:
+// Alexa, ask nationality guesser which country are Flemish people from
+func HandleDemonymIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	slot := request.Body.Intent.Slots["demonym"]
+	demonym := slot.ResolvedName(countries.DemonymSlotType)
+	if demonym == "" {
+		demonym = slot.Value
+	}
+
+	known, found := countries.Embedded.ByDemonym(demonym)
+	if known != "" {
+		demonym = known
+	}
+	var builder alexa.SSMLBuilder
+	switch len(found) {
+	case 0:
+		builder.Say(templates.Render("demonym.unknown", "demonym", demonym))
+	case 1:
+		builder.Say(templates.Render("demonym.country", "demonym", demonym, "country", findCountryName(templates, nil, found[0].Code)))
+		builder.Say(buildCountryFact(templates, request.Body.Locale, found[0].Code, featureFlags.Enabled(ctx, flags.PremiumFacts)))
+	default:
+		var names []string
+		for _, country := range found {
+			names = append(names, findCountryName(templates, nil, country.Code))
+		}
+		builder.Say(templates.Render("demonym.countries", "demonym", demonym, "countries", formatsFor(request).List(names)))
+	}
+	builder.Pause("500")
+	builder.Say(templates.Render("guess.followup"))
+	return alexa.NewSSMLResponse(templates.Render("title.fact"), builder.Build()).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/flags"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/session"
+	"context"
+	"unicode"
+)
+
+// Dialog states of a session
+const (
+	// dialogIdle means no question is pending, so yes and no mean nothing
+	dialogIdle = "idle"
+	// dialogOfferedFact means the user was asked whether to hear a fact about dialogCountry
+	dialogOfferedFact = "offeredFact"
+	// dialogQuiz means the user was asked where the name of the current quiz question comes from
+	dialogQuiz = "quiz"
+	// dialogParty means the players of a party round were asked the name of the one holding the mic
+	dialogParty = "party"
+	// dialogConfirmDeletion means the user was asked whether to delete all of their data
+	dialogConfirmDeletion = "confirmDeletion"
+)
+
+// setDialogState records the pending question in the session attributes,
+// along with the code of the country it is about, if any
+func setDialogState(attributes map[string]interface{}, state string, country string) {
+	session.Load(attributes).SetDialogState(session.Dialog{State: state, Country: country})
+}
+
+// dialogState returns the pending question of the session and the country it is about
+func dialogState(request alexa.Request) (string, string) {
+	dialog := session.Load(request.Session.Attributes).DialogState()
+	if dialog.State == "" {
+		dialog.State = dialogIdle
+	}
+	return dialog.State, dialog.Country
+}
+
+// answerIntentModels declare the built-in intents answering questions in the interaction model
+var answerIntentModels = []model.Intent{{Name: "AMAZON.YesIntent"}, {Name: "AMAZON.NoIntent"}}
+
+// HandleYesIntent answers yes to the pending question of the session
+func HandleYesIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	switch state, country := dialogState(request); state {
+	case dialogOfferedFact:
+		setDialogState(attributes, dialogIdle, "")
+		var builder alexa.SSMLBuilder
+		builder.Say(buildCountryFact(templates, request.Body.Locale, country, featureFlags.Enabled(ctx, flags.PremiumFacts)))
+		builder.Pause("500")
+		builder.Say(templates.Render("guess.followup"))
+		return alexa.NewSSMLResponse(templates.Render("title.fact"), builder.Build()).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	case dialogConfirmDeletion:
+		return confirmDeletion(ctx, request)
+	default:
+		return buildConfusedResponse(templates)
+	}
+}
+
+// HandleNoIntent answers no to the pending question of the session
+func HandleNoIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	switch state, _ := dialogState(request); state {
+	case dialogOfferedFact:
+		setDialogState(attributes, dialogIdle, "")
+		var builder alexa.SSMLBuilder
+		builder.Say(templates.Render("fact.declined"))
+		builder.Say(templates.Render("guess.followup"))
+		return alexa.NewSSMLResponse(templates.Render("title.guess"), builder.Build()).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	case dialogConfirmDeletion:
+		setDialogState(attributes, dialogIdle, "")
+		return alexa.NewSimpleResponse(templates.Render("title.privacy"), templates.Render("privacy.kept")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	default:
+		return buildConfusedResponse(templates)
+	}
+}
+
+// buildConfusedResponse tells the user a yes or no didn't answer anything
+// and explains what they can say instead
+func buildConfusedResponse(templates messages.Set) alexa.Response {
+	reprompt := templates.Render("guess.reprompt")
+	var builder alexa.SSMLBuilder
+	builder.Say(templates.Render("dialog.confused"))
+	builder.Say(reprompt)
+	return alexa.NewSSMLResponse(templates.Render("title.guess"), builder.Build()).
+		WithReprompt(reprompt).
+		WithShouldEndSession(false)
+}
+
+// buildCountryFact returns a fact about the country having code in the language of locale.
+// Curated facts are preferred when allowed, otherwise the fact is made up from what
+// is known of the country
+func buildCountryFact(templates messages.Set, locale string, code string, curated bool) string {
+	if !i18n.Supported(locale) {
+		locale = i18n.DefaultLocale
+	}
+	if fact, ok := pickFact(i18n.Language(locale), code); ok && curated {
+		return fact
+	}
+
+	country, ok := findCountryInfo(nil, code)
+	if !ok {
+		return templates.Render("fact.unknown")
+	}
+	region := country.Region
+	if country.Subregion != "" {
+		region = country.Subregion
+	}
+	fact := templates.Render("fact.region", "country", country.Name, "region", region, "people", pluralDemonym(country.Demonym))
+	// native names in other scripts can't be read by the english voice
+	if country.NativeName != "" && country.NativeName != country.Name && isLatin(country.NativeName) {
+		fact += " " + templates.Render("fact.native", "native", country.NativeName)
+	}
+	return fact
+}
+
+// pluralDemonym returns the word for the people of a country, e.g. "Germans".
+// Demonyms ending in a sibilant, like "French" or "Swiss", are left as they are
+func pluralDemonym(demonym string) string {
+	for _, suffix := range []string{"sh", "ch", "ss", "se", "s", "x", "z"} {
+		if len(demonym) >= len(suffix) && demonym[len(demonym)-len(suffix):] == suffix {
+			return demonym
+		}
+	}
+	return demonym + "s"
+}
+
+// isLatin reports whether every letter of text is written in the latin script
+func isLatin(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
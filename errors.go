@@ -0,0 +1,39 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/analytics"
+	"alexa-skill-test/src/failure"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"context"
+	"strings"
+)
+
+// respondWithError apologizes for err under the card titled by the title message,
+// followed by the support code of its kind, which the card shows as well.
+// The session stays open since most failures are gone when the user asks again
+func respondWithError(ctx context.Context, templates messages.Set, title string, err error) alexa.Response {
+	kind := failure.Classify(err)
+	code := failure.Code(kind)
+	logging.FromContext(ctx).Warn("responding with error", "code", code, "kind", string(kind), "error", err)
+	analytics.Annotate(ctx, func(event *analytics.Event) { event.Outcome = string(kind) })
+
+	message := templates.Render(failure.Message(kind))
+	var builder alexa.SSMLBuilder
+	builder.Say(message)
+	// the code is spoken digit by digit, so "101" isn't heard as a hundred and one
+	for _, part := range templates.Split("error.code") {
+		if part == "{code}" {
+			builder.SayAs("digits", code)
+		} else {
+			builder.Say(strings.TrimSpace(part))
+		}
+	}
+	card := strings.Join([]string{message, templates.Render("error.code", "code", code)}, " ")
+
+	return alexa.NewSSMLResponse(templates.Render(title), builder.Build()).
+		WithCard(alexa.NewSimpleCard(templates.Render(title), card)).
+		WithReprompt(templates.Render("error.reprompt")).
+		WithShouldEndSession(false)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// httpEvent is the part of an http event that the skill needs,
+// whichever way the lambda function is fronted
+type httpEvent struct {
+	headers http.Header
+	body    []byte
+}
+
+// handleEvent detects the shape of the event lambda was invoked with.
+// Alexa invokes the function directly with an alexa request, while API
+// Gateway and Lambda Function URLs wrap the alexa request in an http event,
+// in which case the response is wrapped the same way. Scheduled EventBridge
+// events only warm the container up, see handleWarmup
+func handleEvent(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	var probe struct {
+		Version        string          `json:"version"`
+		Source         string          `json:"source"`
+		HTTPMethod     string          `json:"httpMethod"`
+		RequestContext json.RawMessage `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case probe.Source == "aws.events":
+		// EventBridge schedules invoke the function to keep a container warm
+		return handleWarmup(ctx), nil
+	case probe.HTTPMethod != "":
+		// API Gateway REST APIs send version 1.0 proxy events
+		var proxy events.APIGatewayProxyRequest
+		if err := json.Unmarshal(event, &proxy); err != nil {
+			return nil, err
+		}
+		decoded, err := decodeHTTPEvent(proxy.Headers, proxy.Body, proxy.IsBase64Encoded)
+		status, body := handleHTTPEvent(ctx, decoded, err)
+		return events.APIGatewayProxyResponse{StatusCode: status, Headers: jsonHeaders(), Body: body}, nil
+	case probe.Version == "2.0" && len(probe.RequestContext) > 0:
+		// API Gateway HTTP APIs and Lambda Function URLs share the 2.0 payload format
+		var proxy events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(event, &proxy); err != nil {
+			return nil, err
+		}
+		decoded, err := decodeHTTPEvent(proxy.Headers, proxy.Body, proxy.IsBase64Encoded)
+		status, body := handleHTTPEvent(ctx, decoded, err)
+		return events.LambdaFunctionURLResponse{StatusCode: status, Headers: jsonHeaders(), Body: body}, nil
+	default:
+		var request alexa.Request
+		if err := json.Unmarshal(event, &request); err != nil {
+			return nil, err
+		}
+		return handleRequest(ctx, request)
+	}
+}
+
+// decodeHTTPEvent returns the headers and raw body of an http event
+func decodeHTTPEvent(headers map[string]string, body string, isBase64Encoded bool) (httpEvent, error) {
+	event := httpEvent{headers: http.Header{}, body: []byte(body)}
+	for key, value := range headers {
+		event.headers.Set(key, value)
+	}
+	if isBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return event, err
+		}
+		event.body = decoded
+	}
+	return event, nil
+}
+
+// handleHTTPEvent verifies and dispatches the alexa request in the body of
+// an http event, returning the status and body of the http response
+func handleHTTPEvent(ctx context.Context, event httpEvent, err error) (int, string) {
+	if err != nil {
+		return http.StatusBadRequest, `{"error":"malformed body"}`
+	}
+	if cfg.VerifyRequests {
+		if err := requestVerifier.Verify(event.headers, event.body); err != nil {
+			return http.StatusBadRequest, `{"error":"request verification failed"}`
+		}
+	}
+	var request alexa.Request
+	if err := json.Unmarshal(event.body, &request); err != nil {
+		return http.StatusBadRequest, `{"error":"malformed alexa request"}`
+	}
+	response, err := handleRequest(ctx, request)
+	if err != nil {
+		return http.StatusForbidden, `{"error":"request rejected"}`
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return http.StatusInternalServerError, `{"error":"unable to encode response"}`
+	}
+	return http.StatusOK, string(body)
+}
+
+// jsonHeaders returns the headers of json http responses
+func jsonHeaders() map[string]string {
+	return map[string]string{"Content-Type": "application/json"}
+}
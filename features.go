@@ -0,0 +1,26 @@
+package main
+
+import (
+	"alexa-skill-test/src/flags"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// featureFlags toggles the features of the skill, configured in the
+// environment and optionally overridden from AppConfig
+var featureFlags = newFeatureFlags()
+
+// newFeatureFlags returns the flags of the configuration on top of the defaults
+func newFeatureFlags() *flags.Flags {
+	local, invalid := flags.Parse(cfg.Flags)
+	if len(invalid) > 0 {
+		slog.Warn("ignoring malformed feature flags", "flags", invalid)
+	}
+	return &flags.Flags{
+		Local:        flags.Defaults.Merge(local),
+		AppConfigURL: cfg.FlagsURL,
+		HTTP:         &http.Client{Timeout: time.Second},
+		Refresh:      cfg.FlagsRefresh,
+	}
+}
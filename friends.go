@@ -0,0 +1,185 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/friends"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// friendSlotType is the custom slot type the saved friends are added to as dynamic entities
+const friendSlotType = "FriendName"
+
+// friendsStore keeps the friends of every user across sessions, nil when neither
+// a table nor the persistent attributes are configured
+var friendsStore = newFriendsStore(cfg.FriendsTable)
+
+// newFriendsStore returns a store keeping the friends in the persistent
+// attributes when they are configured, and in the DynamoDB table otherwise
+func newFriendsStore(table string) friends.Store {
+	if persistentAttributes != nil {
+		return friends.Persistent{Attributes: persistentAttributes}
+	}
+	if table == "" {
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("friends only last a session, aws configuration failed", "error", err)
+		return nil
+	}
+	return friends.Dynamo{Client: dynamodb.NewFromConfig(config), Table: table}
+}
+
+// userFriends returns the friends of the speaker of request. They are read
+// from the store once per session and cached in attributes for the following turns
+func userFriends(ctx context.Context, request alexa.Request, attributes map[string]interface{}) ([]string, error) {
+	if cached, ok := friends.Cached(attributes); ok {
+		return cached, nil
+	}
+	var loaded []string
+	if friendsStore != nil {
+		ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+		defer cancel()
+		var err error
+		if loaded, err = friendsStore.List(ctx, speakerKey(request)); err != nil {
+			return nil, err
+		}
+	}
+	friends.Cache(attributes, loaded)
+	return loaded, nil
+}
+
+// buildFriendsDirective returns the directive teaching Alexa the names of
+// the friends for the rest of the session, so they are recognized when asked about
+func buildFriendsDirective(names []string) alexa.Directives {
+	if len(names) == 0 {
+		return alexa.NewUpdateDynamicEntitiesDirective(alexa.EntitiesClear)
+	}
+	friendType := alexa.EntityType{Name: friendSlotType}
+	for _, name := range names {
+		friendType.Values = append(friendType.Values, alexa.Entity{ID: friends.ID(name), Name: alexa.EntityName{Value: name}})
+	}
+	return alexa.NewUpdateDynamicEntitiesDirective(alexa.EntitiesReplace, friendType)
+}
+
+// respondAboutFriends returns a response about the friends of the user, keeping the session open
+func respondAboutFriends(templates messages.Set, attributes map[string]interface{}, speech string) alexa.Response {
+	return alexa.NewSimpleResponse(templates.Render("title.friends"), speech).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// friendsIntentModels declare the intents about friends in the interaction model
+var friendsIntentModels = []model.Intent{
+	{
+		Name:    "RememberFriendIntent",
+		Slots:   []model.Slot{{Name: "first_name", Type: "FirstName"}},
+		Samples: []string{"remember my friend {first_name}", "save my friend {first_name}"},
+	},
+	{
+		Name:    "ListFriendsIntent",
+		Samples: []string{"who my friends are", "who are my friends", "list my friends"},
+	},
+	{
+		Name:    "GuessFriendIntent",
+		Slots:   []model.Slot{{Name: "friend", Type: "FriendName"}},
+		Samples: []string{"guess my friend {friend}", "where is my friend {friend} from"},
+	},
+}
+
+// friendSlotTypeModel declares friendSlotType in the interaction model. Its values
+// come from dynamic entities, the model only needs one to accept the type
+var friendSlotTypeModel = model.SlotType{
+	Name:   "FriendName",
+	Values: []model.Value{{Name: "Priya"}},
+}
+
+// HandleRememberFriendIntent saves the name of a friend of the speaker.
+// A user can say:
+// Alexa, ask nationality guesser to remember my friend Priya
+func HandleRememberFriendIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	name := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(name) {
+		logging.FromContext(ctx).Info("refusing to remember blocked name", logging.NameKey, name)
+		return respondAboutFriends(templates, attributes, templates.Render("guess.blocked"))
+	}
+	saved, err := userFriends(ctx, request, attributes)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Error("reading friends failed", "error", err)
+		return respondAboutFriends(templates, attributes, templates.Render("friends.unavailable"))
+	case len(saved) >= friends.Max:
+		return respondAboutFriends(templates, attributes, templates.Render("friends.full"))
+	}
+	if known, ok := friends.Find(saved, name); ok {
+		return respondAboutFriends(templates, attributes, templates.Render("friends.known", "name", known))
+	}
+
+	if friendsStore != nil {
+		ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+		defer cancel()
+		if err := friendsStore.Add(ctx, speakerKey(request), name); err != nil {
+			logging.FromContext(ctx).Error("saving friend failed", "error", err)
+			return respondAboutFriends(templates, attributes, templates.Render("friends.unavailable"))
+		}
+	}
+	saved = append(saved, name)
+	friends.Cache(attributes, saved)
+	return respondAboutFriends(templates, attributes, templates.Render("friends.saved", "name", name)).
+		WithDirectives(buildFriendsDirective(saved))
+}
+
+// HandleListFriendsIntent reads out the friends the speaker saved.
+// A user can say:
+// Alexa, ask nationality guesser who my friends are
+func HandleListFriendsIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	saved, err := userFriends(ctx, request, attributes)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Error("reading friends failed", "error", err)
+		return respondAboutFriends(templates, attributes, templates.Render("friends.unavailable"))
+	case len(saved) == 0:
+		return respondAboutFriends(templates, attributes, templates.Render("friends.empty"))
+	}
+	return respondAboutFriends(templates, attributes, templates.Render("friends.list", "names", formatsFor(request).List(saved))).
+		WithDirectives(buildFriendsDirective(saved))
+}
+
+// HandleGuessFriendIntent guesses the nationality of a friend the speaker saved.
+// A user can say:
+// Alexa, ask nationality guesser to guess my friend Priya
+func HandleGuessFriendIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+
+	saved, err := userFriends(ctx, request, attributes)
+	if err != nil {
+		logging.FromContext(ctx).Error("reading friends failed", "error", err)
+		return respondAboutFriends(templates, attributes, templates.Render("friends.unavailable"))
+	}
+	// a friend taught as a dynamic entity resolves to its id,
+	// otherwise the name heard is compared to the saved ones
+	slot := request.Body.Intent.Slots["friend"]
+	name, ok := friends.Find(saved, slot.ResolvedID())
+	if !ok {
+		name, ok = friends.Find(saved, names.Sanitize(slot.Value))
+	}
+	if !ok {
+		return respondAboutFriends(templates, attributes, templates.Render("friends.unknown", "name", names.Sanitize(slot.Value)))
+	}
+	return respondWithGuess(ctx, request, templates, name, templates.Render("friends.intro", "name", name))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/gender"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"context"
+	"strconv"
+)
+
+// guessGenderIntentModel declares GuessGenderIntent in the interaction model
+var guessGenderIntentModel = model.Intent{
+	Name:  "GuessGenderIntent",
+	Slots: []model.Slot{{Name: "first_name", Type: "FirstName"}},
+	Samples: []string{
+		"whether {first_name} is a boy's or a girl's name",
+		"is {first_name} a boy's or a girl's name",
+		"guess the gender of {first_name}",
+	},
+}
+
+// HandleGuessGenderIntent guesses whether a first name is more often
+// given to men or to women.
+// A user can say:
+// Alexa, ask nationality guesser whether Ethan is a boy's or a girl's name
+func HandleGuessGenderIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	firstName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(firstName) {
+		logging.FromContext(ctx).Info("refusing to guess gender of blocked name", logging.NameKey, firstName)
+		return alexa.NewSimpleResponse(templates.Render("title.gender"), templates.Render("gender.blocked"))
+	}
+
+	logging.FromContext(ctx).Info("guessing gender", logging.NameKey, firstName)
+	var response gender.Response
+	if err := fetchJSON(ctx, withQuery(cfg.GenderizeURL, nameQuery(firstName)), &response); err != nil {
+		logging.FromContext(ctx).Error("gender guess failed", "error", err)
+		return respondWithError(ctx, templates, "title.gender", err)
+	}
+	return alexa.NewSSMLResponse(templates.Render("title.gender"), buildGenderResponse(templates, firstName, response)).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// buildGenderResponse speaks the gender guessed for firstName along with how certain it is
+func buildGenderResponse(templates messages.Set, firstName string, response gender.Response) string {
+	var builder alexa.SSMLBuilder
+	if response.Gender == "" {
+		builder.Say(templates.Render("gender.unknown", "name", firstName))
+		return builder.Build()
+	}
+	percent := strconv.Itoa(int(response.Probability * 100))
+	builder.Say(templates.Render("gender.result", "name", firstName, "gender", templates.Render("gender."+response.Gender), "percent", percent))
+	return builder.Build()
+}
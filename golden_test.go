@@ -0,0 +1,56 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/golden"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGolden replays the request envelopes of testdata/golden through the
+// middlewares and the dispatcher, guessing from the embedded datasets like
+// cmd/golden does, and compares the shape of each response to the golden one
+func TestGolden(t *testing.T) {
+	keepConfig(t)
+	cfg.NationalityProvider = "offline"
+	cfg.NationalityFallback = ""
+	cfg.EnsembleProviders = nil
+	cfg.DisableCountryAPI = true
+	ctx := withProviders(context.Background(), newProviders())
+
+	requests, err := filepath.Glob(filepath.Join("testdata", "golden", "*.request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) == 0 {
+		t.Fatal("no golden cases")
+	}
+	for _, file := range requests {
+		name := strings.TrimSuffix(filepath.Base(file), ".request.json")
+		t.Run(name, func(t *testing.T) {
+			envelope, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var request alexa.Request
+			if err := json.Unmarshal(envelope, &request); err != nil {
+				t.Fatalf("malformed request: %v", err)
+			}
+			response, err := handleRequest(ctx, request)
+			if err != nil {
+				t.Fatalf("request rejected: %v", err)
+			}
+			body, err := json.Marshal(response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := golden.Check(strings.TrimSuffix(file, ".request.json")+".response.json", body); err != nil {
+				t.Errorf("response differs from the golden one, run cmd/golden with -update if expected:\n%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/preferences"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// warmupCountries are the countries most guesses are about, fetched by a
+// warmup so the first guesses of a new container find them in the cache
+var warmupCountries = []string{"US", "GB", "IN", "DE", "FR", "ES", "IT", "JP", "CN", "BR", "NG", "MX"}
+
+// healthReport is the outcome of checking the configuration and the dependencies of the skill
+type healthReport struct {
+	// Status is "ok", "degraded" when a dependency is unreachable, since the
+	// skill then falls back on what it embeds, or "unhealthy" when the
+	// configuration is broken
+	Status       string                      `json:"status"`
+	Config       []string                    `json:"config,omitempty"`
+	Dependencies map[string]dependencyHealth `json:"dependencies"`
+}
+
+// dependencyHealth is whether an upstream api answered, whatever it answered
+type dependencyHealth struct {
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checkHealth verifies the configuration and reaches every upstream api
+// concurrently, which also opens the connections the next requests reuse.
+// When warm is set the countries guesses are most often about are fetched
+// into the cache as well
+func checkHealth(ctx context.Context, warm bool) healthReport {
+	report := healthReport{Status: "ok", Config: configProblems(), Dependencies: map[string]dependencyHealth{}}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for name, url := range dependencyURLs() {
+		wg.Add(1)
+		go func(name string, url string) {
+			defer wg.Done()
+			health := pingDependency(ctx, url)
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Dependencies[name] = health
+		}(name, url)
+	}
+	if warm {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookupCountries(ctx, warmupCountries)
+		}()
+	}
+	wg.Wait()
+
+	for _, health := range report.Dependencies {
+		if !health.Reachable {
+			report.Status = "degraded"
+		}
+	}
+	if len(report.Config) > 0 {
+		report.Status = "unhealthy"
+	}
+	return report
+}
+
+// dependencyURLs returns the base url of every upstream api the configuration uses, by name
+func dependencyURLs() map[string]string {
+	urls := map[string]string{
+		"agify":     cfg.AgifyURL,
+		"genderize": cfg.GenderizeURL,
+	}
+	// unusable providers fall back to nationalize, see newNationalityProvider
+	for _, name := range configuredProviders() {
+		if name != nationality.OfflineSource && (name != "namsor" || cfg.NamSorAPIKey == "") {
+			urls["nationalize"] = cfg.NationalizeURL
+		}
+	}
+	// surnames are looked up from NamSor whichever provider guesses nationalities
+	if cfg.NamSorAPIKey != "" {
+		urls["namsor"] = cfg.SurnameURL
+	}
+	if !cfg.DisableCountryAPI {
+		urls["countries"] = cfg.CountriesURL
+	}
+	return urls
+}
+
+// configuredProviders returns the names of the nationality providers the configuration uses
+func configuredProviders() []string {
+	providers := []string{cfg.NationalityProvider}
+	if cfg.NationalityFallback != "" {
+		providers = append(providers, cfg.NationalityFallback)
+	}
+	for _, member := range cfg.EnsembleProviders {
+		name, _, _ := strings.Cut(member, ":")
+		providers = append(providers, name)
+	}
+	return providers
+}
+
+// pingDependency gets url, which counts as reachable whatever the status
+// of the response, since a base url without a name is rarely a valid request
+func pingDependency(ctx context.Context, url string) dependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return dependencyHealth{Error: err.Error()}
+	}
+	response, err := httpClient.Do(req)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		return dependencyHealth{LatencyMs: latency, Error: err.Error()}
+	}
+	response.Body.Close()
+	return dependencyHealth{Reachable: true, LatencyMs: latency}
+}
+
+// configProblems returns the settings that can't work as configured, sorted
+func configProblems() []string {
+	var problems []string
+	for _, name := range configuredProviders() {
+		switch name {
+		case "nationalize", nationality.OfflineSource:
+		case "namsor":
+			if cfg.NamSorAPIKey == "" {
+				problems = append(problems, "NAMSOR_API_KEY is required by the namsor provider")
+			}
+		default:
+			problems = append(problems, "unknown nationality provider "+name)
+		}
+	}
+	if cfg.DeadlineMargin >= cfg.ResponseTimeout {
+		problems = append(problems, "DEADLINE_MARGIN leaves no time to respond within RESPONSE_TIMEOUT")
+	}
+	if cfg.UpstreamTimeout > cfg.ResponseTimeout {
+		problems = append(problems, "UPSTREAM_TIMEOUT exceeds RESPONSE_TIMEOUT")
+	}
+	if cfg.ConfidencePhrasing != preferences.Percent && cfg.ConfidencePhrasing != preferences.Words {
+		problems = append(problems, "unknown CONFIDENCE_PHRASING "+cfg.ConfidencePhrasing)
+	}
+	if cfg.AnalyticsStream != "" && cfg.AnalyticsEnabled && cfg.AnalyticsHashKey == "" {
+		problems = append(problems, "ANALYTICS_HASH_KEY is required by ANALYTICS_STREAM")
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// handleWarmup answers the scheduled events keeping a lambda container warm.
+// Nothing is spoken, the report is logged and returned to the scheduler
+func handleWarmup(ctx context.Context) healthReport {
+	report := checkHealth(ctx, true)
+	logger := logging.FromContext(ctx)
+	if report.Status == "ok" {
+		logger.Info("warmed up", "status", report.Status)
+	} else {
+		logger.Warn("warmed up", "status", report.Status, "config", report.Config, "dependencies", report.Dependencies)
+	}
+	return report
+}
+
+// handleHealthHTTP reports the health of the skill as json. It answers
+// 503 Service Unavailable only when the configuration is broken, since
+// unreachable upstream apis are covered by fallbacks and would otherwise
+// take every instance out of service at once
+func handleHealthHTTP(w http.ResponseWriter, r *http.Request) {
+	report := checkHealth(r.Context(), false)
+	if report.Status == "unhealthy" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, report)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/history"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// historyAttribute is the session attribute holding the names guessed for
+// each speaker of the household, keyed by the id returned by speakerID
+const historyAttribute = "history"
+
+// maxHistory is the number of names remembered for each speaker
+const maxHistory = 10
+
+// ownerSpeaker identifies speakers whose voice wasn't recognized,
+// who are treated as the owner of the account
+const ownerSpeaker = "owner"
+
+// speakerID returns the person id of the recognized speaker of request,
+// or ownerSpeaker when the voice wasn't recognized
+func speakerID(request alexa.Request) string {
+	if person := request.Context.System.Person; person != nil && person.PersonID != "" {
+		return person.PersonID
+	}
+	return ownerSpeaker
+}
+
+// guessHistory returns the names guessed for speaker, oldest first.
+// Attributes decoded from a request hold lists of interface values,
+// while attributes set during this request hold lists of strings
+func guessHistory(attributes map[string]interface{}, speaker string) []string {
+	histories, _ := attributes[historyAttribute].(map[string]interface{})
+	switch history := histories[speaker].(type) {
+	case []string:
+		return history
+	case []interface{}:
+		var names []string
+		for _, name := range history {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// recordGuess appends name to the history of speaker in attributes,
+// forgetting the oldest names beyond maxHistory
+func recordGuess(attributes map[string]interface{}, speaker string, name string) {
+	history := append(guessHistory(attributes, speaker), name)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+
+	histories := map[string]interface{}{}
+	if previous, ok := attributes[historyAttribute].(map[string]interface{}); ok {
+		for key, value := range previous {
+			histories[key] = value
+		}
+	}
+	histories[speaker] = history
+	attributes[historyAttribute] = histories
+}
+
+// historyCountries is the number of most likely countries remembered with each guess
+const historyCountries = 3
+
+// historyStore keeps the guesses of every user across sessions, nil when neither
+// a table nor the persistent attributes are configured
+var historyStore = newHistoryStore(cfg.HistoryTable)
+
+// newHistoryStore returns a store keeping the guesses in the persistent
+// attributes when they are configured, and in the DynamoDB table otherwise
+func newHistoryStore(table string) history.Store {
+	if persistentAttributes != nil {
+		return history.Persistent{Attributes: persistentAttributes}
+	}
+	if table == "" {
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("history disabled, aws configuration failed", "error", err)
+		return nil
+	}
+	return history.Dynamo{Client: dynamodb.NewFromConfig(config), Table: table}
+}
+
+// speakerKey returns the id the guesses and preferences of the speaker of request are stored under
+func speakerKey(request alexa.Request) string {
+	var personID string
+	if person := request.Context.System.Person; person != nil {
+		personID = person.PersonID
+	}
+	return history.Key(request.Session.User.UserID, personID)
+}
+
+// saveGuess remembers the guess for name across sessions, along with its most likely countries
+func saveGuess(ctx context.Context, request alexa.Request, name string, predictions []nationality.Prediction) {
+	if historyStore == nil || name == "" {
+		return
+	}
+	guess := history.Guess{Name: name, GuessedAt: time.Now()}
+	for i, v := range sortPredictions(predictions) {
+		if i == historyCountries {
+			break
+		}
+		guess.Countries = append(guess.Countries, v.Country_id)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	if err := historyStore.Save(ctx, speakerKey(request), guess); err != nil {
+		logging.FromContext(ctx).Error("saving guess failed", "error", err)
+	}
+}
+
+// historyIntentModels declare the intents about the history in the interaction model
+var historyIntentModels = []model.Intent{
+	{
+		Name:    "HistoryIntent",
+		Samples: []string{"what my last guess was", "what was my last guess", "which names did i ask about"},
+	},
+	{
+		Name:    "ClearHistoryIntent",
+		Samples: []string{"clear my history", "forget my guesses"},
+	},
+}
+
+// HandleHistoryIntent recalls the last name the speaker asked about and what
+// was guessed for it. Without a history table only the current session is recalled.
+// A user can say:
+// Alexa, ask nationality guesser what my last guess was
+func HandleHistoryIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	respond := func(speech string) alexa.Response {
+		return alexa.NewSimpleResponse(templates.Render("title.history"), speech).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+
+	if historyStore == nil {
+		names := guessHistory(request.Session.Attributes, speakerID(request))
+		if len(names) == 0 {
+			return respond(templates.Render("history.empty"))
+		}
+		return respond(templates.Render("history.last_name", "name", names[len(names)-1]))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	guess, ok, err := historyStore.Last(ctx, speakerKey(request))
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Error("reading history failed", "error", err)
+		return respond(templates.Render("history.unavailable"))
+	case !ok:
+		return respond(templates.Render("history.empty"))
+	case len(guess.Countries) == 0:
+		return respond(templates.Render("history.last_name", "name", guess.Name))
+	}
+
+	var countries []string
+	for _, code := range guess.Countries {
+		countries = append(countries, findCountryName(templates, nil, code))
+	}
+	return respond(templates.Render("history.last", "name", guess.Name, "countries", formatsFor(request).List(countries)))
+}
+
+// HandleClearHistoryIntent forgets every name the speaker asked about,
+// in this session and across sessions.
+// A user can say:
+// Alexa, ask nationality guesser to clear my history
+func HandleClearHistoryIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	if histories, ok := attributes[historyAttribute].(map[string]interface{}); ok {
+		cleared := map[string]interface{}{}
+		for key, value := range histories {
+			if key != speakerID(request) {
+				cleared[key] = value
+			}
+		}
+		attributes[historyAttribute] = cleared
+	}
+
+	speech := templates.Render("history.cleared")
+	if historyStore != nil {
+		ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+		defer cancel()
+		if err := historyStore.Clear(ctx, speakerKey(request)); err != nil {
+			logging.FromContext(ctx).Error("clearing history failed", "error", err)
+			speech = templates.Render("history.unavailable")
+		}
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.history"), speech).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/alexaapi"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/upstream"
+	"alexa-skill-test/src/user"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// identity reads the given name of the speaker of a request
+type identity interface {
+	// GivenName returns the given name of the speaker of request,
+	// or errNoPermission when the skill may not read it
+	GivenName(ctx context.Context, request alexa.Request) (string, error)
+}
+
+// accountIdentity reads the given name of the user from the Customer
+// Profile API and, failing that, from their linked Cognito account
+type accountIdentity struct {
+	// cognitoURL is the endpoint of the Cognito user pool the accounts are linked to
+	cognitoURL string
+	client     *http.Client
+}
+
+// GivenName returns the given name of the user. When the speaker was recognized
+// their own name is preferred to the name of the account owner.
+// It returns errNoPermission when no source may be read
+func (account accountIdentity) GivenName(ctx context.Context, request alexa.Request) (string, error) {
+	client := alexaapi.NewClient(request)
+	for _, fetch := range []func(context.Context) (string, error){client.PersonGivenName, client.GivenName} {
+		name, err := fetch(ctx)
+		if err == nil && name != "" {
+			return name, nil
+		}
+		if err != nil && !errors.Is(err, alexaapi.ErrForbidden) {
+			logging.FromContext(ctx).Warn("customer profile api failed", "error", err)
+		}
+	}
+	return account.cognitoGivenName(ctx, request.Session.User.AccessToken)
+}
+
+// cognitoGivenName calls Cognito API with AccessToken provided in
+// the request received from alexa to get the
+// given (first) name of the user.
+// It returns errNoPermission when there is no access token or it is refused
+func (account accountIdentity) cognitoGivenName(ctx context.Context, accessToken string) (string, error) {
+	if accessToken == "" {
+		return "", errNoPermission
+	}
+	values := map[string]string{"AccessToken": accessToken}
+	jsonValue, _ := json.Marshal(values)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, account.cognitoURL, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSCognitoIdentityProviderService.GetUser")
+	resp, err := account.client.Do(req)
+	if err != nil {
+		return "", upstream.Transport("cognito", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		return "", errNoPermission
+	}
+	if err := upstream.Check("cognito", resp); err != nil {
+		return "", err
+	}
+
+	var userData user.User
+	if err := json.NewDecoder(resp.Body).Decode(&userData); err != nil {
+		return "", upstream.Malformed("cognito", err)
+	}
+
+	return getValueOfNameForUser(userData.Attributes, "given_name"), nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/nationality"
+	"context"
+)
+
+// internationalSlots are the slots holding the names compared by MostInternationalIntent
+var internationalSlots = []string{"name_one", "name_two", "name_three"}
+
+// nonTrivialProbability is the probability under which a country
+// prediction doesn't count towards how international a name is
+const nonTrivialProbability = 0.05
+
+// mostInternationalIntentModel declares MostInternationalIntent in the interaction model
+var mostInternationalIntentModel = model.Intent{
+	Name: "MostInternationalIntent",
+	Slots: []model.Slot{
+		{Name: "name_one", Type: "FirstName"},
+		{Name: "name_two", Type: "FirstName"},
+		{Name: "name_three", Type: "FirstName"},
+	},
+	Samples: []string{
+		"which is more international {name_one} or {name_two}",
+		"which is the most international {name_one} {name_two} or {name_three}",
+		"compare {name_one} and {name_two}",
+	},
+}
+
+// HandleMostInternationalIntent guesses the nationality of several names
+// and reports which one spans the largest number of countries.
+// A user can say:
+// Alexa, ask nationality guesser which is more international, Ethan or Maria
+func HandleMostInternationalIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	var candidates []string
+	spans := map[string]int{}
+	for _, slot := range internationalSlots {
+		name := names.Sanitize(getValueOfName(request.Body.Intent.Slots, slot))
+		if _, seen := spans[name]; name == "" || seen || blocklist.Contains(name) {
+			continue
+		}
+		response, err := lookupNationality(ctx, name)
+		if err != nil {
+			logging.FromContext(ctx).Error("nationality guess failed", logging.NameKey, name, "error", err)
+			return respondWithError(ctx, templates, "title.international", err)
+		}
+		candidates = append(candidates, name)
+		spans[name] = countNonTrivial(response.Predictions)
+	}
+	return alexa.NewSSMLResponse(templates.Render("title.international"), buildMostInternationalResponse(templates, formatsFor(request), candidates, spans))
+}
+
+// countNonTrivial returns how many countries have a probability worth counting
+func countNonTrivial(predictions []nationality.Prediction) int {
+	var count int
+	for _, v := range predictions {
+		if v.Probability >= nonTrivialProbability {
+			count++
+		}
+	}
+	return count
+}
+
+// buildMostInternationalResponse announces the name (or names, on a tie)
+// spanning the most countries, followed by the names nothing was found for
+func buildMostInternationalResponse(templates messages.Set, formats format.Formatter, candidates []string, spans map[string]int) string {
+	var builder alexa.SSMLBuilder
+
+	var winners, unknown []string
+	var most int
+	for _, name := range candidates {
+		switch span := spans[name]; {
+		case span == 0:
+			unknown = append(unknown, name)
+		case span > most:
+			most = span
+			winners = []string{name}
+		case span == most:
+			winners = append(winners, name)
+		}
+	}
+
+	switch {
+	case len(winners) == 0:
+		builder.Say(templates.Render("international.none"))
+		return builder.Build()
+	case len(winners) == 1:
+		builder.Say(templates.Render("international.winner", "name", winners[0], "countries", pluralCountries(templates, formats, most)))
+	default:
+		builder.Say(templates.Render("international.tie", "names", formats.List(winners), "countries", pluralCountries(templates, formats, most)))
+	}
+
+	if len(unknown) > 0 {
+		builder.Pause("300")
+		builder.Say(templates.Render("international.unknown", "names", formats.List(unknown)))
+	}
+	return builder.Build()
+}
+
+// pluralCountries returns how many countries there are, e.g. "1 country" or "3 countries"
+func pluralCountries(templates messages.Set, formats format.Formatter, count int) string {
+	if count == 1 {
+		return templates.Render("countries.one")
+	}
+	return templates.Render("countries.many", "count", formats.Number(count))
+}
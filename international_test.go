@@ -0,0 +1,90 @@
+package main
+
+import (
+	"alexa-skill-test/src/nationality"
+	"strings"
+	"testing"
+)
+
+// spreadGuess returns a guess spread across count countries, with one
+// more country too unlikely to count
+func spreadGuess(count int) nationality.Response {
+	codes := []string{"DE", "FR", "IT", "ES", "PT"}
+	var response nationality.Response
+	for _, code := range codes[:count] {
+		response.Predictions = append(response.Predictions, nationality.Prediction{Country_id: code, Probability: 0.15})
+	}
+	response.Predictions = append(response.Predictions, nationality.Prediction{Country_id: "JP", Probability: 0.01})
+	return response
+}
+
+// compare returns what is said when asked which of names is the most international
+func compare(provider nationality.Provider, names ...string) string {
+	slots := map[string]string{}
+	for i, name := range names {
+		slots[internationalSlots[i]] = name
+	}
+	return spoken(dispatchIntent(testContext(provider), intentRequest("MostInternationalIntent", slots)))
+}
+
+func TestMostInternationalWinner(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{
+		"Maria": spreadGuess(4),
+		"Hans":  spreadGuess(2),
+		"Ethan": spreadGuess(1),
+	}}
+
+	speech := compare(provider, "Hans", "Maria", "Ethan")
+	if !strings.Contains(speech, "maria is the most international name") || !strings.Contains(speech, "4 countries") {
+		t.Errorf("speech %q doesn't name Maria the winner across 4 countries", speech)
+	}
+	if strings.Contains(speech, "couldn&apos;t guess") {
+		t.Errorf("speech %q reports a name without countries", speech)
+	}
+}
+
+func TestMostInternationalTie(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{
+		"Hans":  spreadGuess(3),
+		"Maria": spreadGuess(3),
+		"Ethan": spreadGuess(1),
+	}}
+
+	speech := compare(provider, "Hans", "Maria", "Ethan")
+	if !strings.Contains(speech, "a tie between hans and maria") || !strings.Contains(speech, "3 countries") {
+		t.Errorf("speech %q doesn't announce a tie of Hans and Maria across 3 countries", speech)
+	}
+	if strings.Contains(speech, "ethan") {
+		t.Errorf("speech %q mentions the loser", speech)
+	}
+}
+
+func TestMostInternationalNameWithoutPredictions(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": spreadGuess(1)}}
+
+	speech := compare(provider, "Hans", "Xyzzy")
+	if !strings.Contains(speech, "hans is the most international name") || !strings.Contains(speech, "1 country") {
+		t.Errorf("speech %q doesn't name Hans the winner across 1 country", speech)
+	}
+	if !strings.Contains(speech, "couldn&apos;t guess any countries for xyzzy") {
+		t.Errorf("speech %q doesn't report Xyzzy", speech)
+	}
+}
+
+func TestMostInternationalWithoutAnyPredictions(t *testing.T) {
+	speech := compare(&fakeNationality{}, "Xyzzy", "Plugh")
+	if !strings.Contains(speech, "couldn&apos;t find any countries for those names") {
+		t.Errorf("speech %q, want no country found for any name", speech)
+	}
+}
+
+func TestCountNonTrivial(t *testing.T) {
+	predictions := []nationality.Prediction{
+		{Country_id: "DE", Probability: 0.5},
+		{Country_id: "AT", Probability: nonTrivialProbability},
+		{Country_id: "CH", Probability: 0.049},
+	}
+	if got := countNonTrivial(predictions); got != 2 {
+		t.Errorf("countNonTrivial = %d, want 2", got)
+	}
+}
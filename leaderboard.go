@@ -0,0 +1,117 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/leaderboard"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/quiz"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// leaderboardSize is the number of household scores read out
+const leaderboardSize = 5
+
+// leaderboardStore keeps the quiz scores, nil when no table is configured
+var leaderboardStore = newLeaderboardStore(cfg.LeaderboardTable)
+
+// newLeaderboardStore returns a store keeping the scores in the DynamoDB table,
+// using the credentials of the lambda function
+func newLeaderboardStore(table string) leaderboard.Store {
+	if table == "" {
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("leaderboard disabled, aws configuration failed", "error", err)
+		return nil
+	}
+	return leaderboard.Dynamo{Client: dynamodb.NewFromConfig(config), Table: table}
+}
+
+// recordQuiz saves the score of a finished quiz to the leaderboard
+func recordQuiz(ctx context.Context, request alexa.Request, state quiz.State) {
+	if leaderboardStore == nil || state.Round == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	err := leaderboardStore.Record(ctx, leaderboard.Entry{
+		UserID:   request.Session.User.UserID,
+		PersonID: speakerID(request),
+		Percent:  leaderboard.Percent(state.Score, state.Round),
+		PlayedAt: time.Now(),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("recording quiz score failed", "error", err)
+	}
+}
+
+// leaderboardIntentModel declares LeaderboardIntent in the interaction model
+var leaderboardIntentModel = model.Intent{
+	Name:    "LeaderboardIntent",
+	Samples: []string{"the leaderboard", "show me the leaderboard", "what are the best scores"},
+}
+
+// HandleLeaderboardIntent reads out the best quiz scores of the household
+// and how the best score of the speaker compares to every quiz played.
+// A user can say:
+// Alexa, ask nationality guesser for the leaderboard
+func HandleLeaderboardIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	if leaderboardStore == nil {
+		return alexa.NewSimpleResponse(templates.Render("title.leaderboard"), templates.Render("leaderboard.unavailable"))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	entries, err := leaderboardStore.Household(ctx, request.Session.User.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("reading leaderboard failed", "error", err)
+		return alexa.NewSimpleResponse(templates.Render("title.leaderboard"), templates.Render("leaderboard.unavailable"))
+	}
+	var distribution map[int]int
+	if len(entries) > 0 {
+		if distribution, err = leaderboardStore.Distribution(ctx); err != nil {
+			logging.FromContext(ctx).Error("reading score distribution failed", "error", err)
+		}
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.leaderboard"), buildLeaderboardResponse(templates, formatsFor(request), speakerID(request), entries, distribution)).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// buildLeaderboardResponse lists the best household scores, followed by
+// the percentile of the best score of speaker when it is known
+func buildLeaderboardResponse(templates messages.Set, formats format.Formatter, speaker string, entries []leaderboard.Entry, distribution map[int]int) string {
+	if len(entries) == 0 {
+		return templates.Render("leaderboard.empty")
+	}
+
+	var spoken []string
+	for i, entry := range leaderboard.Top(entries, leaderboardSize) {
+		who := templates.Render("leaderboard.member")
+		switch entry.PersonID {
+		case speaker:
+			who = templates.Render("leaderboard.you")
+		case ownerSpeaker:
+			who = templates.Render("leaderboard.owner")
+		}
+		spoken = append(spoken, templates.Render("leaderboard.entry", "who", who, "rank", formats.Ordinal(i+1), "percent", formats.Number(entry.Percent)))
+	}
+	speech := templates.Render("leaderboard.household", "entries", formats.List(spoken))
+
+	for _, entry := range entries {
+		if entry.PersonID == speaker && distribution != nil {
+			percentile := leaderboard.Percentile(distribution, entry.Percent)
+			speech += " " + templates.Render("leaderboard.percentile", "percent", formats.Number(entry.Percent), "percentile", formats.Number(percentile))
+		}
+	}
+	return speech
+}
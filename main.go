@@ -2,38 +2,338 @@ package main
 
 import (
 	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/analytics"
+	"alexa-skill-test/src/cache"
+	"alexa-skill-test/src/config"
 	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/failure"
+	"alexa-skill-test/src/flags"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/metrics"
+	"alexa-skill-test/src/middleware"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
 	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/pii"
+	"alexa-skill-test/src/preferences"
+	"alexa-skill-test/src/retry"
+	"alexa-skill-test/src/session"
+	"alexa-skill-test/src/surname"
+	"alexa-skill-test/src/upstream"
 	"alexa-skill-test/src/user"
-	"bytes"
+	"alexa-skill-test/src/validation"
+	"alexa-skill-test/src/vcr"
+	"alexa-skill-test/src/verifier"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// cfg holds the settings loaded from the environment when the lambda starts
+var cfg = config.Load()
+
+// logger writes the logs of the skill as json, with names redacted according
+// to the configured pii mode. It is the default logger as well, so the lines
+// of the aws sdk and other libraries are structured too
+var logger = newLogger()
+
+// newLogger returns the logger of the skill, making it the default one
+func newLogger() *slog.Logger {
+	logger := logging.New(os.Stderr, logging.ParseLevel(cfg.LogLevel), pii.ParseMode(cfg.PIILogging))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// requestVerifier checks that requests received over http were signed by Alexa
+var requestVerifier = verifier.New()
+
+// httpClient sends every request to the upstream apis,
+// retrying the lookups that fail for a transient reason
+var httpClient = newHTTPClient()
+
+// newHTTPClient returns the client of the upstream apis, which records
+// or replays their responses when a fixtures directory is configured
+func newHTTPClient() *http.Client {
+	policy := retry.Policy{
+		Attempts:       cfg.RetryAttempts,
+		Backoff:        cfg.RetryBackoff,
+		MaxBackoff:     cfg.RetryMaxBackoff,
+		AttemptTimeout: cfg.RetryAttemptTimeout,
+	}
+	if cfg.FixturesDir == "" {
+		return retry.NewClient(policy, cfg.UpstreamTimeout)
+	}
+	mode := vcr.Mode(cfg.FixturesMode)
+	if mode != vcr.Record {
+		mode = vcr.Replay
+	}
+	slog.Info("using upstream fixtures", "dir", cfg.FixturesDir, "mode", string(mode))
+	recorder := &vcr.Transport{Mode: mode, Dir: cfg.FixturesDir}
+	return &http.Client{Timeout: cfg.UpstreamTimeout, Transport: &retry.Transport{Base: recorder, Policy: policy}}
+}
+
+// metricsRecorder publishes the metrics of the skill to CloudWatch
+var metricsRecorder = metrics.New(cfg.MetricsNamespace)
+
+// recordRequest publishes how long every request took and whether it was rejected
+func recordRequest(intent string, elapsed time.Duration, err error) {
+	rejected := 0.0
+	if err != nil {
+		rejected = 1
+	}
+	metricsRecorder.Record("RequestLatency", float64(elapsed.Milliseconds()), metrics.Milliseconds, "Intent", intent)
+	metricsRecorder.Record("RequestRejected", rejected, metrics.Count, "Intent", intent)
+}
+
+// nationalizeQuota tracks the requests left today on nationalize.io,
+// shared by every nationalize provider since they use the same quota
+var nationalizeQuota = newNationalizeQuota()
+
+// newNationalizeQuota returns a quota publishing the requests left as a metric
+func newNationalizeQuota() *nationality.Quota {
+	quota := nationality.NewQuota()
+	quota.Report = func(remaining int) {
+		metricsRecorder.Record("NationalizeQuotaRemaining", float64(remaining), metrics.Count)
+	}
+	return quota
+}
+
+// newSoloNationalityProvider returns the configured chain without the ensemble
+func newSoloNationalityProvider() nationality.Provider {
+	if _, ok := newEnsemble(cfg.EnsembleProviders); !ok {
+		return nil
+	}
+	return nationality.NewShared(newNationalityChain(false), cfg.UpstreamTimeout)
+}
+
+// newNationalityChain returns the configured provider, or the ensemble when one is
+// configured and withEnsemble is set, falling back to the configured fallback
+// provider and then to the offline dataset when they are enabled
+func newNationalityChain(withEnsemble bool) nationality.Provider {
+	primary := nationality.Named{Name: cfg.NationalityProvider, Provider: newNationalityProvider(cfg.NationalityProvider)}
+	if ensemble, ok := newEnsemble(cfg.EnsembleProviders); ok && withEnsemble {
+		primary = nationality.Named{Name: "ensemble", Provider: ensemble}
+	}
+	chain := []nationality.Named{primary}
+	if cfg.NationalityFallback != "" && cfg.NationalityFallback != cfg.NationalityProvider {
+		chain = append(chain, nationality.Named{Name: cfg.NationalityFallback, Provider: newNationalityProvider(cfg.NationalityFallback)})
+	}
+	// a lone provider is only wrapped when its failures must be tracked for alerts
+	if len(chain) == 1 && !cfg.OfflineFallback && providerMonitor == nil {
+		return chain[0].Provider
+	}
+
+	fallback := nationality.NewFallback(chain...)
+	// each provider gets its share of the time, so the last one still has time to answer
+	fallback.AttemptTimeout = cfg.UpstreamTimeout / time.Duration(len(chain))
+	if cfg.OfflineFallback {
+		fallback.Offline = nationality.Offline{}
+	}
+	if providerMonitor != nil {
+		fallback.Observe = observeProvider
+	}
+	return fallback
+}
+
+// newEnsemble returns an ensemble of the providers listed in members as "name" or
+// "name:weight", reporting whether there were at least two of them to average
+func newEnsemble(members []string) (nationality.Ensemble, bool) {
+	var ensemble nationality.Ensemble
+	for _, member := range members {
+		name, weightText, hasWeight := strings.Cut(member, ":")
+		weight := 1.0
+		if hasWeight {
+			parsed, err := strconv.ParseFloat(weightText, 64)
+			if err != nil || parsed <= 0 {
+				slog.Warn("ignoring invalid weight of ensemble member", "member", member)
+			} else {
+				weight = parsed
+			}
+		}
+		ensemble.Members = append(ensemble.Members, nationality.Member{Name: name, Provider: newNationalityProvider(name), Weight: weight})
+	}
+	return ensemble, len(ensemble.Members) >= 2
+}
+
+// newNationalityProvider returns the provider called name, one of "nationalize", "namsor"
+// or "offline", falling back to nationalize.io when the name is unknown or NamSor is
+// chosen without an api key
+func newNationalityProvider(name string) nationality.Provider {
+	switch {
+	case name == nationality.OfflineSource:
+		return nationality.Offline{}
+	case name == "namsor" && cfg.NamSorAPIKey != "":
+		return nationality.NamSor{Client: surname.NamSor{BaseURL: cfg.SurnameURL, APIKey: cfg.NamSorAPIKey, HTTP: httpClient}}
+	case name != "nationalize":
+		slog.Warn("unusable nationality provider, using nationalize", "provider", name)
+	}
+	return nationality.Nationalize{BaseURL: cfg.NationalizeURL, HTTP: httpClient, Quota: nationalizeQuota, Vintage: cfg.NationalizeVintage}
+}
+
+// lookupCache keeps the responses of the upstream apis by url for cfg.LookupCacheTTL
+var lookupCache = cache.New(cfg.LookupCacheTTL)
+
+// newCountriesProvider returns the countries api, or the embedded
+// countries when the api is disabled
+func newCountriesProvider() countries.Provider {
+	if cfg.DisableCountryAPI {
+		return countries.Embedded
+	}
+	return countries.API{BaseURL: cfg.CountriesURL, HTTP: httpClient, Cache: countries.NewCache()}
+}
+
+// demonymOverrides replaces the demonyms of specific countries, as configured by the operator
+var demonymOverrides = loadDemonymOverrides(cfg.DemonymOverridesFile)
+
+// blocklist holds the names the skill refuses to make guesses for
+var blocklist = names.NewBlocklist(cfg.BlockedNames)
+
 // HandleHelpIntent handles requests for help from users of the skill
-func HandleHelpIntent(request alexa.Request) alexa.Response {
+func HandleHelpIntent(ctx context.Context, request alexa.Request) alexa.Response {
 	// builder is used instead of alexa simple response for more
 	// sophisticated response including voice pauses and other features
+	templates := messagesFor(ctx, request)
 	var builder alexa.SSMLBuilder
-	builder.Say("You can ask me like so:")
+	builder.Say(templates.Render("help.intro"))
 	builder.Pause("1000")
-	builder.Say("Alexa, ask the genie to guess my nationality using my linked account. ")
-	builder.Say("or, ")
-	builder.Say("Alexa, ask the genie to guess my nationality. my name is Ethan")
-	return alexa.NewSSMLResponse("Help", builder.Build())
+	builder.Say(templates.Render("help.linked"))
+	builder.Say(templates.Render("help.or"))
+	builder.Say(templates.Render("help.name"))
+	card := alexa.NewSimpleCard(templates.Render("title.help"), templates.Render("help.card"))
+	return alexa.NewSSMLResponse(templates.Render("title.help"), builder.Build()).WithCard(card)
+}
+
+// HandleLaunchRequest welcomes users opening the skill without asking
+// for anything and waits for a name.
+// A user can say:
+// Alexa, open nationality guesser
+func HandleLaunchRequest(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	response := alexa.NewSimpleResponse(templates.Render("title.welcome"), templates.Render("launch.welcome")).
+		WithReprompt(templates.Render("launch.reprompt")).
+		WithShouldEndSession(false)
+
+	// saved friends are taught to Alexa right away so they're recognized the first time they're asked about
+	attributes := copySessionAttributes(request)
+	if saved, err := userFriends(ctx, request, attributes); err != nil {
+		logging.FromContext(ctx).Error("reading friends failed", "error", err)
+	} else if len(saved) > 0 {
+		response = response.WithSessionAttributes(attributes).WithDirectives(buildFriendsDirective(saved))
+	}
+	return response
+}
+
+// nameIntentModel declares NameIntent in the interaction model
+var nameIntentModel = model.Intent{
+	Name:    "NameIntent",
+	Slots:   []model.Slot{{Name: "first_name", Type: "FirstName"}},
+	Samples: []string{"{first_name}"},
+}
+
+// HandleNameIntent chains a name said on its own, typically right after
+// the skill was opened, into GuessIntent so it doesn't need the full phrasing.
+// A user can say:
+// Ethan
+func HandleNameIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	return alexa.NewDelegateRequestResponse(alexa.Intent{
+		Name: "GuessIntent",
+		Slots: map[string]alexa.Slot{
+			"first_name": request.Body.Intent.Slots["first_name"],
+		},
+	})
+}
+
+// aboutIntentModel declares AboutIntent in the interaction model
+var aboutIntentModel = model.Intent{
+	Name:    "AboutIntent",
+	Samples: []string{"what is this skill", "tell me about this skill", "how do you work", "who made you"},
 }
 
 // HandleAboutIntent handles requests from users asking about the skill
-func HandleAboutIntent(request alexa.Request) alexa.Response {
+func HandleAboutIntent(ctx context.Context, request alexa.Request) alexa.Response {
 	// NewSimpleResponse responds with simple text to the client using the skill
-	return alexa.NewSimpleResponse("About", "Thanks for using me! I can guess your nationality based on your first name. After providing me with your name, I'll list some countries where you might be from, along with a probability for each of them!")
+	templates := messagesFor(ctx, request)
+	return alexa.NewSimpleResponse(templates.Render("title.about"), templates.Render("about.text"))
+}
+
+// HandleStopIntent ends the session when the user is done
+func HandleStopIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	response := alexa.NewSimpleResponse(templates.Render("title.goodbye"), templates.Render("stop.text"))
+	// stop the anthem too, if one is playing
+	if request.AudioPlaying() {
+		response = response.WithDirectives(alexa.NewStopDirective(), alexa.NewClearQueueDirective(alexa.ClearAll))
+	}
+	return response
+}
+
+// repeatIntentModel declares the built-in repeat intent in the interaction model
+var repeatIntentModel = model.Intent{Name: "AMAZON.RepeatIntent"}
+
+// HandleRepeatIntent speaks the last guess of the session again
+// without querying any api.
+// A user can say:
+// Alexa, repeat that
+func HandleRepeatIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	last, ok := session.Load(request.Session.Attributes).LastGuess()
+	if !ok {
+		return alexa.NewSimpleResponse(templates.Render("title.repeat"), templates.Render("repeat.empty")).
+			WithShouldEndSession(false)
+	}
+	return alexa.NewSSMLResponse(templates.Render("title.repeat"), last.Speech).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// copySessionAttributes returns a copy of the session attributes of request
+// that can be modified and returned with the response
+func copySessionAttributes(request alexa.Request) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for key, value := range request.Session.Attributes {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// guessIntentModels declare the intents HandleGuessIntent handles in the interaction model
+var guessIntentModels = []model.Intent{
+	{
+		Name: "GuessIntent",
+		Slots: []model.Slot{{
+			Name:    "first_name",
+			Type:    "FirstName",
+			Samples: []string{"{first_name}", "my name is {first_name}", "it's {first_name}"},
+		}},
+		Samples: []string{
+			"guess my nationality",
+			"guess my nationality my name is {first_name}",
+			"my name is {first_name}",
+			"what about {first_name}",
+			"how about {first_name}",
+			"where is {first_name} from",
+			"guess the nationality of {first_name}",
+		},
+	},
+	{
+		Name:    "GuessWithAccountIntent",
+		Samples: []string{"guess my nationality from my account", "use the name on my account"},
+	},
 }
 
 // HandleGuessIntent is the most important handler.
@@ -42,32 +342,278 @@ func HandleAboutIntent(request alexa.Request) alexa.Response {
 // person based on their name that they provided with the request.
 // A user can say:
 // Alexa, ask nationality guesser to guess my nationality, my name is Ethan
-func HandleGuessIntent(request alexa.Request, usingLinkedAccount bool) alexa.Response {
-	var firstName string
-	if usingLinkedAccount {
-		// get name using user's linked account
-		firstName = fetchGivenName(request.Session.User.AccessToken)
-	} else {
-		// extract first name of user from the request slots
-		firstName = getValueOfName(request.Body.Intent.Slots, "first_name")
+// Alexa, ask nationality guesser to guess my nationality
+func HandleGuessIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	slot := request.Body.Intent.Slots["first_name"]
+
+	// the user said the name they gave was heard wrongly, so ask for it again
+	if slot.Denied() {
+		return withNameReprompt(request, templates, alexa.NewElicitIntentSlotResponse("first_name", guessIntent(), templates.Render("guess.misheard")))
+	}
+
+	firstName, err := guessedName(ctx, request, slot)
+	if firstName == "" {
+		// nothing to guess from, so ask for a name, offering the account
+		// permission when that's what kept the skill from reading it
+		if errors.Is(err, errNoPermission) {
+			return withNameReprompt(request, templates, alexa.NewElicitIntentSlotResponse("first_name", guessIntent(), templates.Render("guess.ask_name_permission"))).
+				WithCard(alexa.NewAskForPermissionsConsentCard(alexa.GivenNamePermission))
+		}
+		return withNameReprompt(request, templates, alexa.NewElicitIntentSlotResponse("first_name", guessIntent(), templates.Render("guess.ask_name")))
+	}
+
+	// confirm unusual names before spending two api calls on a wrong transcription
+	if slot.Value != "" && !slot.Confirmed() && needsConfirmation(slot) {
+		return alexa.NewConfirmSlotResponse("first_name", request.Body.Intent, templates.Render("guess.confirm", "name", names.Sanitize(firstName)))
+	}
+	firstName = names.Sanitize(firstName)
+
+	// refuse offensive queries before any request is sent upstream
+	if blocklist.Contains(firstName) {
+		logging.FromContext(ctx).Info("refusing to guess blocked name", logging.NameKey, firstName)
+		return alexa.NewSimpleResponse(templates.Render("title.guess"), templates.Render("guess.blocked"))
+	}
+
+	// during a party round the name is the one of the player holding the mic
+	if _, ok := session.Load(request.Session.Attributes).Party(); ok {
+		return addPartyPlayer(ctx, request, templates, firstName)
 	}
 
-	fmt.Println(firstName)
+	logging.FromContext(ctx).Info("guessing nationality", logging.NameKey, firstName)
+
+	// Repeat the name back first so the user knows whether it was heard correctly.
+	// Famous names get a playful line instead, before their guess as usual
+	var intro string
+	if id, ok := names.Famous(firstName); ok {
+		intro = templates.Render("famous." + id)
+	} else if cfg.ConfirmName && firstName != "" {
+		intro = templates.Render("guess.heard", "name", firstName)
+	}
+	return respondWithGuess(ctx, request, templates, firstName, intro)
+}
+
+// guessedName returns the name a guess request is about, taken from the first
+// source having one: the account of the speaker, unless they said whose name
+// to guess, then the first_name slot. The name read from the account is cached
+// in the session attributes the guess is answered with.
+// The error of the account is returned along with an empty name so
+// the caller can tell why there was nothing to guess from
+func guessedName(ctx context.Context, request alexa.Request, slot alexa.Slot) (string, error) {
+	var err error
+	if slot.Value == "" {
+		attributes := copySessionAttributes(request)
+		var name string
+		name, err = accountGivenName(ctx, request, attributes)
+		request.Session.Attributes = attributes
+		if err == nil && name != "" {
+			return name, nil
+		}
+		if err != nil && !errors.Is(err, errNoPermission) {
+			logging.FromContext(ctx).Warn("fetching given name failed", "error", err)
+		}
+	}
+	return getValueOfName(request.Body.Intent.Slots, "first_name"), err
+}
+
+// withNameReprompt returns response, which asks for a name, with a reprompt
+// giving more guidance the more often the name was asked for in a row: a
+// short nudge first, then an example of what to say, then the suggestion to
+// spell the name out. The count is kept in the session state
+func withNameReprompt(request alexa.Request, templates messages.Set, response alexa.Response) alexa.Response {
+	attributes := copySessionAttributes(request)
+	store := session.Load(attributes)
+	attempts := store.NameAttempts() + 1
+	store.SetNameAttempts(attempts)
+
+	var builder alexa.SSMLBuilder
+	switch {
+	case attempts <= 1:
+		builder.Say(templates.Render("name.reprompt.nudge"))
+	case attempts == 2:
+		builder.Say(templates.Render("name.reprompt.example"))
+	default:
+		for _, part := range templates.Split("name.reprompt.spell") {
+			switch {
+			case part == "{example}":
+				builder.SayAs("spell-out", "Maria")
+			case strings.TrimSpace(part) != "":
+				builder.Say(strings.TrimSpace(part))
+			}
+		}
+	}
+	return response.WithSSMLReprompt(builder.Build()).WithSessionAttributes(attributes)
+}
+
+// guessIntent returns an empty GuessIntent, which names are elicited for
+// whichever intent the request asking to guess came through
+func guessIntent() alexa.Intent {
+	return alexa.Intent{
+		Name:  "GuessIntent",
+		Slots: map[string]alexa.Slot{"first_name": {Name: "first_name"}},
+	}
+}
+
+// needsConfirmation reports whether the name heard in slot should be confirmed
+// with the user because it looks unusual. The catalog of first names only
+// holds common ones, so a name it didn't resolve isn't unusual for that alone
+func needsConfirmation(slot alexa.Slot) bool {
+	if !cfg.ConfirmUnusualNames || slot.ResolvedName(names.SlotType) != "" {
+		return false
+	}
+	return names.LooksUnusual(names.Sanitize(slot.Value))
+}
+
+// withResolvedNames returns request with the value of every slot the
+// catalog of first names resolved replaced by the spelling of the catalog,
+// so nicknames and names Alexa transcribes in several ways are guessed from
+// the spelling the upstream apis know best, e.g. "Bill" as "William"
+func withResolvedNames(request alexa.Request) alexa.Request {
+	if request.Body.Intent.Slots == nil {
+		return request
+	}
+	slots := map[string]alexa.Slot{}
+	for key, slot := range request.Body.Intent.Slots {
+		if resolved := slot.ResolvedName(names.SlotType); resolved != "" {
+			slot.Value = resolved
+		}
+		slots[key] = slot
+	}
+	request.Body.Intent.Slots = slots
+	return request
+}
+
+// surpriseIntentModel declares SurpriseIntent in the interaction model
+var surpriseIntentModel = model.Intent{
+	Name:    "SurpriseIntent",
+	Samples: []string{"surprise me", "pick a random name", "guess a random name"},
+}
+
+// HandleSurpriseIntent picks a random common name, tells the
+// user which one it chose and guesses its nationality.
+// A user can say:
+// Alexa, ask nationality guesser to surprise me
+func HandleSurpriseIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	firstName := pickSurpriseName()
+	templates := messagesFor(ctx, request)
+	return respondWithGuess(ctx, request, templates, firstName, templates.Render("guess.surprise", "name", firstName))
+}
+
+// messagesFor returns the templates phrasing the responses to request in
+// its locale. English speakers are also bucketed into a phrasing variant,
+// which is logged so A/B tests can be analyzed. Messages having several
+// phrasings get one picked at random for the whole response
+func messagesFor(ctx context.Context, request alexa.Request) messages.Set {
+	templates := i18n.For(request.Body.Locale)
+	if language := i18n.Language(request.Body.Locale); language != "" && language != "en" {
+		return pickPhrasings(templates)
+	}
+	variant, overlay := messages.Select(request.Session.User.UserID, cfg.TemplateVariant)
+	logging.FromContext(ctx).Info("using template variant", "variant", variant)
+	return pickPhrasings(templates.Merge(overlay))
+}
+
+// formatsFor returns the formatter of numbers and lists matching the
+// language messagesFor phrases the responses to request in
+func formatsFor(request alexa.Request) format.Formatter {
+	return format.For(responseLocale(request))
+}
+
+// responseLocale returns the locale the responses to request are phrased
+// in, the default one when the skill doesn't speak the locale of request
+func responseLocale(request alexa.Request) string {
+	if !i18n.Supported(request.Body.Locale) {
+		return i18n.DefaultLocale
+	}
+	return request.Body.Locale
+}
+
+// respondWithGuess fetches the guesses for firstName and builds the response
+// speaking them, preceded by intro when it isn't empty
+func respondWithGuess(ctx context.Context, request alexa.Request, templates messages.Set, firstName string, intro string) alexa.Response {
+	analytics.Annotate(ctx, func(event *analytics.Event) { event.Name = firstName })
+	predictionsResponse, countries, err := fetchGuesses(ctx, firstName)
+	if err != nil {
+		logging.FromContext(ctx).Error("nationality guess failed", "error", err)
+		return respondWithError(ctx, templates, "title.guess", err)
+	}
+	predictions, favoured := adjustPredictions(ctx, request, predictionsResponse.Predictions)
+	predictionsResponse.Predictions = predictions
+
+	// Build and send response using data above
+	attributes := copySessionAttributes(request)
+	prefs := userPreferences(ctx, request, attributes)
+	var outro string
+	if prefs.Length != preferences.Short {
+		outro = popularityNote(templates, formatsFor(request), firstName, countries, predictionsResponse.Predictions)
+	}
+	speech := buildGuessResponse(templates, prefs, intro, outro, countries, predictionsResponse)
+
+	// The last guess is kept in the session so it can be repeated without fetching it again
+	store := session.Load(attributes)
+	last, _ := store.LastGuess()
+	last.Name, last.Speech = firstName, speech
+	last.Sources, last.Count, last.Vintage = guessSources(predictionsResponse), predictionsResponse.Count, predictionsResponse.Vintage
+	last.Favoured = favoured
+	if firstName != "" {
+		recordGuess(attributes, speakerID(request), firstName)
+	}
+	saveGuess(ctx, request, firstName, predictionsResponse.Predictions)
+
+	// When there is a guess the user was offered a fact about the top country,
+	// so the next yes or no answers that offer
+	reprompt := templates.Render("guess.reprompt")
+	setDialogState(attributes, dialogIdle, "")
+	if len(predictionsResponse.Predictions) > 0 {
+		top := sortPredictions(predictionsResponse.Predictions)[0].Country_id
+		setDialogState(attributes, dialogOfferedFact, top)
+		last.Country = top
+		analytics.Annotate(ctx, func(event *analytics.Event) { event.TopCountry = top })
+		reprompt = templates.Render("fact.reprompt", "country", findCountryName(templates, countries, top))
+	}
+	store.SetLastGuess(last)
+
+	// The session stays open so the user can follow up with another name
+	// right away, e.g. "what about Maria?", without invoking the skill again
+	response := alexa.NewSSMLResponse(templates.Render("title.guess"), speech).
+		WithCard(buildGuessCard(templates, formatsFor(request), firstName, countries, predictionsResponse, request.RendersEmoji())).
+		WithReprompt(reprompt).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+
+	// With background music or a sound effect configured the guesses are
+	// spoken through an audio document mixing them instead of plain ssml,
+	// unless the ssml was malformed and replaced by a plain text apology
+	if (cfg.BackgroundAudioURL != "" || cfg.GuessSoundURL != "") && response.Body.OutputSpeech.Type == "SSML" {
+		response.Body.OutputSpeech = nil
+		response.Body.Directives = append(response.Body.Directives, buildGuessAudioDirective(speech))
+	}
+
+	// Devices with screens also get a visual list of the guesses
+	if request.SupportsAPL() && featureFlags.Enabled(ctx, flags.APL) && len(predictionsResponse.Predictions) > 0 {
+		response.Body.Directives = append(response.Body.Directives, buildGuessDirective(request.Context.Viewport, countries, predictionsResponse))
+	}
+	return response
+}
 
+// fetchGuesses fetches the nationality guesses for firstName
+// along with information about every guessed country.
+// It only fails when no guess could be made
+func fetchGuesses(ctx context.Context, firstName string) (nationality.Response, countries.Country, error) {
 	// fetch nationality guesses from the network for the name extracted above
 	// the API returns country codes for which the person might be from
-	predictionsResponse := fetchNationalityPredictions(firstName)
+	predictionsResponse, err := lookupNationality(ctx, firstName)
+	if err != nil {
+		return predictionsResponse, nil, err
+	}
 
 	// append all country codes to an array of codes
 	countryCodes := appendCountryCodes(predictionsResponse)
 
 	// Using country codes we have,
 	// fetch information about those countries from the network
-	countries := fetchCountriesOfCodes(countryCodes)
-
-	// Build and send response using data above
-	response := buildGuessResponse(countries, predictionsResponse)
-	return alexa.NewSSMLResponse("Nationality Guess", response)
+	countries := lookupCountries(ctx, countryCodes)
+	return predictionsResponse, countries, nil
 }
 
 // API sending nationality guesses returns country codes for guesses
@@ -83,34 +629,303 @@ func appendCountryCodes(response nationality.Response) []string {
 }
 
 // buildGuessResponse creates a response builder and builds a guessing
-// response to be sent to the skill user
-func buildGuessResponse(countries countries.Country, predictionsResponse nationality.Response) string {
+// response to be sent to the skill user, starting with intro if any and
+// saying outro, if any, after the guesses, following the preferences of the user
+func buildGuessResponse(templates messages.Set, prefs preferences.Preferences, intro string, outro string, countries countries.Country, predictionsResponse nationality.Response) string {
+	predictions := predictionsResponse.Predictions
+	if cfg.Percentages == percentagesNormalized {
+		predictions = nationality.Normalize(predictions)
+	}
+	spoken := selectSpokenPredictions(predictions)
+	if prefs.Guesses == preferences.TopGuess && len(spoken) > 1 {
+		spoken = spoken[:1]
+	}
+	response := renderGuessResponse(templates, prefs, intro, outro, countries, predictionsResponse, spoken, false)
+
+	// Alexa rejects speech over its length limit, so drop the least
+	// likely guesses until the response fits and mention the skipped ones
+	for len(response) > alexa.MaxSpeechLength && len(spoken) > 1 {
+		spoken = spoken[:len(spoken)-1]
+		response = renderGuessResponse(templates, prefs, intro, outro, countries, predictionsResponse, spoken, true)
+	}
+	return response
+}
+
+// renderGuessResponse builds the ssml speaking the spoken predictions between
+// intro and outro, noting that some guesses were skipped when trimmed is true.
+// Users who asked for short answers don't hear the notes about the guesses
+func renderGuessResponse(templates messages.Set, prefs preferences.Preferences, intro string, outro string, countries countries.Country, predictionsResponse nationality.Response, spoken []nationality.Prediction, trimmed bool) string {
 	// Build and send response using data above
 	var builder alexa.SSMLBuilder
+	builder.UseVoice(cfg.PollyVoice)
 
+	// intro tells the user which name the guesses are for
+	if intro != "" {
+		builder.Say(intro)
+		builder.Pause("300")
+	}
+
+	// Once nationalize's quota is used up guesses come from the offline dataset,
+	// which the user is told about gently rather than hearing no guess at all
+	degraded := nationalizeQuota.Exhausted() && (len(predictionsResponse.Predictions) == 0 || isOffline(predictionsResponse))
 	if len(predictionsResponse.Predictions) == 0 {
 		// If no guesses are found for the name provided, return a message
-		builder.Say(fmt.Sprintf("Sorry, I couldn't guess your nationality based on the name you provided. Try again with your friends' names!"))
+		if degraded {
+			builder.Say(templates.Render("guess.quota_none"))
+		} else {
+			builder.Say(templates.Render("guess.none"))
+		}
 	} else {
-		builder.Say("There is a")
-		// Otherwise, loop through guesses
-		for i, v := range predictionsResponse.Predictions {
+		if degraded {
+			builder.Say(templates.Render("guess.quota"))
+			builder.Pause("300")
+		}
+		// Few records behind a guess means it is shaky, so warn the user first.
+		// A zero count means the api didn't report it at all
+		short := prefs.Length == preferences.Short
+		if !short && predictionsResponse.Count > 0 && predictionsResponse.Count < cfg.LowCountThreshold {
+			builder.Say(templates.Render("guess.uncommon"))
+			builder.Pause("300")
+		}
+		// Guesses averaging several providers say so, unless the operator turned the note off
+		if !short && cfg.EnsembleNote && len(predictionsResponse.Sources) > 1 {
+			builder.Say(templates.Render("guess.ensemble", "count", strconv.Itoa(len(predictionsResponse.Sources))))
+			builder.Pause("300")
+		}
+		inWords := confidencePhrasing(prefs) == preferences.Words
+		if !inWords {
+			builder.Say(templates.Render("guess.lead"))
+		}
+		// Otherwise, loop through the guesses worth reading
+		for i, v := range spoken {
 			// if it's the first guess, don't pause before saying it, otherwise do.
 			if i != 0 {
 				builder.Pause("500")
 			}
 			// Use information fetched to say a guess with a probability and a demonym
-			builder.Say(fmt.Sprintf("%d percent chance you're %s.", int(v.Probability*100), findCountryOfCode(countries, v.Country_id)))
+			template := "guess.item"
+			if inWords {
+				template = confidenceBand(v.Probability)
+			}
+			sayGuess(&builder, templates, template, int(v.Probability*100), findCountryOfCode(countries, v.Country_id), i == 0)
+			if i == 0 && !short {
+				sayNativeName(&builder, templates, countries, v.Country_id)
+				if facts := quickFacts(templates, countries, v.Country_id); facts != "" {
+					builder.Pause("300")
+					builder.Say(facts)
+				}
+			}
+		}
+		if remainder := remainingPercent(spoken); !inWords && cfg.Percentages == percentagesRemainder && remainder > 0 {
+			builder.Pause("300")
+			builder.Say(templates.Render("guess.remainder", "percent", strconv.Itoa(remainder)))
 		}
+		if trimmed {
+			builder.Pause("300")
+			builder.Say(templates.Render("guess.truncated"))
+		}
+		if outro != "" {
+			builder.Pause("300")
+			builder.Say(outro)
+		}
+	}
+	builder.Pause("500")
+	if len(spoken) > 0 {
+		// offer a fact about the most likely country, answered with yes or no
+		builder.Say(templates.Render("fact.offer", "country", findCountryName(templates, countries, spoken[0].Country_id)))
+	} else {
+		builder.Say(templates.Render("guess.followup"))
 	}
 	return builder.Build()
 }
 
+// popularityNote returns the note telling how popular firstName is in the
+// most likely country, empty when there is no ranking of its names
+func popularityNote(templates messages.Set, formats format.Formatter, firstName string, fetched countries.Country, predictions []nationality.Prediction) string {
+	if firstName == "" || len(predictions) == 0 {
+		return ""
+	}
+	top := sortPredictions(predictions)[0].Country_id
+	rank, gender, ok := names.Rank(top, firstName)
+	if !ok {
+		return ""
+	}
+	message := "popularity." + gender
+	if rank == 1 {
+		message += "_top"
+	}
+	return templates.Render(message, "name", firstName, "rank", formats.Ordinal(rank), "country", findCountryName(templates, fetched, top))
+}
+
+// sayGuess speaks a single guess using template, reading the percentage
+// as a number and stressing the demonym of the top guess.
+// Demonyms that Alexa mispronounces are spoken using their IPA pronunciation
+func sayGuess(builder *alexa.SSMLBuilder, templates messages.Set, template string, percent int, demonym string, top bool) {
+	ipa, hasPronunciation := countries.Pronunciation(demonym)
+	for _, part := range templates.Split(template) {
+		switch {
+		case part == "{percent}":
+			builder.SayAs("cardinal", strconv.Itoa(percent))
+		case part == "{demonym}" && hasPronunciation:
+			builder.Phoneme("ipa", ipa, demonym)
+		case part == "{demonym}" && top:
+			builder.Emphasis("moderate", demonym)
+		case part == "{demonym}":
+			builder.Say(demonym)
+		case strings.TrimSpace(part) != "":
+			builder.Say(strings.TrimSpace(part))
+		}
+	}
+}
+
+// sayNativeName speaks the name of the country having code in its own
+// language, using the matching voice model, if Alexa has a voice for it
+func sayNativeName(builder *alexa.SSMLBuilder, templates messages.Set, fetched countries.Country, code string) {
+	locale, ok := countries.SpeechLocale(code)
+	if !ok {
+		return
+	}
+	native := findNativeName(fetched, code)
+	if native == "" {
+		return
+	}
+	for _, part := range templates.Split("guess.native") {
+		switch {
+		case part == "{native}":
+			builder.Lang(locale, native)
+		case strings.TrimSpace(part) != "":
+			builder.Say(strings.TrimSpace(part))
+		}
+	}
+}
+
+// quickFacts returns the capital and currency of the country having code,
+// empty when neither is known
+func quickFacts(templates messages.Set, fetched countries.Country, code string) string {
+	country, _ := findCountryInfo(fetched, code)
+	var currency string
+	if len(country.Currencies) > 0 {
+		currency = country.Currencies[0].Name
+	}
+	switch {
+	case country.Capital != "" && currency != "":
+		return templates.Render("guess.capital_currency", "capital", country.Capital, "currency", currency)
+	case country.Capital != "":
+		return templates.Render("guess.capital", "capital", country.Capital)
+	case currency != "":
+		return templates.Render("guess.currency", "currency", currency)
+	}
+	return ""
+}
+
+// findCountryInfo returns the information about the country having code,
+// looking it up in the embedded countries if it wasn't fetched
+func findCountryInfo(fetched countries.Country, code string) (countries.Info, bool) {
+	for _, list := range []countries.Country{fetched, countries.Embedded} {
+		for _, country := range list {
+			if country.Code == code {
+				return country, true
+			}
+		}
+	}
+	return countries.Info{}, false
+}
+
+// findNativeName returns the name of the country having code in its own language
+func findNativeName(fetched countries.Country, code string) string {
+	country, _ := findCountryInfo(fetched, code)
+	return country.NativeName
+}
+
+// findCountryName returns the english name of the country having code
+func findCountryName(templates messages.Set, fetched countries.Country, code string) string {
+	if country, ok := findCountryInfo(fetched, code); ok && country.Name != "" {
+		return country.Name
+	}
+	return templates.Render("fact.that_country")
+}
+
+// buildGuessCard summarizes every guess for firstName in a card shown in
+// the Alexa app, along with the flag of the most likely country. With emoji
+// set each guess is preceded by the flag emoji of its country
+func buildGuessCard(templates messages.Set, formats format.Formatter, firstName string, fetched countries.Country, predictionsResponse nationality.Response, emoji bool) *alexa.Payload {
+	title := templates.Render("title.guess_card", "name", firstName)
+	if len(predictionsResponse.Predictions) == 0 {
+		return alexa.NewSimpleCard(title, templates.Render("guess.card_none"))
+	}
+
+	predictions := sortPredictions(predictionsResponse.Predictions)
+	var lines []string
+	for _, v := range predictions {
+		line := findCountryOfCode(fetched, v.Country_id) + ": " + formats.Percent(v.Probability)
+		if flag := countries.FlagEmoji(v.Country_id); emoji && flag != "" {
+			line = flag + " " + line
+		}
+		lines = append(lines, line)
+	}
+	top := predictions[0].Country_id
+	return alexa.NewStandardCard(title, strings.Join(lines, "\n"), countries.FlagURL(top, 640), countries.FlagURL(top, 1280))
+}
+
+// selectSpokenPredictions returns only the top prediction when it dominates
+// the others, since reading the rest adds nothing, otherwise the top few.
+// The predictions returned are sorted from the most to the least likely
+func selectSpokenPredictions(predictions []nationality.Prediction) []nationality.Prediction {
+	predictions = sortPredictions(predictions)
+	if len(predictions) > 0 && predictions[0].Probability > cfg.DominanceThreshold {
+		return predictions[:1]
+	}
+	if cfg.MaxGuesses > 0 && len(predictions) > cfg.MaxGuesses {
+		return predictions[:cfg.MaxGuesses]
+	}
+	return predictions
+}
+
+// Values of cfg.Percentages other than "raw"
+const (
+	// percentagesNormalized scales the guesses to sum to 100 percent
+	percentagesNormalized = "normalized"
+	// percentagesRemainder mentions the percentage left to the countries not spoken
+	percentagesRemainder = "remainder"
+)
+
+// remainingPercent returns the percentage left once the spoken
+// predictions are read, as whole percents like they are spoken
+func remainingPercent(spoken []nationality.Prediction) int {
+	remainder := 100
+	for _, v := range spoken {
+		remainder -= int(v.Probability * 100)
+	}
+	if remainder < 0 {
+		return 0
+	}
+	return remainder
+}
+
+// sortPredictions returns a copy of predictions sorted from the most to the least likely
+func sortPredictions(predictions []nationality.Prediction) []nationality.Prediction {
+	predictions = append([]nationality.Prediction(nil), predictions...)
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return predictions[i].Probability > predictions[j].Probability
+	})
+	return predictions
+}
+
 // Given a list of country struct objects
 // findCountryOfCode finds the country having a specific code
-// and returns the Demonym of that country/nationality
-func findCountryOfCode(countries countries.Country, code string) string {
-	for _, v := range countries {
+// and returns the Demonym of that country/nationality,
+// unless the operator configured an override for it
+func findCountryOfCode(fetched countries.Country, code string) string {
+	if demonym, ok := demonymOverrides[code]; ok {
+		return demonym
+	}
+	for _, v := range fetched {
+		if v.Code == code {
+			return v.Demonym
+		}
+	}
+	// fall back to the embedded countries for codes the api didn't return
+	for _, v := range countries.Embedded {
 		if v.Code == code {
 			return v.Demonym
 		}
@@ -118,6 +933,20 @@ func findCountryOfCode(countries countries.Country, code string) string {
 	return "Unknown"
 }
 
+// loadDemonymOverrides loads the demonym overrides file at path if one is configured.
+// A missing or malformed file is logged and ignored rather than failing every request
+func loadDemonymOverrides(path string) countries.Overrides {
+	if path == "" {
+		return countries.Overrides{}
+	}
+	overrides, err := countries.LoadOverrides(path)
+	if err != nil {
+		slog.Warn("ignoring demonym overrides", "error", err)
+		return countries.Overrides{}
+	}
+	return overrides
+}
+
 // Given slots received with the request
 // getValueOfName returns slot value of the slot
 // having the struct field "Name" value equal to the string parameter "name"
@@ -144,97 +973,355 @@ func getValueOfNameForUser(array []user.Attribute, name string) string {
 	return firstName
 }
 
-// fetchNationalityPredictions sends a network request to nationalize api to
-// make nationality guesses for a particular first name
-func fetchNationalityPredictions(name string) nationality.Response {
-	response, err := http.Get(fmt.Sprintf("https://api.nationalize.io?name=%s", name))
-	if err != nil {
-		fmt.Print(err.Error())
-		os.Exit(1)
-	}
+// nameQuery returns the query parameters asking an upstream api about name,
+// which is romanized first since the apis only know names by their latin spelling
+func nameQuery(name string) url.Values {
+	return url.Values{"name": {names.Romanize(name)}}
+}
 
-	responseData, err := ioutil.ReadAll(response.Body)
+// withQuery merges the query parameters in values into the base url
+func withQuery(base string, values url.Values) string {
+	u, err := url.Parse(base)
 	if err != nil {
-		log.Fatal(err)
+		return base + "?" + values.Encode()
 	}
-
-	var predictions nationality.Response
-	json.Unmarshal(responseData, &predictions)
-	return predictions
+	query := u.Query()
+	for key, value := range values {
+		query[key] = value
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
 }
 
-// fetchCountriesOfCodes takes an array of country
-// codes and fetches information about each one of them
-func fetchCountriesOfCodes(countryCodes []string) countries.Country {
-	response, err := http.Get(fmt.Sprintf("https://restcountries.eu/rest/v2/alpha?codes=%s", strings.Join(countryCodes, ";")))
+// fetchJSON gets url and decodes its json body into target,
+// returning an error instead of exiting when anything fails.
+// Statuses other than 200 OK fail with an upstream.StatusError, and requests
+// that can't be sent with an upstream.TransportError leaving out the url.
+// Successful responses are cached so repeated lookups aren't sent upstream
+func fetchJSON(ctx context.Context, url string, target interface{}) error {
+	if body, ok := lookupCache.Get(url); ok {
+		return json.Unmarshal(body, target)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		fmt.Print(err.Error())
-		os.Exit(1)
+		return err
 	}
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return upstream.Transport(req.URL.Host, err)
+	}
+	defer response.Body.Close()
 
-	responseData, err := ioutil.ReadAll(response.Body)
+	if err := upstream.Check(req.URL.Host, response); err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return upstream.Malformed(req.URL.Host, err)
 	}
+	lookupCache.Put(url, body)
+	return nil
+}
 
-	var countries countries.Country
-	json.Unmarshal(responseData, &countries)
-	return countries
+// lookupNationality asks the nationality provider to guess the nationality of name,
+// which is romanized first since the providers only know names by their latin spelling
+func lookupNationality(ctx context.Context, name string) (nationality.Response, error) {
+	upstream := providersFrom(ctx)
+	provider := upstream.nationality
+	if upstream.soloNationality != nil && !featureFlags.Enabled(ctx, flags.Ensemble) {
+		provider = upstream.soloNationality
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	return nationality.Lookup(ctx, provider, names.Romanize(name))
 }
 
-// fetchGivenName calls Cognito API with AccessToken provided in
-// the request received from alexa to get the
-// given (first) name of the user.
-func fetchGivenName(accessToken string) string {
-	values := map[string]string{"AccessToken": accessToken}
-	jsonValue, _ := json.Marshal(values)
+// isOffline reports whether response was made from the offline dataset
+func isOffline(response nationality.Response) bool {
+	return len(response.Sources) == 1 && response.Sources[0] == nationality.OfflineSource
+}
 
-	req, err := http.NewRequest("POST", "https://cognito-idp.us-east-2.amazonaws.com/", bytes.NewBuffer(jsonValue))
-	if err != nil {
-		log.Fatal("Error reading request. ", err)
-	}
-	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
-	req.Header.Set("Content-Length", "1162")
-	req.Header.Set("X-Amz-Target", "AWSCognitoIdentityProviderService.GetUser")
-	req.Header.Set("Content-Length", "1162")
-	client := http.Client{}
-	resp, err := client.Do(req)
+// lookupCountries returns information about the countries of countryCodes.
+// When the countries api fails the embedded countries are used instead,
+// since they only lack the details the api is more up to date about
+func lookupCountries(ctx context.Context, countryCodes []string) countries.Country {
+	fetched, err := fetchCountriesOfCodes(ctx, countryCodes)
 	if err != nil {
-		log.Fatal("Error reading response. ", err)
+		logging.FromContext(ctx).Warn("fetching countries failed, using the embedded ones", "error", err)
+		return countries.Embedded.OfCodes(countryCodes)
 	}
+	return fetched
+}
+
+// fetchCountriesOfCodes takes an array of country
+// codes and fetches information about each one of them
+func fetchCountriesOfCodes(ctx context.Context, countryCodes []string) (countries.Country, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	return providersFrom(ctx).countries.Fetch(ctx, countryCodes)
+}
 
-	responseData, err := ioutil.ReadAll(resp.Body)
-	var userData user.User
-	json.Unmarshal(responseData, &userData)
+// errNoPermission means the user hasn't allowed the skill to read their name
+var errNoPermission = failure.Wrap(failure.PermissionMissing, errors.New("not permitted to read the given name"))
 
-	return getValueOfNameForUser(userData.Attributes, "given_name")
+// errPanic is the cause of the responses to requests whose handler panicked
+var errPanic = errors.New("handler panicked")
 
+// newHandler returns the first function that lambda calls when a request to the skill is made,
+// which looks up upstream through providers.
+// Besides direct invocations by Alexa it accepts API Gateway and Lambda Function URL events.
+// ctx carries the deadline of the invocation, which every upstream call is bounded by
+func newHandler(upstream providers) func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+		return handleEvent(withProviders(ctx, upstream), event)
+	}
 }
 
-// Handler is the first function that lambda calls when a request to the skill is made
-func Handler(request alexa.Request) (alexa.Response, error) {
-	return IntentDispatcher(request), nil
+// intentSlots declares the slots each intent reads, which are
+// validated before the intent reaches its handler
+var intentSlots = map[string][]validation.Slot{
+	"GuessIntent": {
+		// a missing name is read from the account or asked for by HandleGuessIntent
+		{Name: "first_name", Kind: validation.Name, Description: "name"},
+	},
+	"NameIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"GuessGenderIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"GuessAgeIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"SurnameIntent": {
+		{Name: "last_name", Kind: validation.Name, Required: true, Description: "surname"},
+		{Name: "first_name", Kind: validation.Name, Description: "name"},
+	},
+	"RememberFriendIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"GuessFriendIntent": {
+		{Name: "friend", Kind: validation.Name, Required: true, Description: "friend's name"},
+	},
+	"DemonymIntent": {
+		{Name: "demonym", Kind: validation.Text, Required: true, Description: "people"},
+	},
+	"AnswerIntent": {
+		{Name: "country", Kind: validation.Text, Required: true, Description: "country"},
+	},
+	"NameMeaningIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"ProfileIntent": {
+		{Name: "first_name", Kind: validation.Name, Required: true, Description: "name"},
+	},
+	"MostInternationalIntent": {
+		{Name: "name_one", Kind: validation.Name, Required: true, Description: "first name"},
+		{Name: "name_two", Kind: validation.Name, Required: true, Description: "second name"},
+		{Name: "name_three", Kind: validation.Name, Description: "third name"},
+	},
 }
 
+// handleRequest is the dispatcher wrapped with the middlewares every request goes through
+var handleRequest = middleware.Chain(
+	func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+		return IntentDispatcher(ctx, request), nil
+	},
+	middleware.Logging(logger),
+	middleware.Metrics(recordRequest),
+	analyticsMiddleware(analyticsEmitter),
+	middleware.Recovery(func(ctx context.Context, request alexa.Request) alexa.Response {
+		return respondWithError(ctx, i18n.For(request.Body.Locale), "title.error", failure.Wrap(failure.Internal, errPanic))
+	}),
+	middleware.SkillID(cfg.SkillIDs),
+	middleware.Deadline(cfg.ResponseTimeout, cfg.DeadlineMargin),
+)
+
 // IntentDispatcher specifies which intent was fired, then processes it with appropriate handler
-func IntentDispatcher(request alexa.Request) alexa.Response {
+func IntentDispatcher(ctx context.Context, request alexa.Request) alexa.Response {
+	ctx = flags.WithUser(ctx, request.UserID())
+	request = withDeviceLocale(ctx, request)
+	request = withResolvedNames(request)
+	response := dispatchIntent(ctx, request)
+
+	// Alexa only remembers the session attributes returned with each response,
+	// so carry them over while the session stays open if the handler set none
+	if !response.Body.ShouldEndSession && response.SessionAttributes == nil {
+		response.SessionAttributes = copySessionAttributes(request)
+	}
+	// the reprompts asking for a name start over once one was given
+	if !isElicitingSlot(response) && response.SessionAttributes != nil {
+		if store := session.Load(response.SessionAttributes); store.NameAttempts() > 0 {
+			store.SetNameAttempts(0)
+		}
+	}
+	return response
+}
+
+// isElicitingSlot reports whether response asks the user for the value of a slot
+func isElicitingSlot(response alexa.Response) bool {
+	for _, directive := range response.Body.Directives {
+		if directive.Type == "Dialog.ElicitSlot" {
+			return true
+		}
+	}
+	return false
+}
+
+// slotPrompt returns the prompt asking again for slot. A slot may have a
+// pool of prompts of its own, one of which messagesFor picked at random so
+// being asked several times doesn't sound robotic, other slots share the
+// generic prompt naming them
+func slotPrompt(templates messages.Set, slot string) string {
+	if _, ok := templates["slot.prompt."+slot]; ok {
+		return templates.Render("slot.prompt." + slot)
+	}
+	return templates.Render("slot.prompt", "slot", templates.Render("slot."+slot))
+}
+
+// dispatchIntent routes request to the handler of its intent
+func dispatchIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	// playback updates aren't spoken by the user, so they carry no intent,
+	// and neither do requests opening the skill or skill events
+	if request.IsAudioPlayerRequest() {
+		return HandleAudioPlayerRequest(ctx, request)
+	}
+	if request.Body.Type == alexa.LaunchRequest {
+		return HandleLaunchRequest(ctx, request)
+	}
+	if request.Body.Type == alexa.SkillDisabledEvent {
+		return HandleSkillDisabled(ctx, request)
+	}
+	// backend jobs post messages out of session
+	if request.Body.Type == alexa.MessageReceivedRequest {
+		return HandleMessageReceived(ctx, request)
+	}
+	// Alexa Conversations dialogs invoke apis rather than intents
+	if request.IsAPIInvocation() {
+		return HandleAPIInvocation(ctx, request)
+	}
+	// connections hand the dialog back once the other skill is done
+	if request.Body.Type == alexa.SessionResumedRequest {
+		return HandleSessionResumed(ctx, request)
+	}
+
+	// ask again for any slot that is missing or invalid before handling the intent
+	_, err := validation.Validate(request.Body.Intent, intentSlots[request.Body.Intent.Name])
+	if slotErr, ok := err.(*validation.Error); ok {
+		templates := messagesFor(ctx, request)
+		response := alexa.NewElicitSlotResponse(slotErr.Slot.Name, slotPrompt(templates, slotErr.Slot.Name))
+		// the reprompts suggest saying a first name, which doesn't fit surnames
+		if slotErr.Slot.Kind == validation.Name && slotErr.Slot.Name != "last_name" {
+			response = withNameReprompt(request, templates, response)
+		}
+		return response
+	}
+
 	var response alexa.Response
 	switch request.Body.Intent.Name {
 	case alexa.HelpIntent:
-		response = HandleHelpIntent(request)
+		response = HandleHelpIntent(ctx, request)
+	case alexa.StopIntent, alexa.CancelIntent:
+		response = HandleStopIntent(ctx, request)
+	case alexa.RepeatIntent:
+		response = HandleRepeatIntent(ctx, request)
+	case alexa.YesIntent:
+		response = HandleYesIntent(ctx, request)
+	case alexa.NoIntent:
+		response = HandleNoIntent(ctx, request)
+	case alexa.PauseIntent:
+		response = HandlePauseIntent(ctx, request)
+	case alexa.ResumeIntent:
+		response = HandleResumeIntent(ctx, request)
+	case "AnthemIntent":
+		response = HandleAnthemIntent(ctx, request)
+	case "PrintIntent":
+		response = HandlePrintIntent(ctx, request)
+	case "NameMeaningIntent":
+		response = HandleNameMeaningIntent(ctx, request)
+	case "TypicalNamesIntent":
+		response = HandleTypicalNamesIntent(ctx, request)
+	case "WhichContinentIntent":
+		response = HandleWhichContinentIntent(ctx, request)
+	case "DemonymIntent":
+		response = HandleDemonymIntent(ctx, request)
+	case "WhyIntent":
+		response = HandleWhyIntent(ctx, request)
+	case "StartPartyIntent":
+		response = HandleStartPartyIntent(ctx, request)
+	case "EndPartyIntent":
+		response = HandleEndPartyIntent(ctx, request)
 	case "AboutIntent":
-		response = HandleAboutIntent(request)
-	case "GuessIntent":
-		response = HandleGuessIntent(request, false)
-	case "GuessWithAccountIntent":
-		response = HandleGuessIntent(request, true)
+		response = HandleAboutIntent(ctx, request)
+	case "GuessIntent", "GuessWithAccountIntent":
+		response = HandleGuessIntent(ctx, request)
+	case "NameIntent":
+		response = HandleNameIntent(ctx, request)
+	case "GuessGenderIntent":
+		response = HandleGuessGenderIntent(ctx, request)
+	case "GuessAgeIntent":
+		response = HandleGuessAgeIntent(ctx, request)
+	case "SurnameIntent":
+		response = HandleSurnameIntent(ctx, request)
+	case "StartQuizIntent":
+		response = HandleStartQuizIntent(ctx, request)
+	case "AnswerIntent":
+		response = HandleAnswerIntent(ctx, request)
+	case "EndQuizIntent":
+		response = HandleEndQuizIntent(ctx, request)
+	case "LeaderboardIntent":
+		response = HandleLeaderboardIntent(ctx, request)
+	case "ConfidenceStyleIntent":
+		response = HandleConfidenceStyleIntent(ctx, request)
+	case "SetPreferenceIntent":
+		response = HandleSetPreferenceIntent(ctx, request)
+	case "GetPreferencesIntent":
+		response = HandleGetPreferencesIntent(ctx, request)
+	case "RememberFriendIntent":
+		response = HandleRememberFriendIntent(ctx, request)
+	case "ListFriendsIntent":
+		response = HandleListFriendsIntent(ctx, request)
+	case "GuessFriendIntent":
+		response = HandleGuessFriendIntent(ctx, request)
+	case "HistoryIntent":
+		response = HandleHistoryIntent(ctx, request)
+	case "RememberNameIntent":
+		response = HandleRememberNameIntent(ctx, request)
+	case "ForgetNameIntent":
+		response = HandleForgetNameIntent(ctx, request)
+	case "ClearHistoryIntent":
+		response = HandleClearHistoryIntent(ctx, request)
+	case "DeleteMyDataIntent":
+		response = HandleDeleteMyDataIntent(ctx, request)
+	case "SurpriseIntent":
+		response = HandleSurpriseIntent(ctx, request)
+	case "MostInternationalIntent":
+		response = HandleMostInternationalIntent(ctx, request)
+	case "ProfileIntent":
+		response = HandleProfileIntent(ctx, request)
+	case "DebugIntent":
+		response = HandleDebugIntent(ctx, request)
 	default:
-		response = HandleAboutIntent(request)
+		response = HandleAboutIntent(ctx, request)
 	}
 	return response
 }
 
-// entrypoint to the app
+// entrypoint to the app, which runs as an http server instead
+// of a lambda function when an address to listen on is configured
 func main() {
-	lambda.Start(Handler)
+	upstream := newProviders()
+	if cfg.HTTPAddr != "" {
+		if err := serveHTTP(cfg.HTTPAddr, upstream); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	lambda.Start(newHandler(upstream))
 }
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"alexa-skill-test/src/age"
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/gender"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/preferences"
+	"alexa-skill-test/src/upstream"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeNationality is a nationality provider answering from fixed responses
+// by name, or failing with err, and counting the lookups it was asked for
+type fakeNationality struct {
+	responses map[string]nationality.Response
+	err       error
+	lookups   atomic.Int32
+}
+
+func (fake *fakeNationality) Predict(ctx context.Context, name string) ([]nationality.Prediction, error) {
+	response, err := fake.Lookup(ctx, name)
+	return response.Predictions, err
+}
+
+func (fake *fakeNationality) Lookup(ctx context.Context, name string) (nationality.Response, error) {
+	fake.lookups.Add(1)
+	if fake.err != nil {
+		return nationality.Response{}, fake.err
+	}
+	response := fake.responses[name]
+	response.Name = name
+	return response, nil
+}
+
+// hansGuess is what the fake provider of most tests answers for Hans
+var hansGuess = nationality.Response{
+	Count: 5000,
+	Predictions: []nationality.Prediction{
+		{Country_id: "DE", Probability: 0.45},
+		{Country_id: "AT", Probability: 0.2},
+		{Country_id: "CH", Probability: 0.1},
+	},
+}
+
+// testContext returns the context of a request guessing with provider, reading
+// the embedded countries and no name from the account of the user
+func testContext(provider nationality.Provider) context.Context {
+	return withProviders(context.Background(), providers{
+		nationality: provider,
+		countries:   countries.Embedded,
+		identity:    fakeIdentity{err: errNoPermission},
+	})
+}
+
+// intentRequest returns the request Alexa sends for intent with slots, in a new session
+func intentRequest(intent string, slots map[string]string) alexa.Request {
+	var request alexa.Request
+	request.Version = "1.0"
+	request.Session.New = true
+	request.Session.SessionID = "test.session"
+	request.Session.User.UserID = "test.user"
+	request.Context.System.User.UserID = "test.user"
+	request.Body.Type = "IntentRequest"
+	request.Body.Locale = "en-US"
+	request.Body.Intent = alexa.Intent{Name: intent, ConfirmationStatus: alexa.ConfirmationNone, Slots: map[string]alexa.Slot{}}
+	for name, value := range slots {
+		request.Body.Intent.Slots[name] = alexa.Slot{Name: name, Value: value, ConfirmationStatus: alexa.ConfirmationNone}
+	}
+	return request
+}
+
+// spoken returns the ssml or the plain text response speaks
+func spoken(response alexa.Response) string {
+	if response.Body.OutputSpeech == nil {
+		return ""
+	}
+	if response.Body.OutputSpeech.Type == "SSML" {
+		return response.Body.OutputSpeech.SSML
+	}
+	return response.Body.OutputSpeech.Text
+}
+
+// keepConfig restores the configuration once the test, which may change it, is done
+func keepConfig(t *testing.T) {
+	saved := cfg
+	t.Cleanup(func() { cfg = saved })
+}
+
+func TestGuessConfirmsHeardName(t *testing.T) {
+	keepConfig(t)
+	cfg.ConfirmName = true
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})))
+	// the wording of the confirmation varies, the name being said first doesn't
+	heard := strings.Index(speech, "hans.")
+	if heard < 0 {
+		t.Fatalf("speech %q doesn't confirm the name", speech)
+	}
+	if guess := strings.Index(speech, "german"); guess < heard {
+		t.Errorf("speech %q doesn't confirm the name before the guess", speech)
+	}
+}
+
+func TestGuessConfirmationIsEscaped(t *testing.T) {
+	keepConfig(t)
+	cfg.ConfirmName = true
+	provider := &fakeNationality{responses: map[string]nationality.Response{"O'Brien": hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "O'Brien"})))
+	if !strings.Contains(speech, " o&apos;brien.") {
+		t.Errorf("speech %q doesn't confirm the escaped name", speech)
+	}
+	if strings.Contains(speech, "o'brien") {
+		t.Errorf("speech %q carries the name unescaped", speech)
+	}
+}
+
+func TestGuessWithoutConfirmation(t *testing.T) {
+	keepConfig(t)
+	cfg.ConfirmName = false
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})))
+	if strings.Contains(speech, "hans") || !strings.Contains(speech, "german") {
+		t.Errorf("speech %q", speech)
+	}
+}
+
+// serveAPI returns the url of a server that runs handler, with the lookup
+// cache emptied so every test reaches its server
+func serveAPI(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	lookupCache.Clear()
+	t.Cleanup(lookupCache.Clear)
+	return server.URL
+}
+
+func TestFetchJSONAgify(t *testing.T) {
+	var requests atomic.Int32
+	agify := serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if got := r.URL.Query().Get("name"); got != "Hans" {
+			t.Errorf("name sent as %q", got)
+		}
+		w.Write([]byte(`{"name":"Hans","age":61,"count":23456}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		var response age.Response
+		if err := fetchJSON(context.Background(), withQuery(agify, nameQuery("Hans")), &response); err != nil {
+			t.Fatalf("fetchJSON failed: %v", err)
+		}
+		if response.Age != 61 || response.Count != 23456 {
+			t.Errorf("fetchJSON = %+v", response)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("agify was called %d times, want once with the second lookup cached", got)
+	}
+}
+
+func TestFetchJSONGenderize(t *testing.T) {
+	genderize := serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Andrea","gender":"female","probability":0.61,"count":1234}`))
+	})
+
+	var response gender.Response
+	if err := fetchJSON(context.Background(), withQuery(genderize, nameQuery("Andrea")), &response); err != nil {
+		t.Fatalf("fetchJSON failed: %v", err)
+	}
+	if response.Gender != "female" || response.Probability != 0.61 {
+		t.Errorf("fetchJSON = %+v", response)
+	}
+}
+
+func TestFetchJSONStatusErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError} {
+		api := serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+
+		var response age.Response
+		err := fetchJSON(context.Background(), withQuery(api, nameQuery("Hans")), &response)
+		var statusErr *upstream.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+			t.Errorf("fetchJSON error = %v, want a %d StatusError", err, status)
+			continue
+		}
+		if statusErr.Throttled() != (status == http.StatusTooManyRequests) {
+			t.Errorf("status %d: throttled %v", status, statusErr.Throttled())
+		}
+	}
+}
+
+func TestFetchJSONMalformed(t *testing.T) {
+	var requests atomic.Int32
+	api := serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"name":"Hans","age":`))
+	})
+
+	for i := 0; i < 2; i++ {
+		var response age.Response
+		err := fetchJSON(context.Background(), withQuery(api, nameQuery("Hans")), &response)
+		var malformed *upstream.MalformedError
+		if !errors.As(err, &malformed) {
+			t.Errorf("fetchJSON error = %v, want a MalformedError", err)
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("api was called %d times, malformed responses mustn't be cached", got)
+	}
+}
+
+// fakeIdentity is an identity provider answering with a fixed name
+type fakeIdentity struct {
+	name string
+	err  error
+}
+
+func (identity fakeIdentity) GivenName(ctx context.Context, request alexa.Request) (string, error) {
+	return identity.name, identity.err
+}
+
+func TestAccountGivenNameUsesInjectedIdentity(t *testing.T) {
+	ctx := withProviders(context.Background(), providers{identity: fakeIdentity{name: "Hans"}})
+	attributes := map[string]interface{}{}
+	name, err := accountGivenName(ctx, alexa.Request{}, attributes)
+	if err != nil || name != "Hans" {
+		t.Errorf("accountGivenName = %q, %v, want Hans", name, err)
+	}
+	// the name is kept for the rest of the session
+	ctx = withProviders(context.Background(), providers{identity: fakeIdentity{err: errNoPermission}})
+	if name, err := accountGivenName(ctx, alexa.Request{}, attributes); err != nil || name != "Hans" {
+		t.Errorf("accountGivenName = %q, %v, want Hans from the session", name, err)
+	}
+
+	_, err = accountGivenName(ctx, alexa.Request{}, map[string]interface{}{})
+	if !errors.Is(err, errNoPermission) {
+		t.Errorf("accountGivenName error = %v, want errNoPermission", err)
+	}
+}
+
+func TestGuessRefusesBlockedName(t *testing.T) {
+	keepConfig(t)
+	cfg.ConfirmUnusualNames = false
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Bítch": hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "Bítch"})))
+	if !strings.Contains(speech, "rather not guess") {
+		t.Errorf("speech %q doesn't refuse the name", speech)
+	}
+	if got := provider.lookups.Load(); got != 0 {
+		t.Errorf("blocked name was looked up %d times", got)
+	}
+}
+
+func TestGuessAllowsOtherNames(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})))
+	if strings.Contains(speech, "rather not guess") || provider.lookups.Load() != 1 {
+		t.Errorf("allowed name was refused: %q", speech)
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		base string
+		name string
+		want string
+	}{
+		{"https://api.agify.io", "Mary Ann", "https://api.agify.io?name=Mary+Ann"},
+		{"https://api.genderize.io/", "Zoë", "https://api.genderize.io/?name=Zo%C3%AB"},
+		{"https://api.agify.io?country_id=DE", "Tom&Jerry", "https://api.agify.io?country_id=DE&name=Tom%26Jerry"},
+		// names in other scripts are sent in their latin spelling
+		{"https://api.agify.io", "Иван", "https://api.agify.io?name=Ivan"},
+	}
+	for _, test := range tests {
+		if got := withQuery(test.base, nameQuery(test.name)); got != test.want {
+			t.Errorf("withQuery(%s, %q) = %s, want %s", test.base, test.name, got, test.want)
+		}
+	}
+}
+
+func TestGuessWarnsBelowLowCount(t *testing.T) {
+	keepConfig(t)
+	cfg.LowCountThreshold = 100
+	templates := i18n.For("en-US")
+	// a zero count means the provider didn't report it, which isn't a warning
+	for count, want := range map[int]bool{0: false, 1: true, 99: true, 100: false, 101: false, 5000: false} {
+		response := hansGuess
+		response.Count = count
+		speech := buildGuessResponse(templates, preferences.Preferences{}, "", "", countries.Embedded, response)
+		if got := strings.Contains(speech, "uncommon"); got != want {
+			t.Errorf("count %d: warned %v, want %v in %q", count, got, want, speech)
+		}
+	}
+}
+
+func TestGuessLowCountWarningLeftOutOfShortAnswers(t *testing.T) {
+	keepConfig(t)
+	cfg.LowCountThreshold = 100
+	response := hansGuess
+	response.Count = 10
+	speech := buildGuessResponse(i18n.For("en-US"), preferences.Preferences{Length: preferences.Short}, "", "", countries.Embedded, response)
+	if strings.Contains(speech, "uncommon") {
+		t.Errorf("short answer %q warns about the count", speech)
+	}
+}
+
+func TestSelectSpokenPredictions(t *testing.T) {
+	keepConfig(t)
+	cfg.DominanceThreshold = 0.8
+	cfg.MaxGuesses = 3
+	tests := []struct {
+		name        string
+		predictions []nationality.Prediction
+		want        []string
+	}{
+		{"dominant", []nationality.Prediction{{Country_id: "JP", Probability: 0.05}, {Country_id: "DE", Probability: 0.9}}, []string{"DE"}},
+		{"at the threshold", []nationality.Prediction{{Country_id: "DE", Probability: 0.8}, {Country_id: "AT", Probability: 0.1}}, []string{"DE", "AT"}},
+		{"close", []nationality.Prediction{{Country_id: "AT", Probability: 0.3}, {Country_id: "DE", Probability: 0.35}, {Country_id: "CH", Probability: 0.2}, {Country_id: "LI", Probability: 0.1}}, []string{"DE", "AT", "CH"}},
+		{"none", nil, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, v := range selectSpokenPredictions(test.predictions) {
+			got = append(got, v.Country_id)
+		}
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: spoken %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGuessReadsOnlyDominantGuess(t *testing.T) {
+	keepConfig(t)
+	cfg.DominanceThreshold = 0.8
+	templates := i18n.For("en-US")
+
+	dominant := nationality.Response{Predictions: []nationality.Prediction{{Country_id: "JP", Probability: 0.92}, {Country_id: "US", Probability: 0.03}}}
+	speech := buildGuessResponse(templates, preferences.Preferences{}, "", "", countries.Embedded, dominant)
+	if !strings.Contains(speech, "japanese") || strings.Contains(speech, "american") {
+		t.Errorf("dominant guess read as %q", speech)
+	}
+
+	speech = buildGuessResponse(templates, preferences.Preferences{}, "", "", countries.Embedded, hansGuess)
+	for _, demonym := range []string{"german", "austrian", "swiss"} {
+		if !strings.Contains(speech, demonym) {
+			t.Errorf("close guesses read as %q, missing %s", speech, demonym)
+		}
+	}
+}
+
+// wellFormed reports whether ssml parses as an xml document
+func wellFormed(ssml string) bool {
+	decoder := xml.NewDecoder(strings.NewReader(ssml))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			return errors.Is(err, io.EOF)
+		}
+	}
+}
+
+func TestGuessTrimmedToSpeechLimit(t *testing.T) {
+	keepConfig(t)
+	cfg.MaxGuesses = 0
+	cfg.DominanceThreshold = 1
+	// a guess for every country can't be spoken within the limit
+	var batch nationality.Response
+	for i, country := range countries.Embedded {
+		batch.Predictions = append(batch.Predictions, nationality.Prediction{Country_id: country.Code, Probability: 0.5 / float64(i+1)})
+	}
+
+	speech := buildGuessResponse(i18n.For("en-US"), preferences.Preferences{}, "", "", countries.Embedded, batch)
+	if len(speech) > alexa.MaxSpeechLength {
+		t.Errorf("speech is %d characters long, over the limit of %d", len(speech), alexa.MaxSpeechLength)
+	}
+	if !strings.Contains(speech, "skip for brevity") {
+		t.Error("trimmed speech doesn't mention the skipped guesses")
+	}
+	if !wellFormed(speech) {
+		t.Errorf("trimmed speech isn't well formed: %q", speech)
+	}
+	// the most likely guesses are the ones kept
+	top, _ := findCountryInfo(countries.Embedded, countries.Embedded[0].Code)
+	if !strings.Contains(speech, strings.ToLower(top.Demonym)) {
+		t.Errorf("trimmed speech lost the top guess %s", top.Demonym)
+	}
+}
+
+func TestGuessFittingSpeechIsntTrimmed(t *testing.T) {
+	speech := buildGuessResponse(i18n.For("en-US"), preferences.Preferences{}, "", "", countries.Embedded, hansGuess)
+	if strings.Contains(speech, "skip for brevity") || !wellFormed(speech) {
+		t.Errorf("speech %q", speech)
+	}
+}
+
+func TestRequestsAreMeasured(t *testing.T) {
+	var out strings.Builder
+	saved := metricsRecorder.Out
+	metricsRecorder.Out = &out
+	t.Cleanup(func() { metricsRecorder.Out = saved })
+
+	handleRequest(testContext(&fakeNationality{}), intentRequest(alexa.HelpIntent, nil))
+	for _, part := range []string{`"RequestLatency":`, `"RequestRejected":0`, `"Intent":"AMAZON.HelpIntent"`} {
+		if !strings.Contains(out.String(), part) {
+			t.Errorf("metrics %q don't have %s", out.String(), part)
+		}
+	}
+}
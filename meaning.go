@@ -0,0 +1,89 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/meaning"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"context"
+	"net/url"
+)
+
+// nameMeaningIntentModel declares NameMeaningIntent in the interaction model
+var nameMeaningIntentModel = model.Intent{
+	Name:  "NameMeaningIntent",
+	Slots: []model.Slot{{Name: "first_name", Type: "FirstName"}},
+	Samples: []string{
+		"what does {first_name} mean",
+		"what is the meaning of {first_name}",
+		"where does the name {first_name} come from",
+		"what is the origin of {first_name}",
+	},
+}
+
+// HandleNameMeaningIntent explains where a first name comes from and what
+// it means.
+// A user can say:
+// Alexa, ask nationality guesser what does Ethan mean
+func HandleNameMeaningIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	firstName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(firstName) {
+		logging.FromContext(ctx).Info("refusing to explain blocked name", logging.NameKey, firstName)
+		return alexa.NewSimpleResponse(templates.Render("title.meaning"), templates.Render("meaning.blocked"))
+	}
+
+	language := i18n.Language(responseLocale(request))
+	found, ok := lookupMeaning(ctx, templates, firstName, language)
+	var builder alexa.SSMLBuilder
+	builder.Say(buildMeaningResponse(templates, firstName, found, ok))
+	builder.Pause("500")
+	builder.Say(templates.Render("guess.followup"))
+	return alexa.NewSSMLResponse(templates.Render("title.meaning"), builder.Build()).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// lookupMeaning returns the meaning of name in language from the etymology
+// api when one is configured, its responses cached like the other lookups,
+// and from the embedded meanings otherwise or when the api doesn't know it.
+// The embedded meanings are written in english, so other languages only
+// get where the name comes from
+func lookupMeaning(ctx context.Context, templates messages.Set, name string, language string) (meaning.Meaning, bool) {
+	name = names.Romanize(name)
+	if cfg.MeaningURL != "" {
+		var found meaning.Meaning
+		err := fetchJSON(ctx, withQuery(cfg.MeaningURL, url.Values{"name": {name}, "lang": {language}}), &found)
+		if err == nil && found.Origin != "" {
+			return found, true
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("name meaning lookup failed, using the embedded meanings", "error", err)
+		}
+	}
+
+	found, ok := meaning.Embedded(name)
+	if !ok {
+		return found, false
+	}
+	found.Origin = templates.Render("origin." + found.Origin)
+	if language != "en" {
+		found.Meaning = ""
+	}
+	return found, true
+}
+
+// buildMeaningResponse speaks the origin of firstName, along with its meaning when known
+func buildMeaningResponse(templates messages.Set, firstName string, found meaning.Meaning, ok bool) string {
+	switch {
+	case !ok:
+		return templates.Render("meaning.unknown", "name", firstName)
+	case found.Meaning == "":
+		return templates.Render("meaning.origin", "name", firstName, "origin", found.Origin)
+	default:
+		return templates.Render("meaning.result", "name", firstName, "origin", found.Origin, "meaning", found.Meaning)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/alexaapi"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messaging"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// cacheRefreshPayload is the payload of a messaging.CacheRefresh message
+type cacheRefreshPayload struct {
+	// Countries are the alpha-2 codes of the countries fetched again once
+	// the caches are dropped, warmupCountries when empty
+	Countries []string `json:"countries"`
+}
+
+// deletionConfirmedPayload is the payload of a messaging.DeletionConfirmed message
+type deletionConfirmedPayload struct {
+	// Reason is why the data was deleted, written to the audit log
+	Reason string `json:"reason"`
+}
+
+// reminderPayload is the payload of a messaging.ScheduleReminder message
+type reminderPayload struct {
+	ScheduledTime string `json:"scheduledTime"`
+	TimeZoneID    string `json:"timeZoneId"`
+	// Locale is the locale Text is spoken in, the default locale when empty
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// HandleMessageReceived handles the messages backend jobs post to the skill
+// out of session through the Skill Messaging API. Messages not signed with
+// cfg.MessagingKey for the user they are sent to, or older than
+// cfg.MessagingMaxAge, are dropped.
+// Nobody is listening, so nothing is spoken
+func HandleMessageReceived(ctx context.Context, request alexa.Request) alexa.Response {
+	response := alexa.Response{Version: "1.0", Body: alexa.ResBody{ShouldEndSession: true}}
+	logger := logging.FromContext(ctx)
+	if cfg.MessagingKey == "" {
+		logger.Warn("dropping message, MESSAGING_KEY isn't set")
+		return response
+	}
+	message, err := messaging.Verify([]byte(cfg.MessagingKey), request.UserID(), request.Body.Message, cfg.MessagingMaxAge, time.Now())
+	if err != nil {
+		logger.Warn("dropping unauthenticated message", "message", message.Type, "error", err)
+		return response
+	}
+
+	logger = logger.With("message", message.Type)
+	switch message.Type {
+	case messaging.CacheRefresh:
+		err = refreshCaches(ctx, message)
+	case messaging.DeletionConfirmed:
+		var payload deletionConfirmedPayload
+		if err = message.Decode(&payload); err == nil {
+			reason := "confirmed by backend"
+			if payload.Reason != "" {
+				reason += ": " + payload.Reason
+			}
+			err = forgetUser(ctx, request.UserID(), reason)
+		}
+	case messaging.ScheduleReminder:
+		err = scheduleReminder(ctx, request, message, logger)
+	default:
+		logger.Warn("unknown message type")
+		return response
+	}
+	if err != nil {
+		logger.Error("handling message failed", "error", err)
+	}
+	return response
+}
+
+// refreshCaches drops the cached upstream responses and countries, then
+// fetches the countries of the message again so the next guesses find them
+func refreshCaches(ctx context.Context, message messaging.Message) error {
+	var payload cacheRefreshPayload
+	if err := message.Decode(&payload); err != nil {
+		return err
+	}
+	lookupCache.Clear()
+	if api, ok := providersFrom(ctx).countries.(countries.API); ok && api.Cache != nil {
+		api.Cache.Clear()
+	}
+
+	codes := payload.Countries
+	if len(codes) == 0 {
+		codes = warmupCountries
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	lookupCountries(ctx, codes)
+	logging.FromContext(ctx).Info("caches refreshed", "countries", len(codes))
+	return nil
+}
+
+// scheduleReminder sets the reminder of the message through the Reminders
+// API, with the access token Alexa gave the message
+func scheduleReminder(ctx context.Context, request alexa.Request, message messaging.Message, logger *slog.Logger) error {
+	var payload reminderPayload
+	if err := message.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.ScheduledTime == "" || payload.Text == "" {
+		return errors.New("reminder without scheduledTime or text")
+	}
+	if !i18n.Supported(payload.Locale) {
+		payload.Locale = i18n.DefaultLocale
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	id, err := alexaapi.NewClient(request).CreateReminder(ctx, alexaapi.Reminder{
+		ScheduledTime: payload.ScheduledTime,
+		TimeZoneID:    payload.TimeZoneID,
+		Locale:        payload.Locale,
+		Text:          payload.Text,
+	})
+	if errors.Is(err, alexaapi.ErrForbidden) {
+		logger.Info("reminder not scheduled, the user didn't grant the reminders permission")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("reminder scheduled", "reminder", id)
+	return nil
+}
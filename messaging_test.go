@@ -0,0 +1,145 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/messaging"
+	"alexa-skill-test/src/preferences"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// messagingKey is the key the test messages are signed with
+const messagingKey = "messaging key"
+
+// forgettingPreferences records the users whose preferences were forgotten
+type forgettingPreferences struct {
+	preferences.Store
+	forgotten []string
+}
+
+func (store *forgettingPreferences) Forget(ctx context.Context, userID string) error {
+	store.forgotten = append(store.forgotten, userID)
+	return nil
+}
+
+// keepPreferences replaces the preferences store with one recording the
+// users forgotten until the test is done
+func keepPreferences(t *testing.T) *forgettingPreferences {
+	saved := preferencesStore
+	store := &forgettingPreferences{}
+	preferencesStore = store
+	t.Cleanup(func() { preferencesStore = saved })
+	return store
+}
+
+// messageRequest returns the request Alexa sends the user userID for message
+func messageRequest(t *testing.T, userID string, message messaging.Message) alexa.Request {
+	t.Helper()
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request alexa.Request
+	request.Version = "1.0"
+	request.Context.System.User.UserID = userID
+	request.Body.Type = alexa.MessageReceivedRequest
+	request.Body.Locale = "en-US"
+	if err := json.Unmarshal(encoded, &request.Body.Message); err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+// signedFor returns the message of type kind carrying payload, signed now for userID
+func signedFor(userID string, kind string, payload string) messaging.Message {
+	return messaging.Sign([]byte(messagingKey), userID, kind, payload, time.Now())
+}
+
+func TestMessageCacheRefreshDropsCachedLookups(t *testing.T) {
+	keepConfig(t)
+	cfg.MessagingKey = messagingKey
+	lookupCache.Put("https://api.nationalize.io?name=Hans", []byte(`{}`))
+	t.Cleanup(lookupCache.Clear)
+
+	HandleMessageReceived(testContext(&fakeNationality{}), messageRequest(t, "test.user", signedFor("test.user", messaging.CacheRefresh, `{"countries":["DE"]}`)))
+	if _, ok := lookupCache.Get("https://api.nationalize.io?name=Hans"); ok {
+		t.Error("cached lookup kept after a cache refresh")
+	}
+}
+
+func TestMessageDeletionConfirmedForgetsTheUser(t *testing.T) {
+	keepConfig(t)
+	cfg.MessagingKey = messagingKey
+	store := keepPreferences(t)
+
+	HandleMessageReceived(testContext(&fakeNationality{}), messageRequest(t, "test.user", signedFor("test.user", messaging.DeletionConfirmed, `{"reason":"deleted on the website"}`)))
+	if len(store.forgotten) != 1 || store.forgotten[0] != "test.user" {
+		t.Errorf("forgotten %v, want test.user", store.forgotten)
+	}
+}
+
+func TestMessageScheduleReminderCreatesIt(t *testing.T) {
+	keepConfig(t)
+	cfg.MessagingKey = messagingKey
+	var path, authorization, body string
+	endpoint := serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, authorization, body = r.URL.Path, r.Header.Get("Authorization"), string(data)
+		w.Write([]byte(`{"alertToken":"reminder.token"}`))
+	})
+
+	request := messageRequest(t, "test.user", signedFor("test.user", messaging.ScheduleReminder,
+		`{"scheduledTime":"2024-05-02T09:00:00.000","timeZoneId":"Europe/Berlin","text":"guess a new name today"}`))
+	request.Context.System.APIEndpoint = endpoint
+	request.Context.System.APIAccessToken = "api.token"
+	HandleMessageReceived(testContext(&fakeNationality{}), request)
+
+	if path != "/v1/alerts/reminders" || authorization != "Bearer api.token" {
+		t.Fatalf("reminder posted to %q with %q, want /v1/alerts/reminders with the api token", path, authorization)
+	}
+	for _, part := range []string{`"scheduledTime":"2024-05-02T09:00:00.000"`, `"text":"guess a new name today"`, `"locale":"en-US"`} {
+		if !strings.Contains(body, part) {
+			t.Errorf("reminder %s doesn't have %s", body, part)
+		}
+	}
+}
+
+func TestUnauthenticatedMessagesAreDropped(t *testing.T) {
+	tampered := signedFor("test.user", messaging.DeletionConfirmed, "")
+	tampered.Payload = `{"reason":"forged"}`
+
+	tests := map[string]struct {
+		key     string
+		message messaging.Message
+	}{
+		"without a key":    {message: signedFor("test.user", messaging.DeletionConfirmed, "")},
+		"unsigned":         {key: messagingKey, message: messaging.Message{Type: messaging.DeletionConfirmed, IssuedAt: time.Now().Unix()}},
+		"signed otherwise": {key: "other key", message: signedFor("test.user", messaging.DeletionConfirmed, "")},
+		"tampered":         {key: messagingKey, message: tampered},
+		"expired": {
+			key:     messagingKey,
+			message: messaging.Sign([]byte(messagingKey), "test.user", messaging.DeletionConfirmed, "", time.Now().Add(-time.Hour)),
+		},
+		"for another user": {key: messagingKey, message: signedFor("other.user", messaging.DeletionConfirmed, "")},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keepConfig(t)
+			cfg.MessagingKey = test.key
+			store := keepPreferences(t)
+
+			response := HandleMessageReceived(testContext(&fakeNationality{}), messageRequest(t, "test.user", test.message))
+			if len(store.forgotten) > 0 {
+				t.Errorf("forgot %v on an unauthenticated message", store.forgotten)
+			}
+			if !response.Body.ShouldEndSession || response.Body.OutputSpeech != nil {
+				t.Errorf("response %+v, want a silent end of session", response.Body)
+			}
+		})
+	}
+}
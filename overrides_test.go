@@ -0,0 +1,63 @@
+package main
+
+import (
+	"alexa-skill-test/src/countries"
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger to a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buffer bytes.Buffer
+	saved := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buffer, nil)))
+	t.Cleanup(func() { slog.SetDefault(saved) })
+	return &buffer
+}
+
+func TestMalformedDemonymOverridesAreIgnored(t *testing.T) {
+	logs := captureLogs(t)
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(`{"GB": "British",`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if overrides := loadDemonymOverrides(path); len(overrides) != 0 {
+		t.Errorf("loadDemonymOverrides = %v, want none from a malformed file", overrides)
+	}
+	if !strings.Contains(logs.String(), "ignoring demonym overrides") {
+		t.Errorf("malformed file wasn't logged: %q", logs.String())
+	}
+}
+
+func TestMissingDemonymOverridesAreIgnored(t *testing.T) {
+	captureLogs(t)
+	if overrides := loadDemonymOverrides(filepath.Join(t.TempDir(), "missing.json")); len(overrides) != 0 {
+		t.Errorf("loadDemonymOverrides = %v, want none from a missing file", overrides)
+	}
+	if overrides := loadDemonymOverrides(""); len(overrides) != 0 {
+		t.Errorf("loadDemonymOverrides = %v, want none without a file", overrides)
+	}
+}
+
+func TestDemonymOverridesTakePrecedence(t *testing.T) {
+	saved := demonymOverrides
+	t.Cleanup(func() { demonymOverrides = saved })
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(`{"gb": "UK"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	demonymOverrides = loadDemonymOverrides(path)
+
+	fetched := countries.Country{{Code: "GB", Demonym: "British"}, {Code: "FR", Demonym: "French"}}
+	if got := findCountryOfCode(fetched, "GB"); got != "UK" {
+		t.Errorf("demonym of GB = %q, want the override", got)
+	}
+	if got := findCountryOfCode(fetched, "FR"); got != "French" {
+		t.Errorf("demonym of FR = %q, want the fetched one", got)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/party"
+	"alexa-skill-test/src/session"
+	"context"
+	"strconv"
+)
+
+// partyIntentModels declare the intents of the party mode in the interaction model
+var partyIntentModels = []model.Intent{
+	{
+		Name:    "StartPartyIntent",
+		Samples: []string{"start party mode", "pass the mic", "let's play party mode", "guess everyone in the room"},
+	},
+	{
+		Name:    "EndPartyIntent",
+		Samples: []string{"that's everyone", "we're all done", "end the round", "end party mode"},
+	},
+}
+
+// HandleStartPartyIntent starts a party round, where the players in the
+// room pass the mic and say their names in turn, until one of them says
+// that's everyone and the round is summed up.
+// A user can say:
+// Alexa, ask nationality guesser to pass the mic
+func HandleStartPartyIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	store := session.Load(attributes)
+	store.ClearQuiz()
+	store.SetParty(party.State{})
+	setDialogState(attributes, dialogParty, "")
+	return alexa.NewSimpleResponse(templates.Render("title.party"), templates.Render("party.start")).
+		WithReprompt(templates.Render("party.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// HandleEndPartyIntent ends the party round in progress with its summary.
+// A user can say:
+// That's everyone
+func HandleEndPartyIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	round, ok := session.Load(attributes).Party()
+	if !ok {
+		return alexa.NewSimpleResponse(templates.Render("title.party"), templates.Render("party.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+	return endParty(request, templates, attributes, round, "")
+}
+
+// addPartyPlayer guesses firstName as the name of the player holding the mic,
+// then passes the mic to the next player, or sums the round up once it is full.
+// The guess is kept short since everyone is waiting for their turn
+func addPartyPlayer(ctx context.Context, request alexa.Request, templates messages.Set, firstName string) alexa.Response {
+	attributes := copySessionAttributes(request)
+	round, _ := session.Load(attributes).Party()
+
+	predictionsResponse, fetched, err := fetchGuesses(ctx, firstName)
+	if err != nil {
+		logging.FromContext(ctx).Error("party nationality guess failed", "error", err)
+		return respondWithError(ctx, templates, "title.party", err)
+	}
+	predictions, _ := adjustPredictions(ctx, request, predictionsResponse.Predictions)
+	saveGuess(ctx, request, firstName, predictions)
+
+	var turn string
+	if len(predictions) == 0 {
+		turn = templates.Render("party.player_none", "name", firstName)
+	} else {
+		player := party.Player{Name: firstName, Countries: map[string]float64{}}
+		for _, v := range nationality.Normalize(predictions) {
+			player.Countries[v.Country_id] = v.Probability
+		}
+		top := sortPredictions(predictions)[0]
+		player.Country = top.Country_id
+		round.Players = append(round.Players, player)
+		turn = templates.Render("party.player",
+			"name", firstName,
+			"demonym", findCountryOfCode(fetched, top.Country_id),
+			"percent", strconv.Itoa(int(top.Probability*100)))
+	}
+
+	if round.Full() {
+		return endParty(request, templates, attributes, round, turn+" "+templates.Render("party.full"))
+	}
+	session.Load(attributes).SetParty(round)
+	setDialogState(attributes, dialogParty, "")
+	return alexa.NewSimpleResponse(templates.Render("title.party"), turn+" "+templates.Render("party.next")).
+		WithReprompt(templates.Render("party.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// endParty sums round up after intro and forgets it, keeping the session
+// open for more guesses
+func endParty(request alexa.Request, templates messages.Set, attributes map[string]interface{}, round party.State, intro string) alexa.Response {
+	session.Load(attributes).ClearParty()
+	setDialogState(attributes, dialogIdle, "")
+	speech := buildPartySummary(templates, formatsFor(request), round) + " " + templates.Render("guess.followup")
+	if intro != "" {
+		speech = intro + " " + speech
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.party"), speech).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// buildPartySummary names the players of round along with their most likely
+// nationality, then tells which regions of the world the room comes from
+func buildPartySummary(templates messages.Set, formats format.Formatter, round party.State) string {
+	if len(round.Players) == 0 {
+		return templates.Render("party.empty")
+	}
+	var players []string
+	for _, player := range round.Players {
+		players = append(players, templates.Render("party.player_item", "name", player.Name, "demonym", findCountryOfCode(nil, player.Country)))
+	}
+	summary := templates.Render("party.players", "count", formats.Number(len(round.Players)), "players", formats.List(players))
+
+	shares := round.Summarize(func(code string) string {
+		country, _ := findCountryInfo(nil, code)
+		return country.SubregionKey()
+	})
+	if len(shares) == 0 {
+		return summary
+	}
+	summary += " " + templates.Render("party.summary",
+		"region", templates.Render("subregion."+shares[0].Group),
+		"percent", strconv.Itoa(int(shares[0].Probability*100)))
+	if len(shares) > 1 {
+		summary += " " + templates.Render("party.runner_up",
+			"region", templates.Render("subregion."+shares[1].Group),
+			"percent", strconv.Itoa(int(shares[1].Probability*100)))
+	}
+	return summary
+}
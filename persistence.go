@@ -0,0 +1,34 @@
+package main
+
+import (
+	"alexa-skill-test/src/storage"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// persistentAttributes keeps the history, preferences and friends of every
+// user together, nil when they are kept in their own tables
+var persistentAttributes = newPersistentAttributes(cfg.PersistenceBackend)
+
+// newPersistentAttributes returns the persistent attributes kept by backend,
+// "dynamodb" or "s3", or nil when backend is "tables" or isn't configured
+func newPersistentAttributes(backend string) storage.PersistentAttributes {
+	if backend == "tables" {
+		return nil
+	}
+	if (backend != "dynamodb" || cfg.PersistenceTable == "") && (backend != "s3" || cfg.PersistenceBucket == "") {
+		slog.Warn("unusable persistence backend, using the tables of each feature", "backend", backend)
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("persistent attributes disabled, aws configuration failed", "error", err)
+		return nil
+	}
+	if backend == "s3" {
+		return storage.S3{Client: s3.NewFromConfig(config), Bucket: cfg.PersistenceBucket, Prefix: cfg.PersistencePrefix}
+	}
+	return storage.Dynamo{Client: dynamodb.NewFromConfig(config), Table: cfg.PersistenceTable}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/preferences"
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// preferencesStore keeps the preferences of every user across sessions, nil when neither
+// a table nor the persistent attributes are configured
+var preferencesStore = newPreferencesStore(cfg.PreferencesTable)
+
+// newPreferencesStore returns a store keeping the preferences in the persistent
+// attributes when they are configured, and in the DynamoDB table otherwise
+func newPreferencesStore(table string) preferences.Store {
+	if persistentAttributes != nil {
+		return preferences.Persistent{Attributes: persistentAttributes}
+	}
+	if table == "" {
+		return nil
+	}
+	config, err := loadAWSConfig()
+	if err != nil {
+		slog.Warn("preferences only last a session, aws configuration failed", "error", err)
+		return nil
+	}
+	return preferences.Dynamo{Client: dynamodb.NewFromConfig(config), Table: table}
+}
+
+// userPreferences returns the preferences of the speaker of request. They are read
+// from the store once per session and speaker and cached in attributes for the following turns
+func userPreferences(ctx context.Context, request alexa.Request, attributes map[string]interface{}) preferences.Preferences {
+	speaker := speakerKey(request)
+	if cached, ok := preferences.Cached(attributes, speaker); ok {
+		return cached
+	}
+	var loaded preferences.Preferences
+	if preferencesStore != nil {
+		ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+		defer cancel()
+		var err error
+		if loaded, err = preferencesStore.Load(ctx, speaker); err != nil {
+			logging.FromContext(ctx).Error("reading preferences failed", "error", err)
+		}
+	}
+	loaded.Cache(attributes, speaker)
+	return loaded
+}
+
+// confidencePhrasing returns how likely guesses are spoken to a user having prefs
+func confidencePhrasing(prefs preferences.Preferences) string {
+	if prefs.Confidence != "" {
+		return prefs.Confidence
+	}
+	return cfg.ConfidencePhrasing
+}
+
+// confidenceBand returns the id of the message speaking a guess
+// of the given probability in words, following cfg.ConfidenceBands
+func confidenceBand(probability float64) string {
+	for i, band := range []string{"confidence.very_likely", "confidence.likely", "confidence.possibly"} {
+		if i < len(cfg.ConfidenceBands) && probability >= cfg.ConfidenceBands[i] {
+			return band
+		}
+	}
+	return "confidence.long_shot"
+}
+
+// confidenceStyleIntentModel declares ConfidenceStyleIntent in the interaction model
+var confidenceStyleIntentModel = model.Intent{
+	Name:    "ConfidenceStyleIntent",
+	Slots:   []model.Slot{{Name: "style", Type: "ConfidenceStyle"}},
+	Samples: []string{"use {style} instead of percentages", "say how likely guesses are in {style}"},
+}
+
+// confidenceStyleSlotType declares the ways of speaking how likely a guess is
+var confidenceStyleSlotType = model.SlotType{
+	Name: "ConfidenceStyle",
+	Values: []model.Value{
+		{ID: "percent", Name: "percentages", Synonyms: []string{"percent", "numbers"}},
+		{ID: "words", Name: "words", Synonyms: []string{"plain words"}},
+	},
+}
+
+// HandleConfidenceStyleIntent changes whether the speaker hears how likely
+// guesses are as percentages or in words, which suits children better.
+// A user can say:
+// Alexa, ask nationality guesser to use words instead of percentages
+func HandleConfidenceStyleIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	respond := func(speech string) alexa.Response {
+		return alexa.NewSimpleResponse(templates.Render("title.preferences"), speech).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	}
+
+	slot := request.Body.Intent.Slots["style"]
+	style := slot.ResolvedID()
+	if style == "" {
+		style = strings.ToLower(slot.Value)
+	}
+	if style != preferences.Percent && style != preferences.Words {
+		return respond(templates.Render("preferences.unknown"))
+	}
+
+	prefs := userPreferences(ctx, request, attributes)
+	prefs.Confidence = style
+	savePreferences(ctx, request, attributes, prefs)
+	return respond(templates.Render("preferences." + style))
+}
+
+// setPreferenceIntentModel declares SetPreferenceIntent in the interaction model
+var setPreferenceIntentModel = model.Intent{
+	Name:    "SetPreferenceIntent",
+	Slots:   []model.Slot{{Name: "preference", Type: "Preference"}},
+	Samples: []string{"give me {preference}", "use {preference}", "only tell me the {preference}", "switch to {preference}"},
+}
+
+// preferenceSlotType declares the choices of every setting, identified as preferences.Preferences.Set takes them
+var preferenceSlotType = model.SlotType{
+	Name: "Preference",
+	Values: []model.Value{
+		{ID: "short", Name: "short answers", Synonyms: []string{"brief answers", "shorter answers"}},
+		{ID: "detailed", Name: "detailed answers", Synonyms: []string{"long answers", "full answers"}},
+		{ID: "top", Name: "top country", Synonyms: []string{"most likely country", "top guess"}},
+		{ID: "all", Name: "all countries", Synonyms: []string{"every country", "all guesses"}},
+		{ID: "percent", Name: "percentages", Synonyms: []string{"percent"}},
+		{ID: "words", Name: "words"},
+	},
+}
+
+// HandleSetPreferenceIntent changes one of the settings of the speaker,
+// which the responses to their guesses follow from then on.
+// A user can say:
+// Alexa, ask nationality guesser to give me short answers
+// Alexa, ask nationality guesser to only tell me the top country
+// Alexa, ask nationality guesser to use percentages
+func HandleSetPreferenceIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	respond := func(speech string) alexa.Response {
+		return alexa.NewSimpleResponse(templates.Render("title.preferences"), speech).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	}
+
+	slot := request.Body.Intent.Slots["preference"]
+	value := slot.ResolvedID()
+	if value == "" {
+		value = strings.ToLower(slot.Value)
+	}
+	prefs := userPreferences(ctx, request, attributes)
+	if !prefs.Set(value) {
+		return respond(templates.Render("preferences.unknown_setting"))
+	}
+	savePreferences(ctx, request, attributes, prefs)
+	return respond(templates.Render("preferences." + value))
+}
+
+// getPreferencesIntentModel declares GetPreferencesIntent in the interaction model
+var getPreferencesIntentModel = model.Intent{
+	Name:    "GetPreferencesIntent",
+	Samples: []string{"what my settings are", "what are my settings", "read my settings"},
+}
+
+// HandleGetPreferencesIntent reads back every setting of the speaker,
+// including the defaults of those they never changed.
+// A user can say:
+// Alexa, ask nationality guesser what my settings are
+func HandleGetPreferencesIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	prefs := userPreferences(ctx, request, attributes)
+
+	length, guesses := prefs.Length, prefs.Guesses
+	if length == "" {
+		length = preferences.Detailed
+	}
+	if guesses == "" {
+		guesses = preferences.AllGuesses
+	}
+	speech := []string{templates.Render("preferences.current")}
+	for _, value := range []string{confidencePhrasing(prefs), length, guesses} {
+		speech = append(speech, templates.Render("preferences.current_"+value))
+	}
+	if prefs.RememberName {
+		speech = append(speech, templates.Render("preferences.current_name"))
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.preferences"), strings.Join(speech, " ")).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// savePreferences replaces the preferences of the speaker of request with prefs,
+// in the store when there is one and in the session attributes either way
+func savePreferences(ctx context.Context, request alexa.Request, attributes map[string]interface{}, prefs preferences.Preferences) {
+	prefs.Cache(attributes, speakerKey(request))
+	if preferencesStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	if err := preferencesStore.Save(ctx, speakerKey(request), prefs); err != nil {
+		logging.FromContext(ctx).Error("saving preferences failed", "error", err)
+	}
+}
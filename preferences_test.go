@@ -0,0 +1,58 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// speakerRequest returns the request of the household member personID for intent
+// with slots, continuing the session whose latest response was previous when it isn't nil
+func speakerRequest(t *testing.T, personID string, intent string, slots map[string]string, previous *alexa.Response) alexa.Request {
+	request := intentRequest(intent, slots)
+	request.Context.System.Person = &alexa.Person{PersonID: personID}
+	if previous != nil {
+		// the attributes come back decoded from json, as Alexa sends them
+		data, err := json.Marshal(previous.SessionAttributes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &request.Session.Attributes); err != nil {
+			t.Fatal(err)
+		}
+		request.Session.New = false
+	}
+	return request
+}
+
+func TestPreferencesAreKeptPerSpeaker(t *testing.T) {
+	ctx := context.Background()
+	set := HandleSetPreferenceIntent(ctx, speakerRequest(t, "amzn1.ask.person.a", "SetPreferenceIntent", map[string]string{"preference": "short"}, nil))
+
+	other := HandleGetPreferencesIntent(ctx, speakerRequest(t, "amzn1.ask.person.b", "GetPreferencesIntent", nil, &set))
+	if speech := spoken(other); strings.Contains(speech, "short") || !strings.Contains(speech, "everything I know") {
+		t.Errorf("second speaker heard %q, want the default settings", speech)
+	}
+
+	same := HandleGetPreferencesIntent(ctx, speakerRequest(t, "amzn1.ask.person.a", "GetPreferencesIntent", nil, &other))
+	if speech := spoken(same); !strings.Contains(speech, "short") {
+		t.Errorf("first speaker heard %q after the second one spoke, want their short answers", speech)
+	}
+}
+
+func TestSavingPreferencesKeepsOtherSpeakers(t *testing.T) {
+	ctx := context.Background()
+	first := HandleSetPreferenceIntent(ctx, speakerRequest(t, "amzn1.ask.person.a", "SetPreferenceIntent", map[string]string{"preference": "short"}, nil))
+	second := HandleSetPreferenceIntent(ctx, speakerRequest(t, "amzn1.ask.person.b", "SetPreferenceIntent", map[string]string{"preference": "words"}, &first))
+
+	check := func(personID string, want string, unwanted string) {
+		speech := spoken(HandleGetPreferencesIntent(ctx, speakerRequest(t, personID, "GetPreferencesIntent", nil, &second)))
+		if !strings.Contains(speech, want) || strings.Contains(speech, unwanted) {
+			t.Errorf("%s heard %q, want %q without %q", personID, speech, want, unwanted)
+		}
+	}
+	check("amzn1.ask.person.a", "short", "in words")
+	check("amzn1.ask.person.b", "in words", "short")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/i18n"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/printout"
+	"alexa-skill-test/src/session"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// printToken is the token of the connections printing a result, telling
+// their SessionResumedRequest apart from those of other connections
+const printToken = "print"
+
+// printIntentModel declares PrintIntent in the interaction model
+var printIntentModel = model.Intent{
+	Name:    "PrintIntent",
+	Samples: []string{"print my result", "print that", "print the result", "print it"},
+}
+
+// HandlePrintIntent hands the last guess to the printer skill the user
+// connected, which prints the page served by handlePrintHTTP.
+// A user can say:
+// Alexa, print my result
+func HandlePrintIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	last, _ := session.Load(request.Session.Attributes).LastGuess()
+	if last.Name == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.print"), templates.Render("print.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+	if cfg.PrintURL == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.print"), templates.Render("print.unavailable")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+
+	// the printer skill takes over the dialog, which resumes in HandleSessionResumed
+	page := withQuery(cfg.PrintURL, url.Values{"name": {last.Name}, "locale": {responseLocale(request)}})
+	title := templates.Render("title.guess_card", "name", last.Name)
+	return alexa.NewSimpleResponse(templates.Render("title.print"), templates.Render("print.sending", "name", last.Name)).
+		WithDirectives(alexa.NewPrintWebPageDirective(title, templates.Render("print.description", "name", last.Name), page, printToken)).
+		WithSessionAttributes(copySessionAttributes(request))
+}
+
+// HandleSessionResumed answers the session resuming once a connection
+// completed, telling the user whether their result was printed
+func HandleSessionResumed(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	cause := request.Body.Cause
+	if cause == nil || cause.Token != printToken {
+		return buildConfusedResponse(templates)
+	}
+	message := "print.done"
+	if !cause.Succeeded() {
+		logging.FromContext(ctx).Warn("printing failed", "code", cause.Status.Code, "message", cause.Status.Message)
+		message = "print.failed"
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.print"), templates.Render(message)+" "+templates.Render("guess.followup")).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// handlePrintHTTP serves the page printer skills print for
+// /print?name=Ethan&locale=en-US, listing every guess with its flag
+func handlePrintHTTP(w http.ResponseWriter, r *http.Request) {
+	name := names.Sanitize(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+	if blocklist.Contains(name) {
+		http.Error(w, "name not allowed", http.StatusUnprocessableEntity)
+		return
+	}
+	locale := r.URL.Query().Get("locale")
+	if !i18n.Supported(locale) {
+		locale = i18n.DefaultLocale
+	}
+
+	predictionsResponse, fetched, err := fetchGuesses(r.Context(), name)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("nationality guess failed", "error", err)
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		return
+	}
+
+	templates := i18n.For(locale)
+	formats := format.For(locale)
+	sheet := printout.Sheet{
+		Lang:  locale,
+		Title: templates.Render("title.guess_card", "name", name),
+		Empty: templates.Render("guess.card_none"),
+	}
+	for _, v := range sortPredictions(predictionsResponse.Predictions) {
+		sheet.Countries = append(sheet.Countries, printout.Country{
+			Name:    findCountryName(templates, fetched, v.Country_id),
+			Percent: formats.Percent(v.Probability),
+			FlagURL: countries.FlagURL(v.Country_id, 320),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := printout.Render(w, sheet); err != nil {
+		logging.FromContext(r.Context()).Error("rendering printout failed", "error", err)
+	}
+}
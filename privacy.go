@@ -0,0 +1,89 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/pii"
+	"context"
+)
+
+// forgetter is a store able to delete everything it holds about an account
+type forgetter interface {
+	Forget(ctx context.Context, userID string) error
+}
+
+// forgetUser deletes everything stored about the account userID from every
+// store, for every person of its household. A failing store doesn't stop the
+// others from being wiped, the last failure is returned once they all ran.
+// Every deletion is written to the audit log, which only holds a hash of the account
+func forgetUser(ctx context.Context, userID string, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+
+	stores := map[string]forgetter{}
+	if historyStore != nil {
+		stores["history"] = historyStore
+	}
+	if preferencesStore != nil {
+		stores["preferences"] = preferencesStore
+	}
+	if friendsStore != nil {
+		stores["friends"] = friendsStore
+	}
+	if leaderboardStore != nil {
+		stores["leaderboard"] = leaderboardStore
+	}
+
+	audit := logging.FromContext(ctx).With("audit", true, "user", pii.Hash.Redact(userID), "reason", reason)
+	var lastErr error
+	for name, store := range stores {
+		if err := store.Forget(ctx, userID); err != nil {
+			audit.Error("deleting user data failed", "store", name, "error", err)
+			lastErr = err
+		}
+	}
+	if lastErr == nil {
+		audit.Info("user data deleted")
+	}
+	return lastErr
+}
+
+// deleteMyDataIntentModel declares DeleteMyDataIntent in the interaction model
+var deleteMyDataIntentModel = model.Intent{
+	Name:    "DeleteMyDataIntent",
+	Samples: []string{"delete my data", "erase my data", "delete everything you know about me"},
+}
+
+// HandleDeleteMyDataIntent asks the user to confirm they want every guess,
+// friend, preference and quiz score of their household deleted.
+// A user can say:
+// Alexa, ask nationality guesser to delete my data
+func HandleDeleteMyDataIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	setDialogState(attributes, dialogConfirmDeletion, "")
+	return alexa.NewSimpleResponse(templates.Render("title.privacy"), templates.Render("privacy.confirm")).
+		WithReprompt(templates.Render("privacy.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// confirmDeletion deletes the data of the user once they said yes to HandleDeleteMyDataIntent.
+// The session attributes are dropped as well, since they cache some of the data
+func confirmDeletion(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	if err := forgetUser(ctx, request.UserID(), "requested by voice"); err != nil {
+		return respondWithError(ctx, templates, "title.privacy", err)
+	}
+	return alexa.NewSimpleResponse(templates.Render("title.privacy"), templates.Render("privacy.deleted"))
+}
+
+// HandleSkillDisabled deletes the data of a user who disabled the skill.
+// Nothing is spoken in response to the event
+func HandleSkillDisabled(ctx context.Context, request alexa.Request) alexa.Response {
+	if err := forgetUser(ctx, request.UserID(), "skill disabled"); err != nil {
+		logging.FromContext(ctx).Error("deleting data of a disabled skill failed", "error", err)
+	}
+	return alexa.Response{Version: "1.0", Body: alexa.ResBody{ShouldEndSession: true}}
+}
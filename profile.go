@@ -0,0 +1,113 @@
+package main
+
+import (
+	"alexa-skill-test/src/age"
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/gender"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"sync"
+)
+
+// profile gathers the nationality, age and gender guesses for a name.
+// A nil field means the guess for that dimension failed
+type profile struct {
+	nationality *nationality.Response
+	age         *age.Response
+	gender      *gender.Response
+}
+
+// profileIntentModel declares ProfileIntent in the interaction model
+var profileIntentModel = model.Intent{
+	Name:    "ProfileIntent",
+	Slots:   []model.Slot{{Name: "first_name", Type: "FirstName"}},
+	Samples: []string{"for the full profile of {first_name}", "tell me everything about {first_name}"},
+}
+
+// HandleProfileIntent guesses the gender, age and nationality of a name
+// all at once and speaks them in a single sentence.
+// A user can say:
+// Alexa, ask nationality guesser for the full profile of Ethan
+func HandleProfileIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	firstName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(firstName) {
+		logging.FromContext(ctx).Info("refusing to profile blocked name", logging.NameKey, firstName)
+		return alexa.NewSimpleResponse(templates.Render("title.profile"), templates.Render("profile.blocked"))
+	}
+
+	logging.FromContext(ctx).Info("building profile", logging.NameKey, firstName)
+	p := fetchProfile(ctx, firstName)
+	return alexa.NewSSMLResponse(templates.Render("title.profile"), buildProfileResponse(ctx, templates, formatsFor(request), firstName, p))
+}
+
+// fetchProfile queries nationalize, agify and genderize concurrently.
+// Each query that fails is logged and left out of the profile
+func fetchProfile(ctx context.Context, firstName string) profile {
+	var p profile
+	var wg sync.WaitGroup
+	query := nameQuery(firstName)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		response, err := lookupNationality(ctx, firstName)
+		if err != nil {
+			logging.FromContext(ctx).Warn("profile nationality guess failed", "error", err)
+			return
+		}
+		p.nationality = &response
+	}()
+	go func() {
+		defer wg.Done()
+		var response age.Response
+		if err := fetchJSON(ctx, withQuery(cfg.AgifyURL, query), &response); err != nil {
+			logging.FromContext(ctx).Warn("profile age guess failed", "error", err)
+			return
+		}
+		p.age = &response
+	}()
+	go func() {
+		defer wg.Done()
+		var response gender.Response
+		if err := fetchJSON(ctx, withQuery(cfg.GenderizeURL, query), &response); err != nil {
+			logging.FromContext(ctx).Warn("profile gender guess failed", "error", err)
+			return
+		}
+		p.gender = &response
+	}()
+	wg.Wait()
+	return p
+}
+
+// buildProfileResponse combines the guesses of a profile into one sentence,
+// e.g. "Ethan sounds male, is probably around 34 and is most likely American."
+// Dimensions that failed or have no guess are omitted
+func buildProfileResponse(ctx context.Context, templates messages.Set, formats format.Formatter, firstName string, p profile) string {
+	var builder alexa.SSMLBuilder
+
+	var parts []string
+	if p.gender != nil && p.gender.Gender != "" {
+		parts = append(parts, templates.Render("profile.gender", "gender", templates.Render("gender."+p.gender.Gender)))
+	}
+	if p.age != nil && p.age.Age > 0 {
+		parts = append(parts, templates.Render("profile.age", "age", formats.Number(p.age.Age)))
+	}
+	if p.nationality != nil && len(p.nationality.Predictions) > 0 {
+		top := selectSpokenPredictions(p.nationality.Predictions)[0]
+		demonym := findCountryOfCode(lookupCountries(ctx, []string{top.Country_id}), top.Country_id)
+		parts = append(parts, templates.Render("profile.nationality", "demonym", demonym))
+	}
+
+	if len(parts) == 0 {
+		builder.Say(templates.Render("profile.none"))
+	} else {
+		builder.Say(templates.Render("profile.sentence", "name", firstName, "parts", formats.List(parts)))
+	}
+	return builder.Build()
+}
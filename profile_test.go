@@ -0,0 +1,92 @@
+package main
+
+import (
+	"alexa-skill-test/src/nationality"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// serveProfileAPIs points agify and genderize at servers answering for Hans,
+// or failing with the status of the api in failing
+func serveProfileAPIs(t *testing.T, failing map[string]int) {
+	answer := func(api, body string) string {
+		return serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			if status, failed := failing[api]; failed {
+				w.WriteHeader(status)
+				return
+			}
+			w.Write([]byte(body))
+		})
+	}
+	cfg.AgifyURL = answer("agify", `{"name":"Hans","age":61,"count":23456}`)
+	cfg.GenderizeURL = answer("genderize", `{"name":"Hans","gender":"male","probability":0.99,"count":1234}`)
+}
+
+// profileOf returns what is said about the profile of name
+func profileOf(provider nationality.Provider, name string) string {
+	return spoken(dispatchIntent(testContext(provider), intentRequest("ProfileIntent", map[string]string{"first_name": name})))
+}
+
+func TestProfileCombinesAllGuesses(t *testing.T) {
+	keepConfig(t)
+	serveProfileAPIs(t, nil)
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+
+	speech := profileOf(provider, "Hans")
+	for _, part := range []string{"hans sounds male", "is probably around 61", "is most likely german"} {
+		if !strings.Contains(speech, part) {
+			t.Errorf("speech %q doesn't say %q", speech, part)
+		}
+	}
+}
+
+func TestProfileLeavesOutFailedGuesses(t *testing.T) {
+	tests := map[string]struct {
+		failing  map[string]int
+		provider *fakeNationality
+		left     string
+	}{
+		"agify": {
+			failing:  map[string]int{"agify": http.StatusTooManyRequests},
+			provider: &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}},
+			left:     "is probably around",
+		},
+		"genderize": {
+			failing:  map[string]int{"genderize": http.StatusInternalServerError},
+			provider: &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}},
+			left:     "sounds male",
+		},
+		"nationalize": {
+			provider: &fakeNationality{err: nationality.ErrQuotaExhausted},
+			left:     "is most likely",
+		},
+	}
+	for api, test := range tests {
+		t.Run(api, func(t *testing.T) {
+			keepConfig(t)
+			serveProfileAPIs(t, test.failing)
+
+			speech := profileOf(test.provider, "Hans")
+			if !strings.HasPrefix(speech, "<speak>hans ") {
+				t.Errorf("speech %q doesn't speak the profile of Hans", speech)
+			}
+			if strings.Contains(speech, test.left) {
+				t.Errorf("speech %q has the failed guess %q", speech, test.left)
+			}
+			if strings.Contains(speech, "couldn&apos;t guess anything") {
+				t.Errorf("speech %q left out the guesses that succeeded", speech)
+			}
+		})
+	}
+}
+
+func TestProfileWhenEveryGuessFails(t *testing.T) {
+	keepConfig(t)
+	serveProfileAPIs(t, map[string]int{"agify": http.StatusBadGateway, "genderize": http.StatusBadGateway})
+
+	speech := profileOf(&fakeNationality{err: nationality.ErrQuotaExhausted}, "Hans")
+	if !strings.Contains(speech, "couldn&apos;t guess anything about that name") {
+		t.Errorf("speech %q, want nothing guessed", speech)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/nationality"
+	"context"
+)
+
+// providers are the upstream lookups the handlers make. They are created once
+// by main and carried by the context of every request, the same way its logger
+// is, so the handlers can be run against any implementation of them
+type providers struct {
+	// nationality guesses the nationality of names. Concurrent guesses
+	// of the same name share one lookup
+	nationality nationality.Provider
+	// soloNationality guesses instead of nationality for users the ensemble
+	// flag is off for, nil when no ensemble is configured
+	soloNationality nationality.Provider
+	// countries returns information about the guessed countries. The countries
+	// fetched from the api are cached across invocations of a warm lambda
+	// container, and across concurrent requests in http mode
+	countries countries.Provider
+	// identity reads the names of the speakers from their accounts
+	identity identity
+}
+
+// newProviders returns the providers the configuration asks for
+func newProviders() providers {
+	return providers{
+		nationality:     nationality.NewShared(newNationalityChain(true), cfg.UpstreamTimeout),
+		soloNationality: newSoloNationalityProvider(),
+		countries:       newCountriesProvider(),
+		identity:        accountIdentity{cognitoURL: cfg.CognitoURL, client: httpClient},
+	}
+}
+
+// providersKey is the key the providers are stored under in the context of a request
+type providersKey struct{}
+
+// withProviders returns ctx carrying upstream
+func withProviders(ctx context.Context, upstream providers) context.Context {
+	return context.WithValue(ctx, providersKey{}, upstream)
+}
+
+// providersFrom returns the providers carried by ctx. Every entry point
+// stores them, so none are missing while a request is handled
+func providersFrom(ctx context.Context) providers {
+	upstream, _ := ctx.Value(providersKey{}).(providers)
+	return upstream
+}
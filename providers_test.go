@@ -0,0 +1,93 @@
+package main
+
+import (
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingCountriesAPI points the countries api of the configuration at a
+// server answering Germany, returning the number of requests it received
+func countingCountriesAPI(t *testing.T) *atomic.Int32 {
+	var requests atomic.Int32
+	cfg.CountriesURL = serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[{"alpha2Code":"DE","name":"Germany","demonym":"Teutonic"}]`))
+	})
+	return &requests
+}
+
+func TestDisabledCountryAPIUsesEmbeddedCountries(t *testing.T) {
+	keepConfig(t)
+	requests := countingCountriesAPI(t)
+	cfg.DisableCountryAPI = true
+
+	upstream := newProviders()
+	upstream.nationality = &fakeNationality{responses: map[string]nationality.Response{"Hans": hansGuess}}
+	ctx := withProviders(context.Background(), upstream)
+	speech := spoken(dispatchIntent(ctx, intentRequest("GuessIntent", map[string]string{"first_name": "Hans"})))
+	if !strings.Contains(speech, "german") {
+		t.Errorf("speech %q doesn't resolve the demonym from the embedded countries", speech)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("countries api was called %d times while disabled", got)
+	}
+}
+
+func TestEnabledCountryAPIIsCalled(t *testing.T) {
+	keepConfig(t)
+	requests := countingCountriesAPI(t)
+	cfg.DisableCountryAPI = false
+
+	fetched, err := newCountriesProvider().Fetch(context.Background(), []string{"DE"})
+	if err != nil || len(fetched) != 1 || fetched[0].Demonym != "Teutonic" {
+		t.Errorf("Fetch = %+v, %v, want the api's Germany", fetched, err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("countries api was called %d times, want once", got)
+	}
+}
+
+func TestLookupCountriesFallsBackToEmbedded(t *testing.T) {
+	keepConfig(t)
+	cfg.CountriesURL = serveAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	ctx := withProviders(context.Background(), providers{countries: countries.API{BaseURL: cfg.CountriesURL, HTTP: httpClient}})
+
+	fetched := lookupCountries(ctx, []string{"DE"})
+	if len(fetched) != 1 || fetched[0].Demonym != "German" {
+		t.Errorf("lookupCountries = %+v, want the embedded Germany", fetched)
+	}
+}
+
+// predictingNationality is a provider only able to predict, unlike the
+// providers of the skill which also tell the records behind their guesses
+type predictingNationality []nationality.Prediction
+
+func (predictions predictingNationality) Predict(ctx context.Context, name string) ([]nationality.Prediction, error) {
+	return predictions, nil
+}
+
+func TestGuessWithAnyProvider(t *testing.T) {
+	failing := &fakeNationality{err: nationality.ErrQuotaExhausted}
+	french := predictingNationality{{Country_id: "FR", Probability: 0.7}}
+	tests := map[string]nationality.Provider{
+		"predicting": french,
+		"fallback":   nationality.NewFallback(nationality.Named{Name: "failing", Provider: failing}, nationality.Named{Name: "french", Provider: french}),
+		"ensemble": nationality.Ensemble{Members: []nationality.Member{
+			{Name: "failing", Provider: failing, Weight: 1},
+			{Name: "french", Provider: french, Weight: 1},
+		}},
+	}
+	for kind, provider := range tests {
+		speech := spoken(dispatchIntent(testContext(provider), intentRequest("GuessIntent", map[string]string{"first_name": "Jean"})))
+		if !strings.Contains(speech, "french") {
+			t.Errorf("%s provider: speech %q doesn't guess French", kind, speech)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/flags"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/quiz"
+	"alexa-skill-test/src/session"
+	"context"
+)
+
+// quizAttempts is how many names are tried when picking a question,
+// skipping names the api has no guess for
+const quizAttempts = 3
+
+// quizIntentModels declare the intents of the quiz in the interaction model
+var quizIntentModels = []model.Intent{
+	{
+		Name:    "StartQuizIntent",
+		Samples: []string{"start a quiz", "quiz me", "let's play a game"},
+	},
+	{
+		Name:    "AnswerIntent",
+		Slots:   []model.Slot{{Name: "country", Type: "AMAZON.Country"}},
+		Samples: []string{"{country}", "is it {country}", "i think it's {country}"},
+	},
+	{
+		Name:    "EndQuizIntent",
+		Samples: []string{"end the quiz", "stop the quiz", "i'm done with the quiz"},
+	},
+}
+
+// HandleStartQuizIntent starts a quiz where the skill says a name and
+// the user guesses the country it is most common in.
+// A user can say:
+// Alexa, ask nationality guesser to start a quiz
+func HandleStartQuizIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	if !featureFlags.Enabled(ctx, flags.Quiz) {
+		return alexa.NewSimpleResponse(templates.Render("title.quiz"), templates.Render("quiz.disabled")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+	attributes := copySessionAttributes(request)
+	session.Load(attributes).ClearParty()
+	return askQuizQuestion(ctx, templates, attributes, quiz.State{}, templates.Render("quiz.start"))
+}
+
+// HandleAnswerIntent scores the country the user answered against the top
+// guess for the name of the current question, then asks the next question
+// or ends the quiz after cfg.QuizRounds questions.
+// A user can say:
+// Italy
+func HandleAnswerIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	state, ok := session.Load(attributes).Quiz()
+	if current, _ := dialogState(request); !ok || current != dialogQuiz {
+		return buildConfusedResponse(templates)
+	}
+
+	answer := getValueOfName(request.Body.Intent.Slots, "country")
+	code, matched := countries.Embedded.Match(answer)
+	if !matched {
+		speech := templates.Render("quiz.unmatched", "answer", answer, "name", state.Name)
+		return alexa.NewSimpleResponse(templates.Render("title.quiz"), speech).
+			WithReprompt(templates.Render("quiz.reprompt", "name", state.Name)).
+			WithSessionAttributes(attributes).
+			WithShouldEndSession(false)
+	}
+
+	verdict := "quiz.wrong"
+	if code == state.Answer {
+		verdict = "quiz.correct"
+		state.Score++
+	}
+	state.Round++
+	result := templates.Render(verdict, "name", state.Name, "country", findCountryName(templates, nil, state.Answer))
+
+	if state.Round >= cfg.QuizRounds {
+		return endQuiz(ctx, request, templates, attributes, state, result+" "+templates.Render("quiz.finished"))
+	}
+	return askQuizQuestion(ctx, templates, attributes, state, result)
+}
+
+// HandleEndQuizIntent stops the quiz in progress and reads out the score.
+// A user can say:
+// Alexa, end the quiz
+func HandleEndQuizIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	attributes := copySessionAttributes(request)
+	state, ok := session.Load(attributes).Quiz()
+	if !ok {
+		return alexa.NewSimpleResponse(templates.Render("title.quiz"), templates.Render("quiz.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+	return endQuiz(ctx, request, templates, attributes, state, templates.Render("quiz.ended"))
+}
+
+// askQuizQuestion picks the name of the next question, remembers the
+// country it is most common in and asks the user about it, preceded by intro
+func askQuizQuestion(ctx context.Context, templates messages.Set, attributes map[string]interface{}, state quiz.State, intro string) alexa.Response {
+	state.Name, state.Answer = "", ""
+	for attempt := 0; attempt < quizAttempts && state.Answer == ""; attempt++ {
+		name := pickQuizName(state.Asked)
+		state.Asked = append(state.Asked, name)
+		response, err := lookupNationality(ctx, name)
+		if err != nil {
+			logging.FromContext(ctx).Warn("quiz nationality guess failed", "error", err)
+			continue
+		}
+		if predictions := response.Predictions; len(predictions) > 0 {
+			state.Name, state.Answer = name, sortPredictions(predictions)[0].Country_id
+		}
+	}
+	if state.Answer == "" {
+		session.Load(attributes).ClearQuiz()
+		return alexa.NewSimpleResponse(templates.Render("title.quiz"), templates.Render("quiz.unavailable"))
+	}
+
+	session.Load(attributes).SetQuiz(state)
+	setDialogState(attributes, dialogQuiz, "")
+	speech := intro + " " + templates.Render("quiz.question", "name", state.Name)
+	return alexa.NewSimpleResponse(templates.Render("title.quiz"), speech).
+		WithReprompt(templates.Render("quiz.reprompt", "name", state.Name)).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
+
+// endQuiz reads out the score after intro, saves it to the leaderboard
+// and forgets the quiz, keeping the session open for more guesses
+func endQuiz(ctx context.Context, request alexa.Request, templates messages.Set, attributes map[string]interface{}, state quiz.State, intro string) alexa.Response {
+	recordQuiz(ctx, request, state)
+	session.Load(attributes).ClearQuiz()
+	setDialogState(attributes, dialogIdle, "")
+	score := templates.Render("quiz.score", "score", formatsFor(request).Number(state.Score), "rounds", formatsFor(request).Number(state.Round))
+	speech := intro + " " + score + " " + templates.Render("guess.followup")
+	return alexa.NewSimpleResponse(templates.Render("title.quiz"), speech).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithSessionAttributes(attributes).
+		WithShouldEndSession(false)
+}
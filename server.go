@@ -0,0 +1,197 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Guess is a single nationality guess as returned to http consumers
+type Guess struct {
+	Code        string  `json:"code"`
+	Country     string  `json:"country"`
+	Demonym     string  `json:"demonym"`
+	Region      string  `json:"region"`
+	Probability float64 `json:"probability"`
+}
+
+// RegionGuesses groups the guesses of a single region along with their summed probability
+type RegionGuesses struct {
+	Probability float64 `json:"probability"`
+	Guesses     []Guess `json:"guesses"`
+}
+
+// GuessResult is the default flat response of the guess endpoint
+type GuessResult struct {
+	Name    string  `json:"name"`
+	Guesses []Guess `json:"guesses"`
+}
+
+// RegionResult is the response of the guess endpoint when grouping by region,
+// which suits front ends rendering the guesses on a map
+type RegionResult struct {
+	Name    string                   `json:"name"`
+	Regions map[string]RegionGuesses `json:"regions"`
+}
+
+// serveHTTP runs the skill as an http server listening on addr.
+// Alexa requests are accepted on /alexa, guesses can be fetched
+// as json from /guess?name=Ethan, health is reported on /healthz and
+// printer skills fetch the printed results from /print
+//
+// On SIGTERM or SIGINT the server stops accepting connections and
+// waits for in-flight requests to complete before returning
+func serveHTTP(addr string, upstream providers) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	return runServer(ctx, newServer(addr, upstream), cfg.ShutdownTimeout)
+}
+
+// newServer returns an http server routing requests to the skill handlers,
+// which look up upstream through providers
+func newServer(addr string, upstream providers) *http.Server {
+	mux := http.NewServeMux()
+
+	// Alexa only trusts endpoints that check requests were signed by it
+	var alexaHandler http.Handler = http.HandlerFunc(handleAlexaHTTP)
+	if cfg.VerifyRequests {
+		alexaHandler = requestVerifier.Middleware(alexaHandler)
+	}
+	mux.Handle("/alexa", alexaHandler)
+	mux.HandleFunc("/guess", handleGuessHTTP)
+	mux.HandleFunc("/healthz", handleHealthHTTP)
+	mux.HandleFunc("/print", handlePrintHTTP)
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+		BaseContext: func(net.Listener) context.Context {
+			return withProviders(context.Background(), upstream)
+		},
+	}
+}
+
+// runServer serves until ctx is done, then shuts the server down
+// giving in-flight requests up to timeout to complete
+func runServer(ctx context.Context, server *http.Server, timeout time.Duration) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	// the events of the drained requests are sent before exiting
+	analyticsEmitter.Close(shutdownCtx)
+	if err := <-errs; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// handleAlexaHTTP decodes an alexa request from the body and
+// dispatches it exactly like lambda invocations are
+func handleAlexaHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request alexa.Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "malformed alexa request", http.StatusBadRequest)
+		return
+	}
+	response, err := handleRequest(r.Context(), request)
+	if err != nil {
+		http.Error(w, "request rejected", http.StatusForbidden)
+		return
+	}
+	writeJSON(w, response)
+}
+
+// handleGuessHTTP responds with the nationality guesses for the name query
+// parameter, grouped under their regions when group=region is given
+func handleGuessHTTP(w http.ResponseWriter, r *http.Request) {
+	name := names.Sanitize(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+	if blocklist.Contains(name) {
+		http.Error(w, "name not allowed", http.StatusUnprocessableEntity)
+		return
+	}
+
+	predictionsResponse, countries, err := fetchGuesses(r.Context(), name)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("nationality guess failed", "error", err)
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		return
+	}
+	guesses := buildGuesses(countries, predictionsResponse)
+
+	switch r.URL.Query().Get("group") {
+	case "":
+		writeJSON(w, GuessResult{Name: name, Guesses: guesses})
+	case "region":
+		writeJSON(w, RegionResult{Name: name, Regions: groupByRegion(guesses)})
+	default:
+		http.Error(w, "unsupported group", http.StatusBadRequest)
+	}
+}
+
+// buildGuesses joins every prediction with the information about its country
+func buildGuesses(countries countries.Country, predictionsResponse nationality.Response) []Guess {
+	guesses := []Guess{}
+	for _, v := range predictionsResponse.Predictions {
+		guess := Guess{Code: v.Country_id, Country: "Unknown", Demonym: findCountryOfCode(countries, v.Country_id), Region: "Unknown", Probability: v.Probability}
+		for _, country := range countries {
+			if country.Code == v.Country_id {
+				guess.Country = country.Name
+				if country.Region != "" {
+					guess.Region = country.Region
+				}
+			}
+		}
+		guesses = append(guesses, guess)
+	}
+	return guesses
+}
+
+// groupByRegion nests guesses under their regions, summing their probabilities
+func groupByRegion(guesses []Guess) map[string]RegionGuesses {
+	regions := map[string]RegionGuesses{}
+	for _, guess := range guesses {
+		region := regions[guess.Region]
+		region.Probability += guess.Probability
+		region.Guesses = append(region.Guesses, guess)
+		regions[guess.Region] = region
+	}
+	return regions
+}
+
+// writeJSON encodes value as the json body of the response
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"alexa-skill-test/src/nationality"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getGuess returns the response of the guess endpoint to a get of target,
+// the guesses being made by provider
+func getGuess(provider nationality.Provider, target string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	recorder := httptest.NewRecorder()
+	handleGuessHTTP(recorder, request.WithContext(testContext(provider)))
+	return recorder
+}
+
+// mixedGuess spreads the guesses of a name over two regions
+var mixedGuess = nationality.Response{
+	Predictions: []nationality.Prediction{
+		{Country_id: "DE", Probability: 0.4},
+		{Country_id: "BR", Probability: 0.3},
+		{Country_id: "FR", Probability: 0.2},
+	},
+}
+
+func TestGuessHTTPFlat(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": mixedGuess}}
+	recorder := getGuess(provider, "/guess?name=Hans")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d", recorder.Code)
+	}
+
+	var result GuessResult
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("body %q isn't a flat result: %v", recorder.Body.String(), err)
+	}
+	if result.Name != "Hans" || len(result.Guesses) != 3 {
+		t.Fatalf("result %+v", result)
+	}
+	want := Guess{Code: "DE", Country: "Germany", Demonym: "German", Region: "Europe", Probability: 0.4}
+	if result.Guesses[0] != want {
+		t.Errorf("first guess %+v, want %+v", result.Guesses[0], want)
+	}
+}
+
+func TestGuessHTTPGroupedByRegion(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": mixedGuess}}
+	recorder := getGuess(provider, "/guess?name=Hans&group=region")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d", recorder.Code)
+	}
+
+	var result RegionResult
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("body %q isn't a grouped result: %v", recorder.Body.String(), err)
+	}
+	europe, americas := result.Regions["Europe"], result.Regions["Americas"]
+	if len(result.Regions) != 2 || len(europe.Guesses) != 2 || len(americas.Guesses) != 1 {
+		t.Fatalf("regions %+v, want Europe with 2 guesses and the Americas with 1", result.Regions)
+	}
+	if math.Abs(europe.Probability-0.6) > 1e-9 || math.Abs(americas.Probability-0.3) > 1e-9 {
+		t.Errorf("region probabilities %v and %v, want 0.6 and 0.3", europe.Probability, americas.Probability)
+	}
+}
+
+func TestGuessHTTPRejectsBadRequests(t *testing.T) {
+	provider := &fakeNationality{responses: map[string]nationality.Response{"Hans": mixedGuess}}
+	tests := map[string]int{
+		"/guess":                        http.StatusBadRequest,
+		"/guess?name=%3C%3E":            http.StatusBadRequest,
+		"/guess?name=Hans&group=planet": http.StatusBadRequest,
+		"/guess?name=bitch":             http.StatusUnprocessableEntity,
+	}
+	for target, want := range tests {
+		if got := getGuess(provider, target).Code; got != want {
+			t.Errorf("GET %s: status %d, want %d", target, got, want)
+		}
+	}
+}
+
+func TestGuessHTTPUpstreamFailure(t *testing.T) {
+	provider := &fakeNationality{err: nationality.ErrQuotaExhausted}
+	if got := getGuess(provider, "/guess?name=Hans").Code; got != http.StatusBadGateway {
+		t.Errorf("status %d, want %d", got, http.StatusBadGateway)
+	}
+}
+
+// freeAddr returns a local address nothing listens on
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// waitFor calls check until it returns true, failing the test after a few seconds
+func waitFor(t *testing.T, what string, check func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if check() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestRunServerDrainsInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	server := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- runServer(ctx, server, 5*time.Second) }()
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	bodies := make(chan string, 1)
+	go func() {
+		response, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			bodies <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		bodies <- string(body)
+	}()
+	<-started
+
+	// shutting down refuses new connections while the request is in flight
+	cancel()
+	waitFor(t, "new connections to be refused", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	})
+	select {
+	case err := <-stopped:
+		t.Fatalf("server stopped with a request in flight: %v", err)
+	default:
+	}
+
+	close(release)
+	if body := <-bodies; body != "done" {
+		t.Errorf("in-flight request got %q, want it completed", body)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("runServer failed: %v", err)
+	}
+}
+
+func TestRunServerGivesUpAfterTimeout(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	server := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- runServer(ctx, server, 50*time.Millisecond) }()
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	go http.Get("http://" + addr + "/")
+	<-started
+	cancel()
+	if err := <-stopped; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("runServer error = %v, want the shutdown to time out", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/alexaapi"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/nationality"
+	"context"
+	"errors"
+	"strings"
+)
+
+// withDeviceLocale returns request with the primary locale of the device
+// filled in from the Settings API when the request didn't carry any,
+// so responses are spoken in the language the device is set to
+func withDeviceLocale(ctx context.Context, request alexa.Request) alexa.Request {
+	if request.Body.Locale != "" || request.Context.System.Device.DeviceID == "" {
+		return request
+	}
+	locales, err := alexaapi.NewClient(request).DeviceLocales(ctx, request.Context.System.Device.DeviceID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("settings api locales unavailable", "error", err)
+		return request
+	}
+	if len(locales) > 0 {
+		request.Body.Locale = locales[0]
+	}
+	return request
+}
+
+// deviceCountry returns the alpha-2 code of the country the device is in,
+// or an empty string when the user hasn't allowed the skill to read it
+func deviceCountry(ctx context.Context, request alexa.Request) string {
+	if cfg.DeviceCountryBias == 1 || request.Context.System.Device.DeviceID == "" {
+		return ""
+	}
+	country, err := alexaapi.NewClient(request).DeviceCountry(ctx, request.Context.System.Device.DeviceID)
+	if err != nil && !errors.Is(err, alexaapi.ErrForbidden) {
+		logging.FromContext(ctx).Warn("settings api country unavailable", "error", err)
+	}
+	return country
+}
+
+// adjustPredictions returns predictions leaning towards the country the user is
+// likely in, along with the code of that country when the leaning changed them.
+// The country the device is in is favoured when the user allowed the skill to
+// read it, and the country of the locale the request is in otherwise, e.g.
+// India for "en-IN", so a noisy global guess still ranks the local country fairly
+func adjustPredictions(ctx context.Context, request alexa.Request, predictions []nationality.Prediction) ([]nationality.Prediction, string) {
+	if country := deviceCountry(ctx, request); country != "" {
+		biased := biasPredictions(predictions, country)
+		return biased, changedFor(predictions, biased, country)
+	}
+	_, country, _ := strings.Cut(request.Body.Locale, "-")
+	country = strings.ToUpper(country)
+	adjusted := applyLocalePrior(predictions, country)
+	return adjusted, changedFor(predictions, adjusted, country)
+}
+
+// changedFor returns country when adjusted differs from predictions, and an empty string otherwise
+func changedFor(predictions []nationality.Prediction, adjusted []nationality.Prediction, country string) string {
+	for i := range predictions {
+		if predictions[i] != adjusted[i] {
+			return country
+		}
+	}
+	return ""
+}
+
+// applyLocalePrior returns a copy of predictions updated with a weak prior
+// putting cfg.LocalePriorWeight on country and spreading the rest evenly over
+// the guessed countries, the probabilities keeping the same sum. Countries
+// the provider didn't guess stay unguessed, so the prior only reorders them
+func applyLocalePrior(predictions []nationality.Prediction, country string) []nationality.Prediction {
+	weight := cfg.LocalePriorWeight
+	if country == "" || weight <= 0 || weight >= 1 || len(predictions) == 0 {
+		return predictions
+	}
+	// the prior odds of country against any other guessed country
+	boost := 1 + weight*float64(len(predictions))/(1-weight)
+
+	var total, posterior float64
+	for _, v := range predictions {
+		total += v.Probability
+		if v.Country_id == country {
+			posterior += v.Probability * boost
+		} else {
+			posterior += v.Probability
+		}
+	}
+	if posterior == 0 || posterior == total {
+		return predictions
+	}
+
+	result := make([]nationality.Prediction, len(predictions))
+	for i, v := range predictions {
+		probability := v.Probability
+		if v.Country_id == country {
+			probability *= boost
+		}
+		result[i] = nationality.Prediction{Country_id: v.Country_id, Probability: probability * total / posterior}
+	}
+	return result
+}
+
+// biasPredictions returns a copy of predictions with the probability of country
+// multiplied by cfg.DeviceCountryBias, the others being scaled so the
+// probabilities keep the same sum. A user is more likely to be from the
+// country their device is in, so improbable far away countries are down-weighted
+func biasPredictions(predictions []nationality.Prediction, country string) []nationality.Prediction {
+	var total, biased float64
+	for _, v := range predictions {
+		total += v.Probability
+		if v.Country_id == country {
+			biased += v.Probability * cfg.DeviceCountryBias
+		} else {
+			biased += v.Probability
+		}
+	}
+	if country == "" || biased == 0 || biased == total {
+		return predictions
+	}
+
+	result := make([]nationality.Prediction, len(predictions))
+	for i, v := range predictions {
+		probability := v.Probability
+		if v.Country_id == country {
+			probability *= cfg.DeviceCountryBias
+		}
+		result[i] = nationality.Prediction{Country_id: v.Country_id, Probability: probability * total / biased}
+	}
+	return result
+}
@@ -0,0 +1,7 @@
+package age
+
+type Response struct {
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,164 @@
+// Package alerting notifies the operator through SNS when an upstream
+// provider keeps failing, so outages are heard about before users complain
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// Publisher is the part of the SNS client publishing notifications
+type Publisher interface {
+	Publish(ctx context.Context, input *sns.PublishInput, options ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Alert is the notification published about a failing provider
+type Alert struct {
+	Provider string `json:"provider"`
+	// ErrorClass is the kind of the last failure of the provider
+	ErrorClass  string    `json:"errorClass"`
+	FailureRate float64   `json:"failureRate"`
+	FailingFor  string    `json:"failingFor"`
+	Since       time.Time `json:"since"`
+}
+
+// queueSize is how many alerts may wait to be published before new ones are dropped
+const queueSize = 16
+
+// ErrQueueFull is returned by Observe when an alert is due while too many wait to be published
+var ErrQueueFull = errors.New("alerting: too many alerts waiting to be published")
+
+// Monitor watches the failure rate of every provider, publishing an alert to
+// Topic once a provider failed at least FailureRate of the time for After,
+// then again every Repeat while it keeps failing. Alerts are published in the
+// background, so observing never holds up a request. It is safe for concurrent use
+type Monitor struct {
+	Client      Publisher
+	Topic       string
+	FailureRate float64
+	After       time.Duration
+	Repeat      time.Duration
+	// Timeout bounds publishing an alert
+	Timeout time.Duration
+	// Failed, if not nil, is called with every alert that couldn't be published and why
+	Failed func(alert Alert, err error)
+
+	mutex     sync.Mutex
+	providers map[string]*state
+	start     sync.Once
+	queue     chan Alert
+}
+
+// state is what the monitor knows about one provider
+type state struct {
+	failingSince time.Time
+	alertedAt    time.Time
+	errorClass   string
+	rate         float64
+}
+
+// Observe records the failure rate of provider after one of its lookups,
+// along with the class of the error it failed with, if any, and queues
+// an alert when the provider has been failing for too long. Failing providers
+// are tried less often, so each observation checks every provider.
+// It never waits for an alert to be published, and drops the alerts
+// due while the queue is full, returning ErrQueueFull
+func (monitor *Monitor) Observe(provider string, failureRate float64, errorClass string) error {
+	alerts := monitor.update(time.Now(), provider, failureRate, errorClass)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	monitor.start.Do(func() {
+		monitor.queue = make(chan Alert, queueSize)
+		go monitor.run()
+	})
+	var err error
+	for _, alert := range alerts {
+		select {
+		case monitor.queue <- alert:
+		default:
+			err = ErrQueueFull
+		}
+	}
+	return err
+}
+
+// run publishes the queued alerts one at a time, for the life of the process
+func (monitor *Monitor) run() {
+	for alert := range monitor.queue {
+		if err := monitor.publish(context.Background(), alert); err != nil && monitor.Failed != nil {
+			monitor.Failed(alert, err)
+		}
+	}
+}
+
+// update records the observation and returns the alerts due at now
+func (monitor *Monitor) update(now time.Time, provider string, failureRate float64, errorClass string) []Alert {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	if monitor.providers == nil {
+		monitor.providers = map[string]*state{}
+	}
+	current, ok := monitor.providers[provider]
+	if !ok {
+		current = &state{}
+		monitor.providers[provider] = current
+	}
+	current.rate = failureRate
+	if errorClass != "" {
+		current.errorClass = errorClass
+	}
+	switch {
+	case failureRate < monitor.FailureRate:
+		current.failingSince, current.alertedAt = time.Time{}, time.Time{}
+	case current.failingSince.IsZero():
+		current.failingSince = now
+	}
+
+	var alerts []Alert
+	for name, provider := range monitor.providers {
+		if provider.failingSince.IsZero() || now.Sub(provider.failingSince) < monitor.After {
+			continue
+		}
+		if !provider.alertedAt.IsZero() && now.Sub(provider.alertedAt) < monitor.Repeat {
+			continue
+		}
+		provider.alertedAt = now
+		alerts = append(alerts, Alert{
+			Provider:    name,
+			ErrorClass:  provider.errorClass,
+			FailureRate: provider.rate,
+			FailingFor:  now.Sub(provider.failingSince).Round(time.Second).String(),
+			Since:       provider.failingSince.UTC(),
+		})
+	}
+	return alerts
+}
+
+// publish sends alert to the topic, with a subject readable in an email
+func (monitor *Monitor) publish(ctx context.Context, alert Alert) error {
+	if monitor.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, monitor.Timeout)
+		defer cancel()
+	}
+	message, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("%s failing %.0f%% of lookups for %s", alert.Provider, alert.FailureRate*100, alert.FailingFor)
+	_, err = monitor.Client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(monitor.Topic),
+		Subject:  aws.String(subject),
+		Message:  aws.String(string(message)),
+	})
+	return err
+}
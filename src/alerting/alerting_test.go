@@ -0,0 +1,117 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// publisher is an SNS client handing every message to published,
+// after waiting for release when it isn't nil
+type publisher struct {
+	release   chan struct{}
+	published chan Alert
+	err       error
+}
+
+func (publisher *publisher) Publish(ctx context.Context, input *sns.PublishInput, options ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if publisher.release != nil {
+		select {
+		case <-publisher.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	var alert Alert
+	if err := json.Unmarshal([]byte(*input.Message), &alert); err != nil {
+		return nil, err
+	}
+	publisher.published <- alert
+	return &sns.PublishOutput{}, publisher.err
+}
+
+// received returns the next alert published, failing the test when none comes
+func received(t *testing.T, published chan Alert) Alert {
+	select {
+	case alert := <-published:
+		return alert
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert published")
+		return Alert{}
+	}
+}
+
+func TestObserveDoesntWaitForPublishing(t *testing.T) {
+	client := &publisher{release: make(chan struct{}), published: make(chan Alert, 1)}
+	monitor := &Monitor{Client: client, Topic: "arn:aws:sns:eu-west-1:1:alerts", FailureRate: 0.5, Repeat: time.Hour}
+
+	started := time.Now()
+	if err := monitor.Observe("nationalize", 0.9, "Timeout"); err != nil {
+		t.Fatalf("Observe failed: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Observe took %s, waiting for the alert to be published", elapsed)
+	}
+
+	close(client.release)
+	alert := received(t, client.published)
+	if alert.Provider != "nationalize" || alert.ErrorClass != "Timeout" || alert.FailureRate != 0.9 {
+		t.Errorf("alert %+v", alert)
+	}
+}
+
+func TestObserveAlertsOnlyAfterFailingForLong(t *testing.T) {
+	client := &publisher{published: make(chan Alert, 1)}
+	monitor := &Monitor{Client: client, Topic: "topic", FailureRate: 0.5, After: time.Hour, Repeat: time.Hour}
+
+	monitor.Observe("nationalize", 0.9, "ProviderUnavailable")
+	monitor.Observe("nationalize", 0.1, "")
+	select {
+	case alert := <-client.published:
+		t.Errorf("alert %+v published before the provider failed for long", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestObserveDropsAlertsWhenQueueIsFull(t *testing.T) {
+	client := &publisher{release: make(chan struct{}), published: make(chan Alert, queueSize+2)}
+	defer close(client.release)
+	monitor := &Monitor{Client: client, Topic: "topic", FailureRate: 0.5}
+
+	var err error
+	// every observation is due an alert since Repeat is zero, and none gets published
+	for i := 0; i < queueSize+2 && err == nil; i++ {
+		err = monitor.Observe("nationalize", 0.9, "Timeout")
+	}
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Observe error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestPublishingIsBoundedByTimeout(t *testing.T) {
+	client := &publisher{release: make(chan struct{}), published: make(chan Alert, 1)}
+	defer close(client.release)
+	failed := make(chan error, 1)
+	monitor := &Monitor{
+		Client:      client,
+		Topic:       "topic",
+		FailureRate: 0.5,
+		Repeat:      time.Hour,
+		Timeout:     20 * time.Millisecond,
+		Failed:      func(alert Alert, err error) { failed <- err },
+	}
+
+	monitor.Observe("nationalize", 0.9, "Timeout")
+	select {
+	case err := <-failed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("publishing failed with %v, want it timed out", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing didn't time out")
+	}
+}
@@ -0,0 +1,86 @@
+package alexa
+
+import "strings"
+
+// Types of the requests sent when the playback of a stream changes
+const (
+	PlaybackStarted        = "AudioPlayer.PlaybackStarted"
+	PlaybackFinished       = "AudioPlayer.PlaybackFinished"
+	PlaybackStopped        = "AudioPlayer.PlaybackStopped"
+	PlaybackNearlyFinished = "AudioPlayer.PlaybackNearlyFinished"
+	PlaybackFailed         = "AudioPlayer.PlaybackFailed"
+)
+
+// Play behaviors deciding what happens to the queue when a stream is played
+const (
+	// PlayReplaceAll stops the current stream and replaces the whole queue
+	PlayReplaceAll = "REPLACE_ALL"
+	// PlayEnqueue adds the stream to the end of the queue
+	PlayEnqueue = "ENQUEUE"
+	// PlayReplaceEnqueued replaces the queue after the current stream
+	PlayReplaceEnqueued = "REPLACE_ENQUEUED"
+)
+
+// Clear behaviors deciding whether clearing the queue stops the current stream
+const (
+	ClearEnqueued = "CLEAR_ENQUEUED"
+	ClearAll      = "CLEAR_ALL"
+)
+
+// PlayerPlaying is the player activity of a device streaming audio
+const PlayerPlaying = "PLAYING"
+
+// IsAudioPlayerRequest reports whether request is about the playback of a stream
+// rather than something the user said
+func (request Request) IsAudioPlayerRequest() bool {
+	return strings.HasPrefix(request.Body.Type, "AudioPlayer.")
+}
+
+// AudioPlaying reports whether the device is streaming audio played by the skill
+func (request Request) AudioPlaying() bool {
+	return request.Context.AudioPlayer != nil && request.Context.AudioPlayer.PlayerActivity == PlayerPlaying
+}
+
+// NewPlayDirective returns a directive streaming the audio at url, which must be
+// https, from offset milliseconds. The token identifies the stream in the
+// AudioPlayer requests sent about it
+func NewPlayDirective(behavior string, token string, url string, offset int) Directives {
+	directive := Directives{
+		Type:         "AudioPlayer.Play",
+		PlayBehavior: behavior,
+		AudioItem:    &AudioItem{},
+	}
+	directive.AudioItem.Stream.Token = token
+	directive.AudioItem.Stream.URL = url
+	directive.AudioItem.Stream.OffsetInMilliseconds = offset
+	return directive
+}
+
+// NewStopDirective returns a directive stopping the current stream
+func NewStopDirective() Directives {
+	return Directives{Type: "AudioPlayer.Stop"}
+}
+
+// NewClearQueueDirective returns a directive clearing the queue of streams,
+// along with the current stream when behavior is ClearAll
+func NewClearQueueDirective(behavior string) Directives {
+	return Directives{Type: "AudioPlayer.ClearQueue", ClearBehavior: behavior}
+}
+
+// NewAudioPlayerResponse returns a response to an AudioPlayer request,
+// which may only hold AudioPlayer directives and no speech
+func NewAudioPlayerResponse(directives ...Directives) Response {
+	return Response{
+		Version: "1.0",
+		Body: ResBody{
+			Directives:       directives,
+			ShouldEndSession: true,
+		},
+	}
+}
+
+// WithDirectives returns the response with directives appended to its own
+func (r Response) WithDirectives(directives ...Directives) Response {
+	r.Body.Directives = append(append([]Directives(nil), r.Body.Directives...), directives...)
+	return r
+}
@@ -0,0 +1,65 @@
+package alexa
+
+// SessionResumedRequest is the type of the request sent when the session
+// resumes after a skill connection handed the dialog to another skill
+const SessionResumedRequest = "SessionResumedRequest"
+
+// PrintWebPageURI is the connection asking a printer skill of the user to print a web page
+const PrintWebPageURI = "connection://AMAZON.PrintWebPage/1"
+
+// ConnectionCompleted is the cause of a session resumed once a connection completed
+const ConnectionCompleted = "ConnectionCompleted"
+
+// Completion behaviors of a skill connection
+const (
+	// ResumeSession sends a SessionResumedRequest once the connection completed
+	ResumeSession = "RESUME_SESSION"
+	// SendErrorsOnly only resumes the session when the connection failed
+	SendErrorsOnly = "SEND_ERRORS_ONLY"
+)
+
+// ResumeCause tells which connection completed and how it went
+type ResumeCause struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+	// Status is the http-like status of the connection, code "200" when it succeeded
+	Status struct {
+		Code    string `json:"code"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+// Succeeded reports whether the connection completed without an error
+func (cause ResumeCause) Succeeded() bool {
+	return cause.Status.Code == "200"
+}
+
+// PrintWebPageRequest is the input of a PrintWebPageURI connection
+type PrintWebPageRequest struct {
+	Type        string `json:"@type"`
+	Version     string `json:"@version"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// NewStartConnectionDirective returns a directive handing the dialog to the
+// skill of the user providing the connection uri, e.g. PrintWebPageURI,
+// with input. The session resumes with token once the connection completed
+func NewStartConnectionDirective(uri string, input interface{}, token string) Directives {
+	return Directives{
+		Type:         "Connections.StartConnection",
+		URI:          uri,
+		Input:        input,
+		Token:        token,
+		OnCompletion: ResumeSession,
+	}
+}
+
+// NewPrintWebPageDirective returns a directive asking a printer skill of
+// the user to print the page at url, described by title and description
+func NewPrintWebPageDirective(title string, description string, url string, token string) Directives {
+	input := PrintWebPageRequest{Type: "PrintWebPageRequest", Version: "1", Title: title, Description: description, URL: url}
+	return NewStartConnectionDirective(PrintWebPageURI, input, token)
+}
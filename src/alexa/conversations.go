@@ -0,0 +1,54 @@
+package alexa
+
+// APIInvokedRequest is the type of the request sent when an Alexa
+// Conversations dialog invokes one of the apis the skill declares in ACDL
+const APIInvokedRequest = "Dialog.API.Invoked"
+
+// APIRequest is the api an Alexa Conversations dialog invoked, along with
+// the arguments it passed
+type APIRequest struct {
+	Name string `json:"name"`
+	// Arguments maps the name of each argument to its value, of any json type
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	// Slots maps the arguments taken from slots to the slot they were taken
+	// from, which carries the entity resolution of the value
+	Slots map[string]APISlot `json:"slots,omitempty"`
+}
+
+// APISlot is the slot an argument of an api request was filled from
+type APISlot struct {
+	Type        string      `json:"type"`
+	Value       string      `json:"value"`
+	Resolutions Resolutions `json:"resolutions"`
+}
+
+// Argument returns the value of the argument called name as a string,
+// empty when the api was invoked without it or with a value that isn't one
+func (api APIRequest) Argument(name string) string {
+	if slot, ok := api.Slots[name]; ok && slot.Value != "" {
+		return slot.Value
+	}
+	value, _ := api.Arguments[name].(string)
+	return value
+}
+
+// IsAPIInvocation reports whether request was sent by an Alexa Conversations
+// dialog invoking an api, rather than by the interaction model of the intents
+func (request Request) IsAPIInvocation() bool {
+	return request.Body.Type == APIInvokedRequest && request.Body.APIRequest != nil
+}
+
+// NewAPIResponse returns the response of an api invoked by an Alexa
+// Conversations dialog. Nothing is spoken, result is handed to the response
+// templates of the dialog, which speak it
+func NewAPIResponse(result interface{}) Response {
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	return Response{
+		Version: "1.0",
+		Body: ResBody{
+			APIResponse: result,
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package alexa
+
+// Update behaviors of dynamic entities
+const (
+	// EntitiesReplace replaces the dynamic entities of the session with the ones sent
+	EntitiesReplace = "REPLACE"
+	// EntitiesClear removes every dynamic entity of the session
+	EntitiesClear = "CLEAR"
+)
+
+// EntityType lists values added to a custom slot type for the rest of the session,
+// so Alexa recognizes them better and resolves them to their id
+type EntityType struct {
+	Name   string   `json:"name"`
+	Values []Entity `json:"values"`
+}
+
+// Entity is a value of a slot type, resolved to ID when heard
+type Entity struct {
+	ID   string     `json:"id"`
+	Name EntityName `json:"name"`
+}
+
+// EntityName is how an entity is said, along with its other phrasings
+type EntityName struct {
+	Value    string   `json:"value"`
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// NewUpdateDynamicEntitiesDirective returns a directive replacing or clearing
+// the dynamic entities of the session, depending on behavior
+func NewUpdateDynamicEntitiesDirective(behavior string, types ...EntityType) Directives {
+	return Directives{
+		Type:           "Dialog.UpdateDynamicEntities",
+		UpdateBehavior: behavior,
+		Types:          types,
+	}
+}
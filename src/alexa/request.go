@@ -1,11 +1,38 @@
 package alexa
 
+import "strings"
+
 const (
 	HelpIntent   = "AMAZON.HelpIntent"
 	CancelIntent = "AMAZON.CancelIntent"
 	StopIntent   = "AMAZON.StopIntent"
+	RepeatIntent = "AMAZON.RepeatIntent"
+	YesIntent    = "AMAZON.YesIntent"
+	NoIntent     = "AMAZON.NoIntent"
+	PauseIntent  = "AMAZON.PauseIntent"
+	ResumeIntent = "AMAZON.ResumeIntent"
 )
 
+// LaunchRequest is the type of the request sent when the user opens the skill without asking for anything
+const LaunchRequest = "LaunchRequest"
+
+// SkillDisabledEvent is the type of the event sent when the user disables the skill
+const SkillDisabledEvent = "AlexaSkillEvent.SkillDisabled"
+
+// MessageReceivedRequest is the type of the request sent out of session when
+// a backend job posts a message to the skill through the Skill Messaging API
+const MessageReceivedRequest = "Messaging.MessageReceived"
+
+// GivenNamePermission is the scope allowing the skill to read the given name of the user
+const GivenNamePermission = "alexa::profile:given_name:read"
+
+// APLInterface is the supported interface key sent by devices with screens
+const APLInterface = "Alexa.Presentation.APL"
+
+// DisplayInterface is the supported interface key sent by the devices with
+// screens that predate APL, rendering display templates instead
+const DisplayInterface = "Display"
+
 type Request struct {
 	Version string  `json:"version"`
 	Session Session `json:"session"`
@@ -27,15 +54,73 @@ type Session struct {
 }
 
 type Context struct {
-	System struct {
-		APIAccessToken string `json:"apiAccessToken"`
-		Device         struct {
-			DeviceID string `json:"deviceId,omitempty"`
-		} `json:"device,omitempty"`
-		Application struct {
-			ApplicationID string `json:"applicationId,omitempty"`
-		} `json:"application,omitempty"`
-	} `json:"System,omitempty"`
+	System      System       `json:"System,omitempty"`
+	AudioPlayer *AudioPlayer `json:"AudioPlayer,omitempty"`
+	Viewport    *Viewport    `json:"Viewport,omitempty"`
+}
+
+// AudioPlayer is the state of the audio stream played by the skill, if any
+type AudioPlayer struct {
+	Token                string `json:"token,omitempty"`
+	OffsetInMilliseconds int    `json:"offsetInMilliseconds,omitempty"`
+	PlayerActivity       string `json:"playerActivity,omitempty"`
+}
+
+// System describes the skill, the user and the device a request was sent from
+type System struct {
+	APIAccessToken string      `json:"apiAccessToken"`
+	APIEndpoint    string      `json:"apiEndpoint,omitempty"`
+	Application    Application `json:"application,omitempty"`
+	User           User        `json:"user,omitempty"`
+	Device         Device      `json:"device,omitempty"`
+	// Person is the recognized speaker, nil when the voice wasn't recognized
+	Person *Person `json:"person,omitempty"`
+}
+
+type Application struct {
+	ApplicationID string `json:"applicationId,omitempty"`
+}
+
+// User is the Amazon account the device is registered to
+type User struct {
+	UserID      string `json:"userId,omitempty"`
+	AccessToken string `json:"accessToken,omitempty"`
+	Permissions struct {
+		ConsentToken string `json:"consentToken,omitempty"`
+	} `json:"permissions,omitempty"`
+}
+
+type Device struct {
+	DeviceID            string                 `json:"deviceId,omitempty"`
+	SupportedInterfaces map[string]interface{} `json:"supportedInterfaces,omitempty"`
+}
+
+// Person is a member of the household recognized by their voice
+type Person struct {
+	PersonID    string `json:"personId,omitempty"`
+	AccessToken string `json:"accessToken,omitempty"`
+}
+
+// Viewport describes the screen of devices that have one
+type Viewport struct {
+	Experiences []struct {
+		ArcMinuteWidth  int  `json:"arcMinuteWidth,omitempty"`
+		ArcMinuteHeight int  `json:"arcMinuteHeight,omitempty"`
+		CanRotate       bool `json:"canRotate,omitempty"`
+		CanResize       bool `json:"canResize,omitempty"`
+	} `json:"experiences,omitempty"`
+	Mode               string   `json:"mode,omitempty"`
+	Shape              string   `json:"shape,omitempty"`
+	PixelWidth         int      `json:"pixelWidth,omitempty"`
+	PixelHeight        int      `json:"pixelHeight,omitempty"`
+	DPI                int      `json:"dpi,omitempty"`
+	CurrentPixelWidth  int      `json:"currentPixelWidth,omitempty"`
+	CurrentPixelHeight int      `json:"currentPixelHeight,omitempty"`
+	Touch              []string `json:"touch,omitempty"`
+	Keyboard           []string `json:"keyboard,omitempty"`
+	Video              struct {
+		Codecs []string `json:"codecs,omitempty"`
+	} `json:"video,omitempty"`
 }
 
 type ReqBody struct {
@@ -46,21 +131,91 @@ type ReqBody struct {
 	Intent      Intent `json:"intent,omitempty"`
 	Reason      string `json:"reason,omitempty"`
 	DialogState string `json:"dialogState,omitempty"`
+	// Token and OffsetInMilliseconds describe the stream AudioPlayer requests are about
+	Token                string `json:"token,omitempty"`
+	OffsetInMilliseconds int    `json:"offsetInMilliseconds,omitempty"`
+	// APIRequest is the api an Alexa Conversations dialog invoked, if any
+	APIRequest *APIRequest `json:"apiRequest,omitempty"`
+	// Cause is why a SessionResumedRequest resumed the session
+	Cause *ResumeCause `json:"cause,omitempty"`
+	// Message is the data a backend job posted, for a MessageReceivedRequest
+	Message map[string]interface{} `json:"message,omitempty"`
 }
 
+// Confirmation statuses of intents and slots
+const (
+	ConfirmationNone      = "NONE"
+	ConfirmationConfirmed = "CONFIRMED"
+	ConfirmationDenied    = "DENIED"
+)
+
+// Entity resolution status codes of slots
+const (
+	ResolutionMatch   = "ER_SUCCESS_MATCH"
+	ResolutionNoMatch = "ER_SUCCESS_NO_MATCH"
+)
+
 type Intent struct {
-	Name  string          `json:"name"`
-	Slots map[string]Slot `json:"slots"`
+	Name               string          `json:"name"`
+	ConfirmationStatus string          `json:"confirmationStatus,omitempty"`
+	Slots              map[string]Slot `json:"slots"`
 }
 
 type Slot struct {
-	Name        string      `json:"name"`
-	Value       string      `json:"value"`
-	Resolutions Resolutions `json:"resolutions"`
+	Name               string      `json:"name"`
+	Value              string      `json:"value"`
+	ConfirmationStatus string      `json:"confirmationStatus,omitempty"`
+	Resolutions        Resolutions `json:"resolutions"`
+}
+
+// Confirmed reports whether the user confirmed the value of the slot
+func (slot Slot) Confirmed() bool {
+	return slot.ConfirmationStatus == ConfirmationConfirmed
+}
+
+// Denied reports whether the user said the value of the slot was wrong
+func (slot Slot) Denied() bool {
+	return slot.ConfirmationStatus == ConfirmationDenied
+}
+
+// ResolutionStatus returns the entity resolution status code of the slot,
+// or an empty string if its slot type doesn't resolve values
+func (slot Slot) ResolutionStatus() string {
+	for _, authority := range slot.Resolutions.ResolutionPerAuthority {
+		return authority.Status.Code
+	}
+	return ""
+}
+
+// ResolvedID returns the id of the value the slot was resolved to,
+// or an empty string if it didn't match any value of its slot type
+func (slot Slot) ResolvedID() string {
+	for _, authority := range slot.Resolutions.ResolutionPerAuthority {
+		if authority.Status.Code == ResolutionMatch && len(authority.Values) > 0 {
+			return authority.Values[0].Value.Id
+		}
+	}
+	return ""
+}
+
+// ResolvedName returns the name of the value the slot was resolved to by the
+// custom slot type called slotType, whose authority ends with the type name,
+// or an empty string if that type didn't match the slot
+func (slot Slot) ResolvedName(slotType string) string {
+	for _, authority := range slot.Resolutions.ResolutionPerAuthority {
+		if strings.HasSuffix(authority.Authority, "."+slotType) && authority.Status.Code == ResolutionMatch && len(authority.Values) > 0 {
+			return authority.Values[0].Value.Name
+		}
+	}
+	return ""
 }
 
 type Resolutions struct {
 	ResolutionPerAuthority []struct {
+		Authority string `json:"authority"`
+		Status    struct {
+			Code string `json:"code"`
+		} `json:"status"`
 		Values []struct {
 			Value struct {
 				Name string `json:"name"`
@@ -69,3 +224,27 @@ type Resolutions struct {
 		} `json:"values"`
 	} `json:"resolutionsPerAuthority"`
 }
+
+// UserID returns the id of the account request was sent from. Skill events
+// are sent outside of a session, so they only carry it in the context
+func (request Request) UserID() string {
+	if id := request.Session.User.UserID; id != "" {
+		return id
+	}
+	return request.Context.System.User.UserID
+}
+
+// SupportsAPL reports whether the device that sent the request has a screen
+// able to render Alexa Presentation Language documents
+func (request Request) SupportsAPL() bool {
+	_, ok := request.Context.System.Device.SupportedInterfaces[APLInterface]
+	return ok
+}
+
+// RendersEmoji reports whether the device that sent the request shows emoji
+// in the text of cards. Devices without a screen leave cards to the Alexa
+// app, which does, but the screens predating APL lack the glyphs
+func (request Request) RendersEmoji() bool {
+	_, legacy := request.Context.System.Device.SupportedInterfaces[DisplayInterface]
+	return !legacy || request.SupportsAPL()
+}
@@ -1,6 +1,11 @@
 package alexa
 
-import "strings"
+import (
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"strings"
+)
 
 func NewSimpleResponse(title string, text string) Response {
 	r := Response{
@@ -10,11 +15,7 @@ func NewSimpleResponse(title string, text string) Response {
 				Type: "PlainText",
 				Text: text,
 			},
-			Card: &Payload{
-				Type:    "Simple",
-				Title:   title,
-				Content: text,
-			},
+			Card:             NewSimpleCard(title, text),
 			ShouldEndSession: true,
 		},
 	}
@@ -33,6 +34,8 @@ type ResBody struct {
 	Reprompt         *Reprompt    `json:"reprompt,omitempty"`
 	Directives       []Directives `json:"directives,omitempty"`
 	ShouldEndSession bool         `json:"shouldEndSession"`
+	// APIResponse is the result of an api invoked by an Alexa Conversations dialog
+	APIResponse interface{} `json:"apiResponse,omitempty"`
 }
 
 type Reprompt struct {
@@ -42,15 +45,68 @@ type Reprompt struct {
 type Directives struct {
 	Type          string         `json:"type,omitempty"`
 	SlotToElicit  string         `json:"slotToElicit,omitempty"`
-	UpdatedIntent *UpdatedIntent `json:"UpdatedIntent,omitempty"`
+	SlotToConfirm string         `json:"slotToConfirm,omitempty"`
+	UpdatedIntent *UpdatedIntent `json:"updatedIntent,omitempty"`
 	PlayBehavior  string         `json:"playBehavior,omitempty"`
-	AudioItem     struct {
-		Stream struct {
-			Token                string `json:"token,omitempty"`
-			URL                  string `json:"url,omitempty"`
-			OffsetInMilliseconds int    `json:"offsetInMilliseconds,omitempty"`
-		} `json:"stream,omitempty"`
-	} `json:"audioItem,omitempty"`
+	ClearBehavior string         `json:"clearBehavior,omitempty"`
+	AudioItem     *AudioItem     `json:"audioItem,omitempty"`
+	Token         string         `json:"token,omitempty"`
+	Document      interface{}    `json:"document,omitempty"`
+	Datasources   interface{}    `json:"datasources,omitempty"`
+	// Target, Period and UpdatedRequest describe a Dialog.DelegateRequest
+	Target         string            `json:"target,omitempty"`
+	Period         *DelegatePeriod   `json:"period,omitempty"`
+	UpdatedRequest *DelegatedRequest `json:"updatedRequest,omitempty"`
+	// UpdateBehavior and Types describe a Dialog.UpdateDynamicEntities
+	UpdateBehavior string       `json:"updateBehavior,omitempty"`
+	Types          []EntityType `json:"types,omitempty"`
+	// URI, Input and OnCompletion describe a Connections.StartConnection
+	URI          string      `json:"uri,omitempty"`
+	Input        interface{} `json:"input,omitempty"`
+	OnCompletion string      `json:"onCompletion,omitempty"`
+}
+
+// DelegatePeriod is how long a delegated request hands over the dialog
+type DelegatePeriod struct {
+	Until string `json:"until"`
+}
+
+// DelegatedRequest is the request the dialog is handed over with
+type DelegatedRequest struct {
+	Type   string         `json:"type"`
+	Intent *UpdatedIntent `json:"intent,omitempty"`
+}
+
+type AudioItem struct {
+	Stream struct {
+		Token                 string `json:"token,omitempty"`
+		ExpectedPreviousToken string `json:"expectedPreviousToken,omitempty"`
+		URL                   string `json:"url,omitempty"`
+		OffsetInMilliseconds  int    `json:"offsetInMilliseconds"`
+	} `json:"stream,omitempty"`
+}
+
+// NewAPLDirective returns a directive asking a screen device to render
+// the APL document using the data provided in datasources
+func NewAPLDirective(token string, document interface{}, datasources interface{}) Directives {
+	return Directives{
+		Type:        "Alexa.Presentation.APL.RenderDocument",
+		Token:       token,
+		Document:    document,
+		Datasources: datasources,
+	}
+}
+
+// NewAPLADirective returns a directive asking the device to play the
+// APL for Audio document using the data provided in datasources.
+// It replaces the output speech, which must be left empty when sending it
+func NewAPLADirective(token string, document interface{}, datasources interface{}) Directives {
+	return Directives{
+		Type:        "Alexa.Presentation.APLA.RenderDocument",
+		Token:       token,
+		Document:    document,
+		Datasources: datasources,
+	}
 }
 
 type UpdatedIntent struct {
@@ -70,10 +126,100 @@ type Payload struct {
 	Text    string `json:"text,omitempty"`
 	SSML    string `json:"ssml,omitempty"`
 	Content string `json:"content,omitempty"`
-	Image   Image  `json:"image,omitempty"`
+	Image   *Image `json:"image,omitempty"`
+	// Permissions are the scopes requested by AskForPermissionsConsent cards
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// NewSimpleCard returns a card showing title and content in the Alexa app
+func NewSimpleCard(title string, content string) *Payload {
+	return &Payload{
+		Type:    "Simple",
+		Title:   title,
+		Content: content,
+	}
+}
+
+// NewAskForPermissionsConsentCard returns a card asking the user to grant
+// the skill permissions, e.g. GivenNamePermission, in the Alexa app
+func NewAskForPermissionsConsentCard(permissions ...string) *Payload {
+	return &Payload{
+		Type:        "AskForPermissionsConsent",
+		Permissions: permissions,
+	}
+}
+
+// NewStandardCard returns a card showing title, text and an image in the Alexa app.
+// Image urls must be https and point to png or jpeg images
+func NewStandardCard(title string, text string, smallImageURL string, largeImageURL string) *Payload {
+	return &Payload{
+		Type:  "Standard",
+		Title: title,
+		Text:  text,
+		Image: &Image{
+			SmallImageURL: smallImageURL,
+			LargeImageURL: largeImageURL,
+		},
+	}
+}
+
+// WithShouldEndSession returns the response ending the session after
+// it is spoken if end is true, or keeping it open for a follow-up otherwise
+func (r Response) WithShouldEndSession(end bool) Response {
+	r.Body.ShouldEndSession = end
+	return r
+}
+
+// WithReprompt returns the response speaking text if the user doesn't
+// answer while the session is open
+func (r Response) WithReprompt(text string) Response {
+	r.Body.Reprompt = &Reprompt{
+		OutputSpeech: Payload{
+			Type: "PlainText",
+			Text: text,
+		},
+	}
+	return r
+}
+
+// WithSSMLReprompt returns the response speaking the SSML document ssml if
+// the user doesn't answer while the session is open. A malformed document
+// would be rejected by Alexa, so the reprompt is left as it was instead
+func (r Response) WithSSMLReprompt(ssml string) Response {
+	if err := ValidateSSML(ssml); err != nil {
+		slog.Error("dropping malformed ssml reprompt", "error", err)
+		return r
+	}
+	r.Body.Reprompt = &Reprompt{
+		OutputSpeech: Payload{
+			Type: "SSML",
+			SSML: ssml,
+		},
+	}
+	return r
 }
 
+// WithSessionAttributes returns the response carrying attributes,
+// which Alexa sends back with the next request of the session
+func (r Response) WithSessionAttributes(attributes map[string]interface{}) Response {
+	r.SessionAttributes = attributes
+	return r
+}
+
+// WithCard returns the response with card attached to it
+func (r Response) WithCard(card *Payload) Response {
+	r.Body.Card = card
+	return r
+}
+
+// NewSSMLResponse returns a response speaking the SSML document text.
+// A malformed document would be rejected by Alexa, so it is replaced
+// with a plain apology instead
 func NewSSMLResponse(title string, text string) Response {
+	if err := ValidateSSML(text); err != nil {
+		slog.Error("replacing malformed ssml", "error", err)
+		return NewSimpleResponse(title, "Sorry, something went wrong while preparing my answer. Please try again.")
+	}
 	r := Response{
 		Version: "1.0",
 		Body: ResBody{
@@ -87,6 +233,122 @@ func NewSSMLResponse(title string, text string) Response {
 	return r
 }
 
+// NewElicitSlotResponse keeps the session open and asks
+// the user to provide the slot again by speaking text
+func NewElicitSlotResponse(slot string, text string) Response {
+	r := Response{
+		Version: "1.0",
+		Body: ResBody{
+			OutputSpeech: &Payload{
+				Type: "PlainText",
+				Text: text,
+			},
+			Reprompt: &Reprompt{
+				OutputSpeech: Payload{
+					Type: "PlainText",
+					Text: text,
+				},
+			},
+			Directives: []Directives{
+				{
+					Type:         "Dialog.ElicitSlot",
+					SlotToElicit: slot,
+				},
+			},
+			ShouldEndSession: false,
+		},
+	}
+	return r
+}
+
+// NewElicitIntentSlotResponse keeps the session open and asks the user, by
+// speaking text, for the value of slot of intent. Unlike NewElicitSlotResponse
+// it can ask for a slot of another intent than the one of the request
+func NewElicitIntentSlotResponse(slot string, intent Intent, text string) Response {
+	r := NewElicitSlotResponse(slot, text)
+	r.Body.Directives[0].UpdatedIntent = NewUpdatedIntent(intent)
+	return r
+}
+
+// MaxSpeechLength is the longest output speech Alexa accepts, in characters
+const MaxSpeechLength = 8000
+
+// NewConfirmSlotResponse keeps the session open and asks the user,
+// by speaking text, to confirm the value heard for slot of intent
+func NewConfirmSlotResponse(slot string, intent Intent, text string) Response {
+	r := Response{
+		Version: "1.0",
+		Body: ResBody{
+			OutputSpeech: &Payload{
+				Type: "PlainText",
+				Text: text,
+			},
+			Reprompt: &Reprompt{
+				OutputSpeech: Payload{
+					Type: "PlainText",
+					Text: text,
+				},
+			},
+			Directives: []Directives{
+				{
+					Type:          "Dialog.ConfirmSlot",
+					SlotToConfirm: slot,
+					UpdatedIntent: NewUpdatedIntent(intent),
+				},
+			},
+			ShouldEndSession: false,
+		},
+	}
+	return r
+}
+
+// NewDelegateRequestResponse chains into intent by asking Alexa to send
+// the skill a new request for it, holding the slots already filled in
+func NewDelegateRequestResponse(intent Intent) Response {
+	return Response{
+		Version: "1.0",
+		Body: ResBody{
+			Directives: []Directives{
+				{
+					Type:   "Dialog.DelegateRequest",
+					Target: "skill",
+					Period: &DelegatePeriod{Until: "EXPLICIT_RETURN"},
+					UpdatedRequest: &DelegatedRequest{
+						Type:   "IntentRequest",
+						Intent: NewUpdatedIntent(intent),
+					},
+				},
+			},
+			ShouldEndSession: false,
+		},
+	}
+}
+
+// NewUpdatedIntent returns intent in the shape dialog directives expect
+func NewUpdatedIntent(intent Intent) *UpdatedIntent {
+	slots := map[string]interface{}{}
+	for key, slot := range intent.Slots {
+		slots[key] = map[string]string{
+			"name":               slot.Name,
+			"value":              slot.Value,
+			"confirmationStatus": confirmationStatus(slot.ConfirmationStatus),
+		}
+	}
+	return &UpdatedIntent{
+		Name:               intent.Name,
+		ConfirmationStatus: confirmationStatus(intent.ConfirmationStatus),
+		Slots:              slots,
+	}
+}
+
+// confirmationStatus returns status, or NONE if it wasn't sent
+func confirmationStatus(status string) string {
+	if status == "" {
+		return ConfirmationNone
+	}
+	return status
+}
+
 type SSML struct {
 	text  string
 	pause string
@@ -94,6 +356,8 @@ type SSML struct {
 
 type SSMLBuilder struct {
 	SSML []SSML
+	// voice is the Amazon Polly voice speaking the whole response, if any
+	voice string
 }
 
 func ParseString(text string) string {
@@ -113,11 +377,91 @@ func ParseString(text string) string {
 	return text
 }
 
+// xmlEscaper replaces the characters that would otherwise break the SSML document
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;", "'", "&apos;")
+
+// EscapeXML escapes text so it can be safely placed inside an SSML document
+func EscapeXML(text string) string {
+	return xmlEscaper.Replace(text)
+}
+
+// Say adds text to be spoken. Text is escaped since it may contain
+// user input, so it can't add SSML markup to the response
 func (builder *SSMLBuilder) Say(text string) {
-	text = ParseString(text)
+	text = EscapeXML(ParseString(text))
 	builder.SSML = append(builder.SSML, SSML{text: text})
 }
 
+// SayRaw adds markup to the response as it is, without escaping it.
+// It must never be given user input
+func (builder *SSMLBuilder) SayRaw(markup string) {
+	builder.SSML = append(builder.SSML, SSML{text: markup})
+}
+
+// ValidateSSML returns an error if ssml isn't a well-formed xml document
+func ValidateSSML(ssml string) error {
+	decoder := xml.NewDecoder(strings.NewReader(ssml))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Emphasis adds text spoken with the emphasis level, one of "strong", "moderate" or "reduced"
+func (builder *SSMLBuilder) Emphasis(level string, text string) {
+	builder.tag("emphasis", text, "level", level)
+}
+
+// Prosody adds text spoken with a different rate, pitch and volume,
+// e.g. "slow", "+10%" and "loud". Empty values are left as they are
+func (builder *SSMLBuilder) Prosody(rate string, pitch string, volume string, text string) {
+	builder.tag("prosody", text, "rate", rate, "pitch", pitch, "volume", volume)
+}
+
+// SayAs adds text interpreted as interpretAs, e.g. "cardinal", "ordinal" or "spell-out"
+func (builder *SSMLBuilder) SayAs(interpretAs string, text string) {
+	builder.tag("say-as", text, "interpret-as", interpretAs)
+}
+
+// Phoneme adds text pronounced as ph, written in alphabet which is either "ipa" or "x-sampa"
+func (builder *SSMLBuilder) Phoneme(alphabet string, ph string, text string) {
+	builder.tag("phoneme", text, "alphabet", alphabet, "ph", ph)
+}
+
+// Lang adds text pronounced by the voice model of locale, e.g. "fr-FR"
+func (builder *SSMLBuilder) Lang(locale string, text string) {
+	builder.tag("lang", text, "xml:lang", locale)
+}
+
+// Voice adds text spoken by the Amazon Polly voice name, e.g. "Brian"
+func (builder *SSMLBuilder) Voice(name string, text string) {
+	builder.tag("voice", text, "name", name)
+}
+
+// UseVoice makes the Amazon Polly voice name speak the whole response.
+// An empty name keeps Alexa's own voice
+func (builder *SSMLBuilder) UseVoice(name string) {
+	builder.voice = name
+}
+
+// tag adds text wrapped in an SSML element having the attributes
+// given as name and value pairs, skipping attributes without a value
+func (builder *SSMLBuilder) tag(name string, text string, attributes ...string) {
+	element := "<" + name
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if attributes[i+1] != "" {
+			element += " " + attributes[i] + "='" + EscapeXML(attributes[i+1]) + "'"
+		}
+	}
+	element += ">" + EscapeXML(ParseString(text)) + "</" + name + ">"
+	builder.SSML = append(builder.SSML, SSML{text: element})
+}
+
 func (builder *SSMLBuilder) Pause(pause string) {
 	builder.SSML = append(builder.SSML, SSML{pause: pause})
 }
@@ -131,5 +475,8 @@ func (builder *SSMLBuilder) Build() string {
 			response += "<break time='" + ssml.pause + "ms'/> "
 		}
 	}
+	if builder.voice != "" {
+		response = "<voice name='" + EscapeXML(builder.voice) + "'>" + response + "</voice>"
+	}
 	return "<speak>" + response + "</speak>"
 }
@@ -0,0 +1,26 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeXML(t *testing.T) {
+	got := EscapeXML(`Tom & "Jerry" <break/> O'Brien`)
+	want := "Tom &amp; &quot;Jerry&quot; &lt;break/&gt; O&apos;Brien"
+	if got != want {
+		t.Errorf("EscapeXML = %q, want %q", got, want)
+	}
+}
+
+func TestSayEscapesUserInput(t *testing.T) {
+	var builder SSMLBuilder
+	builder.Say("I heard <audio src='x'/>.")
+	ssml := builder.Build()
+	if strings.Contains(ssml, "<audio") {
+		t.Errorf("ssml %q carries the markup of the input", ssml)
+	}
+	if !strings.Contains(ssml, "i heard &lt;audio src=&apos;x&apos;/&gt;.") {
+		t.Errorf("ssml %q doesn't speak the escaped input", ssml)
+	}
+}
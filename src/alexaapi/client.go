@@ -0,0 +1,114 @@
+// Package alexaapi calls the Alexa service apis, such as the Customer
+// Profile and Settings APIs, on behalf of the user who sent a request
+package alexaapi
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/upstream"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrForbidden means the user hasn't granted the skill the permission the api requires
+var ErrForbidden = errors.New("alexaapi: permission not granted")
+
+// Client calls the apis with the access token Alexa sent along with a request
+type Client struct {
+	// Endpoint is the base url of the apis in the region of the user
+	Endpoint string
+	// Token is the api access token of the request
+	Token string
+	// PersonToken is the access token of the recognized speaker, if any
+	PersonToken string
+	// HTTP sends the api calls
+	HTTP *http.Client
+}
+
+// NewClient returns a client calling the apis for the user who sent request
+func NewClient(request alexa.Request) Client {
+	client := Client{
+		Endpoint: request.Context.System.APIEndpoint,
+		Token:    request.Context.System.APIAccessToken,
+		HTTP:     &http.Client{Timeout: 3 * time.Second},
+	}
+	if person := request.Context.System.Person; person != nil {
+		client.PersonToken = person.AccessToken
+	}
+	return client
+}
+
+// GivenName returns the given name of the account owner from the Customer
+// Profile API, which requires the alexa.GivenNamePermission permission
+func (client Client) GivenName(ctx context.Context) (string, error) {
+	var name string
+	err := client.get(ctx, "/v2/accounts/~current/settings/Profile.givenName", client.Token, &name)
+	return name, err
+}
+
+// PersonGivenName returns the given name of the recognized speaker, who may
+// not be the account owner, which requires the alexa.GivenNamePermission permission
+func (client Client) PersonGivenName(ctx context.Context) (string, error) {
+	var name string
+	err := client.get(ctx, "/v2/persons/~current/profile/givenName", client.PersonToken, &name)
+	return name, err
+}
+
+// get decodes the json response of the api at path, called with token, into target.
+// The call is abandoned once ctx is done
+func (client Client) get(ctx context.Context, path string, token string, target interface{}) error {
+	return client.call(ctx, http.MethodGet, path, token, nil, target)
+}
+
+// post sends body as json to the api at path, called with token, decoding
+// the json response into target unless it is nil
+func (client Client) post(ctx context.Context, path string, token string, body interface{}, target interface{}) error {
+	return client.call(ctx, http.MethodPost, path, token, body, target)
+}
+
+// call sends a request to the api at path with token and body, if any, as json.
+// The call is abandoned once ctx is done
+func (client Client) call(ctx context.Context, method string, path string, token string, body interface{}, target interface{}) error {
+	if client.Endpoint == "" || token == "" {
+		return ErrForbidden
+	}
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(client.Endpoint, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := client.HTTP.Do(req)
+	if err != nil {
+		return upstream.Transport("alexaapi", err)
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusForbidden, response.StatusCode == http.StatusUnauthorized:
+		return ErrForbidden
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return &upstream.StatusError{Service: "alexaapi", StatusCode: response.StatusCode}
+	case target == nil:
+		return nil
+	default:
+		return upstream.Malformed("alexaapi", json.NewDecoder(response.Body).Decode(target))
+	}
+}
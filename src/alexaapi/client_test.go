@@ -0,0 +1,95 @@
+package alexaapi
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serve returns a client calling a server that runs handler with token
+func serve(t *testing.T, handler http.HandlerFunc) Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return Client{Endpoint: server.URL, Token: "token", PersonToken: "person", HTTP: server.Client()}
+}
+
+func TestGivenName(t *testing.T) {
+	client := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/accounts/~current/settings/Profile.givenName" {
+			t.Errorf("called %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("authorized as %q", got)
+		}
+		w.Write([]byte(`"Hans"`))
+	})
+
+	name, err := client.GivenName(context.Background())
+	if err != nil || name != "Hans" {
+		t.Errorf("GivenName = %q, %v, want Hans", name, err)
+	}
+}
+
+func TestPersonGivenName(t *testing.T) {
+	client := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer person" {
+			t.Errorf("authorized as %q", got)
+		}
+		w.Write([]byte(`"Anna"`))
+	})
+
+	name, err := client.PersonGivenName(context.Background())
+	if err != nil || name != "Anna" {
+		t.Errorf("PersonGivenName = %q, %v, want Anna", name, err)
+	}
+}
+
+func TestGivenNameForbidden(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusUnauthorized} {
+		client := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+		if _, err := client.GivenName(context.Background()); !errors.Is(err, ErrForbidden) {
+			t.Errorf("status %d: GivenName error = %v, want ErrForbidden", status, err)
+		}
+	}
+
+	// no call is made without a token
+	client := Client{Endpoint: "http://127.0.0.1:0", HTTP: http.DefaultClient}
+	if _, err := client.PersonGivenName(context.Background()); !errors.Is(err, ErrForbidden) {
+		t.Errorf("PersonGivenName error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestGivenNameStatusErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError} {
+		client := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+
+		_, err := client.GivenName(context.Background())
+		var statusErr *upstream.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status || statusErr.Service != "alexaapi" {
+			t.Errorf("GivenName error = %v, want a %d StatusError from alexaapi", err, status)
+			continue
+		}
+		if statusErr.Throttled() != (status == http.StatusTooManyRequests) {
+			t.Errorf("status %d: throttled %v", status, statusErr.Throttled())
+		}
+	}
+}
+
+func TestGivenNameMalformed(t *testing.T) {
+	client := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"givenName":`))
+	})
+
+	_, err := client.GivenName(context.Background())
+	var malformed *upstream.MalformedError
+	if !errors.As(err, &malformed) || malformed.Service != "alexaapi" {
+		t.Errorf("GivenName error = %v, want a MalformedError from alexaapi", err)
+	}
+}
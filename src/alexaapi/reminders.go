@@ -0,0 +1,62 @@
+package alexaapi
+
+import (
+	"context"
+	"time"
+)
+
+// RemindersPermission is the scope allowing the skill to set reminders for the user
+const RemindersPermission = "alexa::alerts:reminders:skill:readwrite"
+
+// Reminder is a reminder spoken at a set time
+type Reminder struct {
+	// ScheduledTime is when the reminder is spoken, without a time zone,
+	// e.g. "2026-10-20T09:00:00"
+	ScheduledTime string
+	// TimeZoneID is the time zone of ScheduledTime, e.g. "Europe/Berlin",
+	// the time zone of the device when empty
+	TimeZoneID string
+	Locale     string
+	Text       string
+}
+
+// reminderRequest is the body of a request to the Reminders API
+type reminderRequest struct {
+	RequestTime string `json:"requestTime"`
+	Trigger     struct {
+		Type          string `json:"type"`
+		ScheduledTime string `json:"scheduledTime"`
+		TimeZoneID    string `json:"timeZoneId,omitempty"`
+	} `json:"trigger"`
+	AlertInfo struct {
+		SpokenInfo struct {
+			Content []reminderContent `json:"content"`
+		} `json:"spokenInfo"`
+	} `json:"alertInfo"`
+	PushNotification struct {
+		Status string `json:"status"`
+	} `json:"pushNotification"`
+}
+
+type reminderContent struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// CreateReminder sets reminder for the user through the Reminders API,
+// which requires the RemindersPermission permission, returning its id
+func (client Client) CreateReminder(ctx context.Context, reminder Reminder) (string, error) {
+	var body reminderRequest
+	body.RequestTime = time.Now().UTC().Format("2006-01-02T15:04:05.000")
+	body.Trigger.Type = "SCHEDULED_ABSOLUTE"
+	body.Trigger.ScheduledTime = reminder.ScheduledTime
+	body.Trigger.TimeZoneID = reminder.TimeZoneID
+	body.AlertInfo.SpokenInfo.Content = []reminderContent{{Locale: reminder.Locale, Text: reminder.Text}}
+	body.PushNotification.Status = "ENABLED"
+
+	var created struct {
+		AlertToken string `json:"alertToken"`
+	}
+	err := client.post(ctx, "/v1/alerts/reminders", client.Token, body, &created)
+	return created.AlertToken, err
+}
@@ -0,0 +1,31 @@
+package alexaapi
+
+import (
+	"context"
+	"net/url"
+)
+
+// CountryPermission is the scope allowing the skill to read the country the device is in
+const CountryPermission = "read::alexa:device:all:address:country_and_postal_code"
+
+// Address is the coarse location of a device
+type Address struct {
+	CountryCode string `json:"countryCode"`
+	PostalCode  string `json:"postalCode"`
+}
+
+// DeviceLocales returns the locales the device speaks, the first one being
+// its primary locale. Devices in multilingual mode return more than one
+func (client Client) DeviceLocales(ctx context.Context, deviceID string) ([]string, error) {
+	var locales []string
+	err := client.get(ctx, "/v2/devices/"+url.PathEscape(deviceID)+"/settings/System.locales", client.Token, &locales)
+	return locales, err
+}
+
+// DeviceCountry returns the alpha-2 code of the country the device is
+// registered in, which requires the CountryPermission permission
+func (client Client) DeviceCountry(ctx context.Context, deviceID string) (string, error) {
+	var address Address
+	err := client.get(ctx, "/v1/devices/"+url.PathEscape(deviceID)+"/settings/address/countryAndPostalCode", client.Token, &address)
+	return address.CountryCode, err
+}
@@ -0,0 +1,249 @@
+// Package analytics sends an anonymized event about every request to a
+// Kinesis Firehose stream, so product usage can be analyzed without scraping
+// the logs. Events are batched and sent in the background, so they never
+// slow down or fail a response, and names and user ids are only sent hashed
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// Outcomes of a request other than the kinds of failure it was answered with
+const (
+	// OK means the request was answered as asked
+	OK = "ok"
+	// Rejected means the request wasn't answered at all, e.g. for an unknown skill id
+	Rejected = "rejected"
+)
+
+// maxBatch is the number of records Firehose accepts in one batch
+const maxBatch = 500
+
+// Event is what is sent about one request
+type Event struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Intent string    `json:"intent,omitempty"`
+	Locale string    `json:"locale,omitempty"`
+	// User is the id of the account, hashed by Emit
+	User string `json:"user,omitempty"`
+	// Name is the name asked about, if any, hashed by Emit
+	Name string `json:"name,omitempty"`
+	// TopCountry is the alpha-2 code of the most likely country guessed, if any
+	TopCountry string `json:"topCountry,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	// Outcome is OK, Rejected or the kind of failure the user was told about
+	Outcome string `json:"outcome"`
+}
+
+// Putter is the part of the Firehose client sending batches of records
+type Putter interface {
+	PutRecordBatch(ctx context.Context, input *firehose.PutRecordBatchInput, options ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error)
+}
+
+// Emitter sends events to a Firehose stream in the background.
+// A nil emitter drops every event, which is how analytics are switched off
+type Emitter struct {
+	client   Putter
+	stream   string
+	key      []byte
+	interval time.Duration
+
+	events  chan Event
+	flushes chan chan struct{}
+	done    chan struct{}
+	closed  sync.Once
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// New returns an emitter sending events to stream through client at least
+// every interval, with names and user ids hashed using key. At most buffer
+// events wait to be sent, more are dropped
+func New(client Putter, stream string, key []byte, interval time.Duration, buffer int) *Emitter {
+	emitter := &Emitter{
+		client:   client,
+		stream:   stream,
+		key:      key,
+		interval: interval,
+		events:   make(chan Event, buffer),
+		flushes:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go emitter.run()
+	return emitter
+}
+
+// Hash returns the keyed hash standing in for value in the events,
+// the same for every spelling case so the same name is counted once.
+// Unlike a plain hash, it can't be reversed by hashing a list of common names
+func (emitter *Emitter) Hash(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, emitter.key)
+	mac.Write([]byte(strings.ToLower(value)))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// Emit queues event to be sent with its user and name hashed, dropping
+// it when the queue is full or the emitter is closed. It never blocks
+func (emitter *Emitter) Emit(event Event) {
+	if emitter == nil {
+		return
+	}
+	event.User, event.Name = emitter.Hash(event.User), emitter.Hash(event.Name)
+	select {
+	case <-emitter.done:
+		emitter.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case emitter.events <- event:
+	default:
+		emitter.dropped.Add(1)
+	}
+}
+
+// Flush sends the queued events right away, returning once they were sent or ctx is done
+func (emitter *Emitter) Flush(ctx context.Context) {
+	if emitter == nil {
+		return
+	}
+	flushed := make(chan struct{})
+	select {
+	case emitter.flushes <- flushed:
+	case <-emitter.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+	}
+}
+
+// Close sends the queued events and stops the emitter, later events are dropped
+func (emitter *Emitter) Close(ctx context.Context) {
+	if emitter == nil {
+		return
+	}
+	emitter.Flush(ctx)
+	emitter.closed.Do(func() { close(emitter.done) })
+}
+
+// Stats returns how many events were dropped because the queue was full
+// and how many Firehose failed to store
+func (emitter *Emitter) Stats() (dropped int64, failed int64) {
+	if emitter == nil {
+		return 0, 0
+	}
+	return emitter.dropped.Load(), emitter.failed.Load()
+}
+
+// run sends the queued events every interval, or as soon as a batch is full
+func (emitter *Emitter) run() {
+	ticker := time.NewTicker(emitter.interval)
+	defer ticker.Stop()
+
+	var batch []Event
+	for {
+		select {
+		case event := <-emitter.events:
+			batch = append(batch, event)
+			if len(batch) >= maxBatch {
+				emitter.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			emitter.send(batch)
+			batch = nil
+		case flushed := <-emitter.flushes:
+			batch = emitter.drain(batch)
+			emitter.send(batch)
+			batch = nil
+			close(flushed)
+		case <-emitter.done:
+			return
+		}
+	}
+}
+
+// drain appends the events waiting in the queue to batch
+func (emitter *Emitter) drain(batch []Event) []Event {
+	for {
+		select {
+		case event := <-emitter.events:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+}
+
+// send puts batch to the stream as json lines, maxBatch records at a time.
+// Records Firehose fails to store are counted and not retried, losing a few
+// events is better than piling them up while the stream is throttled
+func (emitter *Emitter) send(batch []Event) {
+	for start := 0; start < len(batch); start += maxBatch {
+		end := start + maxBatch
+		if end > len(batch) {
+			end = len(batch)
+		}
+		var records []types.Record
+		for _, event := range batch[start:end] {
+			data, err := json.Marshal(event)
+			if err != nil {
+				emitter.failed.Add(1)
+				continue
+			}
+			records = append(records, types.Record{Data: append(data, '\n')})
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), emitter.interval+5*time.Second)
+		output, err := emitter.client.PutRecordBatch(ctx, &firehose.PutRecordBatchInput{
+			DeliveryStreamName: aws.String(emitter.stream),
+			Records:            records,
+		})
+		cancel()
+		switch {
+		case err != nil:
+			emitter.failed.Add(int64(len(records)))
+		case output.FailedPutCount != nil:
+			emitter.failed.Add(int64(*output.FailedPutCount))
+		}
+	}
+}
+
+// contextKey is the key the event of a request is stored under in its context
+type contextKey struct{}
+
+// WithEvent returns ctx carrying event, which handlers fill in through Annotate
+func WithEvent(ctx context.Context, event *Event) context.Context {
+	return context.WithValue(ctx, contextKey{}, event)
+}
+
+// Annotate lets update fill in the event of the request ctx belongs to,
+// e.g. with the name asked about. It does nothing outside of a request
+func Annotate(ctx context.Context, update func(event *Event)) {
+	if event, ok := ctx.Value(contextKey{}).(*Event); ok {
+		update(event)
+	}
+}
@@ -0,0 +1,72 @@
+// Package apl holds the Alexa Presentation Language documents rendered
+// on devices with screens, picks the layout fitting each screen and holds
+// the APL for Audio documents mixing speech with music and sound effects
+package apl
+
+import (
+	"alexa-skill-test/src/alexa"
+	_ "embed"
+	"encoding/json"
+)
+
+//go:embed guess.json
+var guessDocument []byte
+
+// GuessDocument lists the guessed countries with their flags, bound
+// from the "guesses" datasource. It is sent as is, so the template in
+// guess.json can be edited and previewed in the authoring tool directly
+var GuessDocument = json.RawMessage(guessDocument)
+
+//go:embed guess_audio.json
+var guessAudioDocument []byte
+
+// GuessAudioDocument is the APL for Audio document speaking the guesses bound
+// from the "guess" datasource, mixed with a sound effect and background music
+var GuessAudioDocument = json.RawMessage(guessAudioDocument)
+
+// Profile is the kind of screen a document is laid out for
+type Profile string
+
+// Profiles of the screens the documents adapt to
+const (
+	// ProfileRound is the round screen of the Echo Spot, only fitting the top guess
+	ProfileRound Profile = "round"
+	// ProfileSmall is a rectangular screen narrower than 960 pixels, e.g. the Echo Show 5
+	ProfileSmall Profile = "small"
+	// ProfileLarge is a rectangular screen such as the Echo Show 8 or 10
+	ProfileLarge Profile = "large"
+	// ProfileTV is a television watched from further away, e.g. a Fire TV
+	ProfileTV Profile = "tv"
+)
+
+// smallWidth is the pixel width under which a screen is considered small
+const smallWidth = 960
+
+// ProfileOf returns the profile of the screen described by viewport,
+// falling back to ProfileLarge when the device did not describe it
+func ProfileOf(viewport *alexa.Viewport) Profile {
+	switch {
+	case viewport == nil:
+		return ProfileLarge
+	case viewport.Shape == "ROUND":
+		return ProfileRound
+	case viewport.Mode == "TV":
+		return ProfileTV
+	case viewport.PixelWidth > 0 && viewport.PixelWidth < smallWidth:
+		return ProfileSmall
+	default:
+		return ProfileLarge
+	}
+}
+
+// FlagWidth returns the width in pixels of the flag images shown on the profile
+func (profile Profile) FlagWidth() int {
+	switch profile {
+	case ProfileSmall:
+		return 160
+	case ProfileRound, ProfileTV:
+		return 640
+	default:
+		return 320
+	}
+}
@@ -0,0 +1,47 @@
+package apl
+
+import (
+	"alexa-skill-test/src/alexa"
+	"encoding/json"
+	"testing"
+)
+
+func TestProfileOf(t *testing.T) {
+	tests := []struct {
+		viewport *alexa.Viewport
+		want     Profile
+	}{
+		{nil, ProfileLarge},
+		{&alexa.Viewport{Shape: "ROUND", PixelWidth: 480}, ProfileRound},
+		{&alexa.Viewport{Shape: "RECTANGLE", Mode: "TV", PixelWidth: 1920}, ProfileTV},
+		{&alexa.Viewport{Shape: "RECTANGLE", PixelWidth: 960}, ProfileLarge},
+		{&alexa.Viewport{Shape: "RECTANGLE", PixelWidth: 959}, ProfileSmall},
+		{&alexa.Viewport{Shape: "RECTANGLE"}, ProfileLarge},
+	}
+	for _, test := range tests {
+		if got := ProfileOf(test.viewport); got != test.want {
+			t.Errorf("ProfileOf(%+v) = %s, want %s", test.viewport, got, test.want)
+		}
+	}
+}
+
+func TestFlagWidth(t *testing.T) {
+	for profile, want := range map[Profile]int{ProfileSmall: 160, ProfileLarge: 320, ProfileRound: 640, ProfileTV: 640} {
+		if got := profile.FlagWidth(); got != want {
+			t.Errorf("%s.FlagWidth() = %d, want %d", profile, got, want)
+		}
+	}
+}
+
+func TestDocumentsAreJSON(t *testing.T) {
+	for name, document := range map[string]json.RawMessage{"guess.json": GuessDocument, "guess_audio.json": GuessAudioDocument} {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(document, &decoded); err != nil {
+			t.Errorf("%s isn't json: %v", name, err)
+			continue
+		}
+		if decoded["type"] == nil || decoded["mainTemplate"] == nil {
+			t.Errorf("%s lacks the type or main template of a document", name)
+		}
+	}
+}
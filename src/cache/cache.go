@@ -0,0 +1,78 @@
+// Package cache keeps the responses of the upstream apis for a while,
+// so names asked about repeatedly are only looked up once
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache maps keys, such as request urls, to the responses cached under them
+// until they expire. It is safe for concurrent use
+type Cache struct {
+	mutex   sync.RWMutex
+	ttl     time.Duration
+	entries map[string]entry
+	// hits and misses count the lookups answered from the cache and the others
+	hits, misses atomic.Int64
+	// Now returns the current time, it can be replaced to control expiry
+	Now func() time.Time
+}
+
+// entry is a cached response along with when it expires
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// New returns an empty cache keeping responses for ttl.
+// A cache with a ttl of zero or less keeps nothing
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: map[string]entry{}, Now: time.Now}
+}
+
+// Get returns the response cached under key, if it hasn't expired
+func (cache *Cache) Get(key string) ([]byte, bool) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	cached, ok := cache.entries[key]
+	if !ok || !cache.Now().Before(cached.expires) {
+		cache.misses.Add(1)
+		return nil, false
+	}
+	cache.hits.Add(1)
+	return cached.value, true
+}
+
+// Stats returns how many lookups were answered from the cache and how many weren't
+func (cache *Cache) Stats() (hits int64, misses int64) {
+	return cache.hits.Load(), cache.misses.Load()
+}
+
+// Clear drops every cached response
+func (cache *Cache) Clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = map[string]entry{}
+}
+
+// Put caches value under key, replacing any response cached under it.
+// Expired responses are dropped at the same time so the cache doesn't grow forever
+func (cache *Cache) Put(key string, value []byte) {
+	if cache.ttl <= 0 {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := cache.Now()
+	for k, cached := range cache.entries {
+		if !now.Before(cached.expires) {
+			delete(cache.entries, k)
+		}
+	}
+	cache.entries[key] = entry{value: value, expires: now.Add(cache.ttl)}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetPut(t *testing.T) {
+	cache := New(time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("empty cache answered a lookup")
+	}
+	cache.Put("a", []byte("1"))
+	if value, ok := cache.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("Get(a) = %q, %v, want 1", value, ok)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats() = %d, %d, want 1, 1", hits, misses)
+	}
+	cache.Clear()
+	if _, ok := cache.Get("a"); ok {
+		t.Error("cleared cache answered a lookup")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(time.Minute)
+	cache.Now = func() time.Time { return now }
+	cache.Put("a", []byte("1"))
+
+	now = now.Add(59 * time.Second)
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("response expired before its ttl")
+	}
+	now = now.Add(time.Second)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("response outlived its ttl")
+	}
+
+	// expired responses are dropped on the next Put
+	cache.Put("b", []byte("2"))
+	if len(cache.entries) != 1 {
+		t.Errorf("cache holds %d entries, want 1", len(cache.entries))
+	}
+}
+
+func TestZeroTTLKeepsNothing(t *testing.T) {
+	cache := New(0)
+	cache.Put("a", []byte("1"))
+	if _, ok := cache.Get("a"); ok {
+		t.Error("cache without ttl answered a lookup")
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	cache := New(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := strconv.Itoa(j % 10)
+				switch {
+				case i%8 == 0 && j == 50:
+					cache.Clear()
+				case i%2 == 0:
+					cache.Put(key, []byte(key))
+				default:
+					if value, ok := cache.Get(key); ok && string(value) != key {
+						t.Errorf("Get(%s) = %q", key, value)
+					}
+					cache.Stats()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	hits, misses := cache.Stats()
+	if hits+misses != 16*100 {
+		t.Errorf("counted %d lookups, want %d", hits+misses, 16*100)
+	}
+}
+
+func TestResponseFetchedWhileOthersLookUp(t *testing.T) {
+	cache := New(time.Minute)
+	// the lookups keep missing while the response is fetched,
+	// then all of them share the response it cached
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(5 * time.Second)
+			for time.Now().Before(deadline) {
+				if value, ok := cache.Get("url"); ok {
+					if string(value) != "body" {
+						t.Errorf("Get(url) = %q, want the fetched body", value)
+					}
+					return
+				}
+			}
+			t.Error("the fetched response was never shared")
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	cache.Put("url", []byte("body"))
+	wg.Wait()
+}
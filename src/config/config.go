@@ -0,0 +1,340 @@
+// Package config reads the runtime settings of the skill from
+// environment variables, which is how lambda passes configuration
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every setting an operator can change without a redeploy
+type Config struct {
+	// ConfirmName makes the skill say the name it heard before guessing
+	ConfirmName bool
+	// BlockedNames extends the embedded list of names the skill refuses to guess
+	BlockedNames []string
+	// NationalizeURL is the endpoint queried for nationality guesses
+	NationalizeURL string
+	// NationalizeVintage is when the records of nationalize.io were collected, which
+	// its api doesn't report, e.g. "2022". WhyIntent leaves it out when empty
+	NationalizeVintage string
+	// NationalityProvider guesses nationalities, either "nationalize", "namsor", which needs NamSorAPIKey,
+	// or "offline", which only knows common names
+	NationalityProvider string
+	// NationalityFallback is the provider tried when NationalityProvider fails, none when empty
+	NationalityFallback string
+	// EnsembleProviders are the providers whose predictions are averaged instead of asking
+	// NationalityProvider alone, as "name" or "name:weight" such as "nationalize:2,namsor:1"
+	EnsembleProviders []string
+	// EnsembleNote tells users when their guess combines several providers
+	EnsembleNote bool
+	// MetricsNamespace is the CloudWatch namespace the metrics of the skill are published under
+	MetricsNamespace string
+	// OfflineFallback guesses from an embedded dataset of common names when every provider failed
+	OfflineFallback bool
+	// CountriesURL is the endpoint queried for information about countries
+	CountriesURL string
+	// CognitoURL is the endpoint of the Cognito user pool accounts are linked to
+	CognitoURL string
+	// AgifyURL is the endpoint queried for age guesses
+	AgifyURL string
+	// GenderizeURL is the endpoint queried for gender guesses
+	GenderizeURL string
+	// LowCountThreshold is the number of records under which a guess is considered unreliable
+	LowCountThreshold int
+	// HTTPAddr makes the skill run as an http server listening on it instead of a lambda function
+	HTTPAddr string
+	// ShutdownTimeout is how long the http server waits for in-flight requests when stopping
+	ShutdownTimeout time.Duration
+	// DominanceThreshold is the probability above which only the top guess is spoken
+	DominanceThreshold float64
+	// MaxGuesses is the number of guesses spoken when no guess dominates
+	MaxGuesses int
+	// Percentages is how the spoken percentages account for the countries left unspoken, either "raw",
+	// as the provider answered them, "normalized", scaled to sum to 100, or "remainder", which
+	// mentions the share left to the other countries after the guesses
+	Percentages string
+	// SurpriseSeed seeds the random choice of names to surprise users with, zero seeds it from the clock
+	SurpriseSeed int64
+	// DisableCountryAPI skips the countries api, relying only on the embedded countries
+	DisableCountryAPI bool
+	// DemonymOverridesFile is the path of a json file mapping country codes to demonyms to speak instead
+	DemonymOverridesFile string
+	// PIILogging controls how names are logged, either "hash", "none" or "full"
+	PIILogging string
+	// LogLevel is the least severe level logged, either "debug", "info", "warn" or "error"
+	LogLevel string
+	// TemplateVariant forces every user onto one phrasing variant instead of bucketing them
+	TemplateVariant string
+	// PollyVoice is the Amazon Polly voice speaking guesses instead of Alexa's own, e.g. "Matthew"
+	PollyVoice string
+	// ConfirmUnusualNames makes the skill confirm names that may have been misheard before guessing
+	ConfirmUnusualNames bool
+	// VerifyRequests makes the http server check that requests were signed by Alexa
+	VerifyRequests bool
+	// BackgroundAudioURL is the https url of music played quietly under the spoken guesses
+	BackgroundAudioURL string
+	// GuessSoundURL is the https url of a sound effect played before the guesses are spoken
+	GuessSoundURL string
+	// SkillIDs are the ids of the skills allowed to send requests, any skill is allowed when empty
+	SkillIDs []string
+	// DebugUserIDs are the ids of the developer accounts allowed to use DebugIntent
+	DebugUserIDs []string
+	// DeviceCountryBias multiplies the probability of the country the device is in, 1 disables it
+	DeviceCountryBias float64
+	// LocalePriorWeight is the share of the prior put on the country of the request locale when
+	// the device country is unknown, the rest being spread over the guessed countries, 0 disables it
+	LocalePriorWeight float64
+	// AnthemURL is the https url of the national anthems, where "{code}" is replaced by the alpha-2 code of a country
+	AnthemURL string
+	// PrintURL is the public https url of the /print route of the http server,
+	// which printer skills fetch the printed result from. Printing is off when empty
+	PrintURL string
+	// MessagingKey is the key backend jobs sign the messages they post to the
+	// skill with. Messages are refused when empty
+	MessagingKey string
+	// MessagingMaxAge is how long after being signed a message is still accepted
+	MessagingMaxAge time.Duration
+	// UpstreamTimeout bounds every request sent to the upstream apis
+	UpstreamTimeout time.Duration
+	// ResponseTimeout bounds the handling of a whole request, which Alexa gives up on after 8 seconds
+	ResponseTimeout time.Duration
+	// DeadlineMargin is the time kept before the lambda deadline to send the response
+	DeadlineMargin time.Duration
+	// RetryAttempts is how many times a failing upstream GET is sent at most, one disables retries
+	RetryAttempts int
+	// RetryBackoff is the longest wait before the first retry, doubled for every following one
+	RetryBackoff time.Duration
+	// RetryMaxBackoff caps the wait between two retries
+	RetryMaxBackoff time.Duration
+	// RetryAttemptTimeout bounds every attempt, so a hung one leaves time to retry
+	RetryAttemptTimeout time.Duration
+	// FixturesDir makes the upstream responses be recorded to or replayed from it, see FixturesMode
+	FixturesDir string
+	// FixturesMode is either "record" or "replay", which sends nothing upstream
+	FixturesMode string
+	// LookupCacheTTL is how long the responses of the upstream apis are cached, zero disables the cache
+	LookupCacheTTL time.Duration
+	// SurnameURL is the NamSor origin endpoint queried for the origin of surnames
+	SurnameURL string
+	// NamSorAPIKey authenticates requests to NamSor, surnames can't be looked up without it
+	NamSorAPIKey string
+	// MeaningURL is the etymology endpoint queried for the meaning of names,
+	// answering {"name", "origin", "meaning"} for the name and lang query
+	// parameters. The embedded meanings are used alone when empty
+	MeaningURL string
+	// QuizRounds is the number of questions asked in a quiz
+	QuizRounds int
+	// LeaderboardTable is the DynamoDB table keeping the quiz scores, the leaderboard is disabled when empty
+	LeaderboardTable string
+	// HistoryTable is the DynamoDB table keeping the guesses of every user, history only lasts a session when empty
+	HistoryTable string
+	// ConfidencePhrasing is how likely guesses are spoken to users who didn't choose, either "percent" or "words"
+	ConfidencePhrasing string
+	// ConfidenceBands are the probabilities from which a guess is spoken as very likely, likely
+	// and possibly when phrased in words, anything less likely is a long shot
+	ConfidenceBands []float64
+	// PreferencesTable is the DynamoDB table keeping the preferences of every user, they only last a session when empty
+	PreferencesTable string
+	// FriendsTable is the DynamoDB table keeping the friends of every user, they only last a session when empty
+	FriendsTable string
+	// PersistenceBackend is where the history, preferences and friends of every user are kept, either
+	// "tables", in HistoryTable, PreferencesTable and FriendsTable, or "dynamodb" or "s3", together
+	// in the persistent attributes of each user kept in PersistenceTable or PersistenceBucket
+	PersistenceBackend string
+	// PersistenceTable is the DynamoDB table of the persistent attributes, with the partition key "id" and the index erase.AccountIndex
+	PersistenceTable string
+	// PersistenceBucket is the S3 bucket of the persistent attributes
+	PersistenceBucket string
+	// PersistencePrefix is the path the persistent attributes are kept under in PersistenceBucket
+	PersistencePrefix string
+	// AnalyticsStream is the Firehose delivery stream receiving an event about every request, none are sent when empty
+	AnalyticsStream string
+	// AnalyticsEnabled switches the analytics events off without unsetting the stream when false
+	AnalyticsEnabled bool
+	// AnalyticsHashKey keys the hashes of the names and user ids in the events, none are sent without it
+	AnalyticsHashKey string
+	// AnalyticsInterval is how often the queued events are sent
+	AnalyticsInterval time.Duration
+	// AlertTopicARN is the SNS topic notified when a nationality provider keeps failing, no alert is sent when empty
+	AlertTopicARN string
+	// AlertFailureRate is the recent failure rate from which a provider counts as failing
+	AlertFailureRate float64
+	// AlertAfter is how long a provider fails before an alert is sent
+	AlertAfter time.Duration
+	// AlertRepeat is how often the alert is sent again while the provider keeps failing
+	AlertRepeat time.Duration
+	// AlertTimeout bounds publishing an alert, which happens apart from the requests
+	AlertTimeout time.Duration
+	// Flags toggles features as entries such as "quiz=off" or "ensemble=25%", see flags.Parse
+	Flags []string
+	// FlagsURL is the AppConfig extension url of the feature flag profile overriding Flags, unused when empty
+	FlagsURL string
+	// FlagsRefresh is how often the feature flag profile is fetched again
+	FlagsRefresh time.Duration
+}
+
+// Load reads the configuration from the environment,
+// falling back to defaults for unset or malformed values
+func Load() Config {
+	return Config{
+		ConfirmName:    boolEnv("CONFIRM_NAME", false),
+		BlockedNames:   listEnv("BLOCKED_NAMES"),
+		NationalizeURL: stringEnv("NATIONALIZE_URL", "https://api.nationalize.io"),
+		CountriesURL:   stringEnv("COUNTRIES_URL", "https://restcountries.eu/rest/v2/alpha"),
+		CognitoURL:     stringEnv("COGNITO_URL", "https://cognito-idp.us-east-2.amazonaws.com/"),
+		AgifyURL:       stringEnv("AGIFY_URL", "https://api.agify.io"),
+		GenderizeURL:   stringEnv("GENDERIZE_URL", "https://api.genderize.io"),
+		HTTPAddr:       stringEnv("HTTP_ADDR", ""),
+
+		NationalityProvider: stringEnv("NATIONALITY_PROVIDER", "nationalize"),
+		NationalityFallback: stringEnv("NATIONALITY_FALLBACK", ""),
+		NationalizeVintage:  stringEnv("NATIONALIZE_VINTAGE", ""),
+		OfflineFallback:     boolEnv("OFFLINE_FALLBACK", true),
+		EnsembleProviders:   listEnv("ENSEMBLE_PROVIDERS"),
+		EnsembleNote:        boolEnv("ENSEMBLE_NOTE", true),
+		MetricsNamespace:    stringEnv("METRICS_NAMESPACE", "NationalityGuesser"),
+
+		LowCountThreshold: intEnv("LOW_COUNT_THRESHOLD", 100),
+		ShutdownTimeout:   durationEnv("SHUTDOWN_TIMEOUT", 10*time.Second),
+
+		DominanceThreshold: floatEnv("DOMINANCE_THRESHOLD", 0.8),
+		MaxGuesses:         intEnv("MAX_GUESSES", 5),
+		Percentages:        stringEnv("PERCENTAGES", "raw"),
+		SurpriseSeed:       int64(intEnv("SURPRISE_SEED", 0)),
+		DisableCountryAPI:  boolEnv("DISABLE_COUNTRY_API", false),
+
+		DemonymOverridesFile: stringEnv("DEMONYM_OVERRIDES_FILE", ""),
+		PIILogging:           stringEnv("PII_LOGGING", "hash"),
+		LogLevel:             stringEnv("LOG_LEVEL", "info"),
+		TemplateVariant:      stringEnv("TEMPLATE_VARIANT", ""),
+		PollyVoice:           stringEnv("POLLY_VOICE", ""),
+		ConfirmUnusualNames:  boolEnv("CONFIRM_UNUSUAL_NAMES", true),
+		VerifyRequests:       boolEnv("VERIFY_REQUESTS", true),
+		BackgroundAudioURL:   stringEnv("BACKGROUND_AUDIO_URL", ""),
+		GuessSoundURL:        stringEnv("GUESS_SOUND_URL", ""),
+		SkillIDs:             listEnv("SKILL_IDS"),
+		DebugUserIDs:         listEnv("DEBUG_USER_IDS"),
+		DeviceCountryBias:    floatEnv("DEVICE_COUNTRY_BIAS", 1.5),
+		LocalePriorWeight:    floatEnv("LOCALE_PRIOR_WEIGHT", 0.1),
+		AnthemURL:            stringEnv("ANTHEM_URL", ""),
+		PrintURL:             stringEnv("PRINT_URL", ""),
+		MessagingKey:         stringEnv("MESSAGING_KEY", ""),
+		MessagingMaxAge:      durationEnv("MESSAGING_MAX_AGE", 5*time.Minute),
+		UpstreamTimeout:      durationEnv("UPSTREAM_TIMEOUT", 5*time.Second),
+		ResponseTimeout:      durationEnv("RESPONSE_TIMEOUT", 7*time.Second),
+		DeadlineMargin:       durationEnv("DEADLINE_MARGIN", 250*time.Millisecond),
+		RetryAttempts:        intEnv("RETRY_ATTEMPTS", 3),
+		RetryBackoff:         durationEnv("RETRY_BACKOFF", 100*time.Millisecond),
+		RetryMaxBackoff:      durationEnv("RETRY_MAX_BACKOFF", time.Second),
+		RetryAttemptTimeout:  durationEnv("RETRY_ATTEMPT_TIMEOUT", 2*time.Second),
+		FixturesDir:          stringEnv("UPSTREAM_FIXTURES", ""),
+		FixturesMode:         stringEnv("UPSTREAM_FIXTURES_MODE", "replay"),
+		LookupCacheTTL:       durationEnv("LOOKUP_CACHE_TTL", time.Hour),
+		SurnameURL:           stringEnv("SURNAME_URL", "https://v2.namsor.com/NamSorAPIv2/api2/json/origin"),
+		NamSorAPIKey:         stringEnv("NAMSOR_API_KEY", ""),
+		MeaningURL:           stringEnv("MEANING_URL", ""),
+		QuizRounds:           intEnv("QUIZ_ROUNDS", 5),
+		LeaderboardTable:     stringEnv("LEADERBOARD_TABLE", ""),
+		HistoryTable:         stringEnv("HISTORY_TABLE", ""),
+		ConfidencePhrasing:   stringEnv("CONFIDENCE_PHRASING", "percent"),
+		ConfidenceBands:      floatListEnv("CONFIDENCE_BANDS", []float64{0.5, 0.2, 0.05}),
+		PreferencesTable:     stringEnv("PREFERENCES_TABLE", ""),
+		FriendsTable:         stringEnv("FRIENDS_TABLE", ""),
+		PersistenceBackend:   stringEnv("PERSISTENCE_BACKEND", "tables"),
+		PersistenceTable:     stringEnv("PERSISTENCE_TABLE", ""),
+		PersistenceBucket:    stringEnv("PERSISTENCE_BUCKET", ""),
+		PersistencePrefix:    stringEnv("PERSISTENCE_PREFIX", ""),
+		AnalyticsStream:      stringEnv("ANALYTICS_STREAM", ""),
+		AnalyticsEnabled:     boolEnv("ANALYTICS_ENABLED", true),
+		AnalyticsHashKey:     stringEnv("ANALYTICS_HASH_KEY", ""),
+		AnalyticsInterval:    durationEnv("ANALYTICS_INTERVAL", time.Second),
+		AlertTopicARN:        stringEnv("ALERT_TOPIC_ARN", ""),
+		AlertFailureRate:     floatEnv("ALERT_FAILURE_RATE", 0.8),
+		AlertAfter:           durationEnv("ALERT_AFTER", 5*time.Minute),
+		AlertRepeat:          durationEnv("ALERT_REPEAT", time.Hour),
+		AlertTimeout:         durationEnv("ALERT_TIMEOUT", 2*time.Second),
+		Flags:                listEnv("FLAGS"),
+		FlagsURL:             stringEnv("FLAGS_URL", ""),
+		FlagsRefresh:         durationEnv("FLAGS_REFRESH", 45*time.Second),
+	}
+}
+
+// stringEnv returns the value of the environment variable key or fallback if it is unset
+func stringEnv(key string, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// boolEnv returns the boolean value of the environment variable key
+// or fallback if it is unset or not a valid boolean
+func boolEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// intEnv returns the integer value of the environment variable key
+// or fallback if it is unset or not a valid integer
+func intEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// floatEnv returns the decimal value of the environment variable key
+// or fallback if it is unset or not a valid number
+func floatEnv(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(key)), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// durationEnv returns the duration value (e.g. "10s") of the environment
+// variable key or fallback if it is unset or not a valid duration
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// listEnv splits the comma separated environment variable key into its items
+func listEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// floatListEnv parses the comma separated numbers of the environment variable key,
+// or returns fallback if it is unset or any of them isn't a valid number
+func floatListEnv(key string, fallback []float64) []float64 {
+	items := listEnv(key)
+	if len(items) == 0 {
+		return fallback
+	}
+	values := make([]float64, 0, len(items))
+	for _, item := range items {
+		value, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return fallback
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,51 @@
+package countries
+
+import "sync"
+
+// Cache keeps the countries fetched from the api by code so they are
+// only fetched once per container. It is safe for concurrent use
+type Cache struct {
+	mutex     sync.RWMutex
+	countries map[string]Info
+}
+
+// NewCache returns an empty cache
+func NewCache() *Cache {
+	return &Cache{countries: map[string]Info{}}
+}
+
+// Get returns the cached countries having one of the codes,
+// along with the codes that aren't cached yet
+func (cache *Cache) Get(codes []string) (Country, []string) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	var found Country
+	var missing []string
+	for _, code := range codes {
+		if country, ok := cache.countries[code]; ok {
+			found = append(found, country)
+		} else {
+			missing = append(missing, code)
+		}
+	}
+	return found, missing
+}
+
+// Put adds countries to the cache, replacing any cached under the same code
+func (cache *Cache) Put(countries Country) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for _, country := range countries {
+		cache.countries[country.Code] = country
+	}
+}
+
+// Clear drops every cached country
+func (cache *Cache) Clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.countries = map[string]Info{}
+}
@@ -0,0 +1,109 @@
+package countries
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheGetPut(t *testing.T) {
+	cache := NewCache()
+	cache.Put(Country{{Code: "DE", Name: "Germany"}})
+
+	found, missing := cache.Get([]string{"DE", "FR"})
+	if len(found) != 1 || found[0].Name != "Germany" {
+		t.Errorf("found %v, want Germany", found)
+	}
+	if len(missing) != 1 || missing[0] != "FR" {
+		t.Errorf("missing %v, want [FR]", missing)
+	}
+
+	cache.Clear()
+	if found, _ := cache.Get([]string{"DE"}); len(found) != 0 {
+		t.Errorf("found %v after Clear", found)
+	}
+}
+
+func TestCacheConcurrentUse(t *testing.T) {
+	cache := NewCache()
+	codes := []string{"DE", "FR", "JP", "BR", "NG"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				code := codes[(i+j)%len(codes)]
+				if i%4 == 0 {
+					cache.Put(Country{{Code: code, Name: code}})
+				} else {
+					cache.Get(codes)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	found, missing := cache.Get(codes)
+	if len(found) != len(codes) || len(missing) != 0 {
+		t.Errorf("found %d countries and missing %v, want all %d", len(found), missing, len(codes))
+	}
+}
+
+func TestAPISharesCacheWithFetchInFlight(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		var fetched Country
+		for _, code := range strings.Split(r.URL.Query().Get("codes"), ";") {
+			fetched = append(fetched, Info{Code: code, Name: "Country " + code})
+		}
+		json.NewEncoder(w).Encode(fetched)
+	}))
+	defer server.Close()
+
+	cache := NewCache()
+	api := API{BaseURL: server.URL, HTTP: server.Client(), Cache: cache}
+
+	done := make(chan error)
+	go func() {
+		_, err := api.Fetch(context.Background(), []string{"DE", "FR"})
+		done <- err
+	}()
+
+	// the cache stays usable by others while the fetch waits on the api
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Put(Country{{Code: "JP", Name: "Japan"}})
+			cache.Get([]string{"DE", "FR", "JP"})
+		}()
+	}
+	wg.Wait()
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	// the countries fetched are then answered from the cache
+	fetched, err := api.Fetch(context.Background(), []string{"DE", "FR", "JP"})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(fetched) != 3 {
+		t.Errorf("fetched %v, want DE, FR and JP", fetched)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("api was called %d times, want once", got)
+	}
+}
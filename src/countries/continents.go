@@ -0,0 +1,46 @@
+package countries
+
+import "strings"
+
+// Continents, as the keys of their names in the messages
+const (
+	Africa       = "africa"
+	Antarctica   = "antarctica"
+	Asia         = "asia"
+	Europe       = "europe"
+	NorthAmerica = "north_america"
+	Oceania      = "oceania"
+	SouthAmerica = "south_america"
+)
+
+// Continent returns the continent of the country, splitting the region of
+// the Americas into North America, which takes Central America and the
+// Caribbean, and South America. It is empty when the region is unknown
+func (country Info) Continent() string {
+	switch country.Region {
+	case "Africa":
+		return Africa
+	case "Asia":
+		return Asia
+	case "Europe":
+		return Europe
+	case "Oceania":
+		return Oceania
+	case "Polar", "Antarctic":
+		return Antarctica
+	case "Americas":
+		if country.Subregion == "South America" {
+			return SouthAmerica
+		}
+		return NorthAmerica
+	default:
+		return ""
+	}
+}
+
+// SubregionKey returns the subregion of the country as the key of its name
+// in the messages, e.g. "northern_europe" or "south_eastern_asia", empty
+// when the subregion is unknown
+func (country Info) SubregionKey() string {
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(country.Subregion))
+}
@@ -0,0 +1,110 @@
+package countries
+
+import (
+	"sort"
+	"strings"
+)
+
+// DemonymSlotType is the name of the custom slot type of demonyms the model
+// generator builds from the embedded countries
+const DemonymSlotType = "Demonym"
+
+// alternateDemonyms maps the other words for the people of a country, such
+// as the people of one of its regions, to the alpha-2 code of the country.
+// Demonyms several countries of the dataset share are mapped to the country
+// people usually mean by them
+var alternateDemonyms = map[string]string{
+	"American":       "US",
+	"Dutch":          "NL",
+	"French":         "FR",
+	"Norwegian":      "NO",
+	"Flemish":        "BE",
+	"Walloon":        "BE",
+	"English":        "GB",
+	"Scottish":       "GB",
+	"Scots":          "GB",
+	"Welsh":          "GB",
+	"Northern Irish": "GB",
+	"Brit":           "GB",
+	"Briton":         "GB",
+	"Kiwi":           "NZ",
+	"Maori":          "NZ",
+	"Aussie":         "AU",
+	"Bavarian":       "DE",
+	"Quebecois":      "CA",
+	"Sicilian":       "IT",
+	"Sardinian":      "IT",
+	"Corsican":       "FR",
+	"Breton":         "FR",
+	"Catalan":        "ES",
+	"Andalusian":     "ES",
+	"Galician":       "ES",
+	"Afrikaner":      "ZA",
+	"Persian":        "IR",
+	"Argentinian":    "AR",
+	"Saudi":          "SA",
+	"Emirian":        "AE",
+	"Luxembourgish":  "LU",
+	"Kosovan":        "XK",
+	"Filipina":       "PH",
+	"Hawaiian":       "US",
+	"Texan":          "US",
+}
+
+// ByDemonym returns the countries whose people are called demonym, such as
+// "German", "Germans" or "Flemish people", ignoring case and accents, along
+// with the demonym as it is known, e.g. "German".
+// Several countries are returned when they share the demonym
+func (countries Country) ByDemonym(demonym string) (string, Country) {
+	demonym = strings.TrimSuffix(normalize(demonym), " people")
+	demonym = strings.TrimPrefix(demonym, "the ")
+	if demonym == "" {
+		return "", nil
+	}
+	if known, found := countries.byDemonym(demonym); len(found) > 0 {
+		return known, found
+	}
+	// plurals such as "Germans" or "Filipinos"
+	if singular := strings.TrimSuffix(demonym, "s"); singular != demonym {
+		return countries.byDemonym(singular)
+	}
+	return "", nil
+}
+
+// byDemonym returns the countries whose people are called demonym, already
+// normalized, along with the demonym as it is known
+func (countries Country) byDemonym(demonym string) (string, Country) {
+	for alternate, code := range alternateDemonyms {
+		if normalize(alternate) == demonym {
+			return alternate, countries.OfCodes([]string{code})
+		}
+	}
+	var known string
+	var found Country
+	for _, country := range countries {
+		if country.Demonym != "" && normalize(country.Demonym) == demonym {
+			known = country.Demonym
+			found = append(found, country)
+		}
+	}
+	return known, found
+}
+
+// Demonyms returns every demonym ByDemonym knows of, sorted
+func (countries Country) Demonyms() []string {
+	unique := map[string]bool{}
+	for _, country := range countries {
+		if country.Demonym != "" {
+			unique[country.Demonym] = true
+		}
+	}
+	for alternate := range alternateDemonyms {
+		unique[alternate] = true
+	}
+	demonyms := make([]string, 0, len(unique))
+	for demonym := range unique {
+		demonyms = append(demonyms, demonym)
+	}
+	sort.Strings(demonyms)
+	return demonyms
+}
@@ -0,0 +1,66 @@
+package countries
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:embed countries.json
+var embeddedCountries []byte
+
+// Embedded lists every country the skill knows about without querying
+// the countries api, in the same shape the api responds with
+var Embedded = parseEmbedded()
+
+// parseEmbedded decodes the embedded countries, which are
+// validated at build time so a failure here is a programming error
+func parseEmbedded() Country {
+	var countries Country
+	if err := json.Unmarshal(embeddedCountries, &countries); err != nil {
+		panic("countries: malformed embedded countries.json: " + err.Error())
+	}
+	return countries
+}
+
+// OfCodes returns the countries having one of the alpha-2 codes
+func (countries Country) OfCodes(codes []string) Country {
+	wanted := map[string]bool{}
+	for _, code := range codes {
+		wanted[code] = true
+	}
+	var matching Country
+	for _, country := range countries {
+		if wanted[country.Code] {
+			matching = append(matching, country)
+		}
+	}
+	return matching
+}
+
+// FlagURL returns the url of a png image of the flag of the country
+// having the alpha-2 code, width pixels wide (e.g. 320, 640 or 1280)
+func FlagURL(code string, width int) string {
+	return fmt.Sprintf("https://flagcdn.com/w%d/%s.png", width, strings.ToLower(code))
+}
+
+// FlagEmoji returns the emoji of the flag of the country having the alpha-2
+// code, the pair of regional indicator symbols spelling the code, e.g. "🇩🇪"
+// for "DE". Codes that aren't two letters have none
+func FlagEmoji(code string) string {
+	if len(code) != 2 {
+		return ""
+	}
+	flag := make([]rune, 0, 2)
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+		flag = append(flag, regionalIndicatorA+r-'A')
+	}
+	return string(flag)
+}
+
+// regionalIndicatorA is the regional indicator symbol of the letter A
+const regionalIndicatorA = 0x1F1E6
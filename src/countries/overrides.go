@@ -0,0 +1,29 @@
+package countries
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+)
+
+// Overrides maps alpha-2 country codes to the demonym that should be
+// spoken instead of the one returned by the api or embedded in the skill
+type Overrides map[string]string
+
+// LoadOverrides reads overrides from a json file such as {"GB": "British"}.
+// Codes are normalized to upper case
+func LoadOverrides(path string) (Overrides, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	overrides := Overrides{}
+	for code, demonym := range raw {
+		overrides[strings.ToUpper(strings.TrimSpace(code))] = demonym
+	}
+	return overrides, nil
+}
@@ -0,0 +1,43 @@
+package countries
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes content to a file of a temporary directory and returns its path
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOverrides(t *testing.T) {
+	overrides, err := LoadOverrides(writeFile(t, `{" gb ": "British", "nl": "Dutch"}`))
+	if err != nil {
+		t.Fatalf("LoadOverrides failed: %v", err)
+	}
+	if len(overrides) != 2 || overrides["GB"] != "British" || overrides["NL"] != "Dutch" {
+		t.Errorf("LoadOverrides = %v, want the codes in upper case", overrides)
+	}
+}
+
+func TestLoadOverridesMissingFile(t *testing.T) {
+	_, err := LoadOverrides(filepath.Join(t.TempDir(), "missing.json"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadOverrides error = %v, want a missing file", err)
+	}
+}
+
+func TestLoadOverridesMalformedFile(t *testing.T) {
+	for _, content := range []string{`{"GB": "British"`, `["British"]`, `{"GB": 1}`} {
+		if overrides, err := LoadOverrides(writeFile(t, content)); err == nil {
+			t.Errorf("LoadOverrides(%s) = %v, want an error", content, overrides)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package countries
+
+import "strings"
+
+// pronunciations holds the IPA pronunciations of demonyms that
+// Alexa's default text to speech gets wrong, keyed by lowercased demonym
+var pronunciations = map[string]string{
+	"bahraini":               "bɑːˈreɪni",
+	"burkinabe":              "bɜːrˈkiːnəbeɪ",
+	"comoran":                "ˈkɒmərən",
+	"djiboutian":             "dʒɪˈbuːtiən",
+	"emirati":                "ˌɛmɪˈrɑːti",
+	"guinea-bissauan":        "ˌɡɪni bɪˈsaʊən",
+	"herzegovinian":          "ˌhɛərtsəɡoʊˈvɪniən",
+	"i-kiribati":             "ˌiːkɪrɪˈbæs",
+	"ivorian":                "aɪˈvɔːriən",
+	"kazakhstani":            "ˌkæzəkˈstæni",
+	"liechtensteiner":        "ˈlɪktənstaɪnər",
+	"luxembourger":           "ˈlʌksəmbɜːrɡər",
+	"malagasy":               "ˌmæləˈɡæsi",
+	"malawian":               "məˈlɑːwiən",
+	"manx":                   "mæŋks",
+	"monegasque":             "ˌmɒnɪˈɡæsk",
+	"mosotho":                "mʊˈsuːtuː",
+	"motswana":               "mʊˈtswɑːnə",
+	"ni-vanuatu":             "ˌniːvɑːnuˈɑːtuː",
+	"nigerien":               "niːˈʒɛəriən",
+	"qatari":                 "kəˈtɑːri",
+	"sahrawi":                "sɑːˈrɑːwi",
+	"sammarinese":            "ˌsæmærɪˈniːz",
+	"sao tomean":             "ˌsaʊ təˈmeɪən",
+	"seychellois":            "ˌseɪʃɛlˈwɑː",
+	"bosnian, herzegovinian": "ˈbɒzniən ənd ˌhɛərtsəɡoʊˈvɪniən",
+}
+
+// Pronunciation returns the IPA pronunciation of demonym if
+// the default text to speech is known to mispronounce it
+func Pronunciation(demonym string) (string, bool) {
+	ipa, ok := pronunciations[strings.ToLower(demonym)]
+	return ipa, ok
+}
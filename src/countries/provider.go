@@ -0,0 +1,80 @@
+package countries
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Provider returns information about countries
+type Provider interface {
+	// Fetch returns the information about the countries having one of codes
+	Fetch(ctx context.Context, codes []string) (Country, error)
+}
+
+// API queries the REST Countries api
+type API struct {
+	// BaseURL is the endpoint, the codes are sent in its "codes" query parameter
+	BaseURL string
+	HTTP    *http.Client
+	// Cache, if not nil, keeps the countries fetched so each is only fetched once
+	Cache *Cache
+}
+
+// Fetch returns the information about the countries having one of codes,
+// only asking the api about those that aren't cached yet
+func (api API) Fetch(ctx context.Context, codes []string) (Country, error) {
+	var cached Country
+	missing := codes
+	if api.Cache != nil {
+		cached, missing = api.Cache.Get(codes)
+	}
+	if len(missing) == 0 {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api.URL(missing), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := api.HTTP.Do(req)
+	if err != nil {
+		return nil, upstream.Transport("countries", err)
+	}
+	defer response.Body.Close()
+	if err := upstream.Check("countries", response); err != nil {
+		return nil, err
+	}
+
+	var fetched Country
+	if err := json.NewDecoder(response.Body).Decode(&fetched); err != nil {
+		return nil, upstream.Malformed("countries", err)
+	}
+	if api.Cache != nil {
+		api.Cache.Put(fetched)
+	}
+	return append(cached, fetched...), nil
+}
+
+// URL returns the url fetching information about all
+// the countries having one of codes in a single request
+func (api API) URL(codes []string) string {
+	joined := strings.Join(codes, ";")
+	u, err := url.Parse(api.BaseURL)
+	if err != nil {
+		return api.BaseURL + "?" + url.Values{"codes": {joined}}.Encode()
+	}
+	query := u.Query()
+	query.Set("codes", joined)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Fetch returns the countries of the list having one of codes,
+// so a fixed list such as Embedded can be used as a Provider
+func (countries Country) Fetch(ctx context.Context, codes []string) (Country, error) {
+	return countries.OfCodes(codes), nil
+}
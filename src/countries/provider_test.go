@@ -0,0 +1,95 @@
+package countries
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serve returns a countries api client calling a server that runs handler
+func serve(t *testing.T, handler http.HandlerFunc) API {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return API{BaseURL: server.URL, HTTP: server.Client(), Cache: NewCache()}
+}
+
+func TestAPIFetch(t *testing.T) {
+	api := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("codes"); got != "DE;FR" {
+			t.Errorf("codes sent as %q", got)
+		}
+		w.Write([]byte(`[{"alpha2Code":"DE","name":"Germany","demonym":"German"},{"alpha2Code":"FR","name":"France","demonym":"French"}]`))
+	})
+
+	fetched, err := api.Fetch(context.Background(), []string{"DE", "FR"})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(fetched) != 2 || fetched[0].Demonym != "German" || fetched[1].Name != "France" {
+		t.Errorf("Fetch = %+v", fetched)
+	}
+	if cached, missing := api.Cache.Get([]string{"DE", "FR"}); len(cached) != 2 || len(missing) != 0 {
+		t.Errorf("fetched countries weren't cached, missing %v", missing)
+	}
+}
+
+func TestAPIStatusErrors(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		api := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+
+		_, err := api.Fetch(context.Background(), []string{"DE"})
+		var statusErr *upstream.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status || statusErr.Service != "countries" {
+			t.Errorf("Fetch error = %v, want a %d StatusError from countries", err, status)
+			continue
+		}
+		if statusErr.Throttled() != (status == http.StatusTooManyRequests) {
+			t.Errorf("status %d: throttled %v", status, statusErr.Throttled())
+		}
+		if _, missing := api.Cache.Get([]string{"DE"}); len(missing) != 1 {
+			t.Errorf("status %d: a failed fetch was cached", status)
+		}
+	}
+}
+
+func TestAPIMalformed(t *testing.T) {
+	api := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":404,"message":"Not Found"}`))
+	})
+
+	_, err := api.Fetch(context.Background(), []string{"DE"})
+	var malformed *upstream.MalformedError
+	if !errors.As(err, &malformed) || malformed.Service != "countries" {
+		t.Errorf("Fetch error = %v, want a MalformedError from countries", err)
+	}
+}
+
+func TestEmbeddedIsAProvider(t *testing.T) {
+	var provider Provider = Embedded
+	fetched, err := provider.Fetch(context.Background(), []string{"JP"})
+	if err != nil || len(fetched) != 1 || fetched[0].Name != "Japan" {
+		t.Errorf("Fetch = %+v, %v, want Japan", fetched, err)
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	tests := []struct {
+		base  string
+		codes []string
+		want  string
+	}{
+		{"https://restcountries.com/v2/alpha", []string{"DE"}, "https://restcountries.com/v2/alpha?codes=DE"},
+		{"https://restcountries.com/v2/alpha", []string{"DE", "FR", "JP"}, "https://restcountries.com/v2/alpha?codes=DE%3BFR%3BJP"},
+		{"https://restcountries.com/v2/alpha?fields=name", []string{"DE", "FR"}, "https://restcountries.com/v2/alpha?codes=DE%3BFR&fields=name"},
+	}
+	for _, test := range tests {
+		if got := (API{BaseURL: test.base}).URL(test.codes); got != test.want {
+			t.Errorf("URL(%v) = %s, want %s", test.codes, got, test.want)
+		}
+	}
+}
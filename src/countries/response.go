@@ -1,6 +1,22 @@
 package countries
 
-type Country []struct {
-	Demonym string `json:"demonym"`
-	Code    string `json:"alpha2Code"`
+type Country []Info
+
+type Info struct {
+	Name       string     `json:"name"`
+	NativeName string     `json:"nativeName"`
+	Demonym    string     `json:"demonym"`
+	Code       string     `json:"alpha2Code"`
+	Flag       string     `json:"flag"`
+	Region     string     `json:"region"`
+	Subregion  string     `json:"subregion"`
+	Capital    string     `json:"capital"`
+	Currencies []Currency `json:"currencies"`
+}
+
+// Currency is a currency used in a country, e.g. {"EUR", "Euro", "€"}
+type Currency struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol,omitempty"`
 }
@@ -0,0 +1,43 @@
+package countries
+
+// speechLocales maps country codes to the locale of the Alexa voice model
+// speaking the language of that country, for countries Alexa has a voice for
+var speechLocales = map[string]string{
+	"AR": "es-US",
+	"AT": "de-DE",
+	"BO": "es-US",
+	"BR": "pt-BR",
+	"CL": "es-US",
+	"CO": "es-US",
+	"CR": "es-US",
+	"CU": "es-US",
+	"DE": "de-DE",
+	"DO": "es-US",
+	"EC": "es-US",
+	"ES": "es-ES",
+	"FR": "fr-FR",
+	"GT": "es-US",
+	"HN": "es-US",
+	"IN": "hi-IN",
+	"IT": "it-IT",
+	"JP": "ja-JP",
+	"LI": "de-DE",
+	"MC": "fr-FR",
+	"MX": "es-MX",
+	"NI": "es-US",
+	"PA": "es-US",
+	"PE": "es-US",
+	"PT": "pt-BR",
+	"PY": "es-US",
+	"SM": "it-IT",
+	"SV": "es-US",
+	"UY": "es-US",
+	"VE": "es-US",
+}
+
+// SpeechLocale returns the locale of the voice model that speaks the
+// language of the country having code, if Alexa supports one
+func SpeechLocale(code string) (string, bool) {
+	locale, ok := speechLocales[code]
+	return locale, ok
+}
@@ -0,0 +1,94 @@
+package countries
+
+import (
+	"strings"
+	"unicode"
+)
+
+// synonyms maps the names people commonly use for a country, besides its
+// name and demonym, to the alpha-2 code of the country
+var synonyms = map[string]string{
+	"america":                  "US",
+	"usa":                      "US",
+	"us":                       "US",
+	"united states of america": "US",
+	"the states":               "US",
+	"britain":                  "GB",
+	"great britain":            "GB",
+	"uk":                       "GB",
+	"england":                  "GB",
+	"scotland":                 "GB",
+	"wales":                    "GB",
+	"northern ireland":         "GB",
+	"english":                  "GB",
+	"scottish":                 "GB",
+	"welsh":                    "GB",
+	"holland":                  "NL",
+	"korea":                    "KR",
+	"czechia":                  "CZ",
+	"persia":                   "IR",
+	"burma":                    "MM",
+	"ivory coast":              "CI",
+	"cote d'ivoire":            "CI",
+	"congo":                    "CD",
+	"uae":                      "AE",
+	"emirates":                 "AE",
+	"the netherlands":          "NL",
+	"the philippines":          "PH",
+	"philippines":              "PH",
+	"turkiye":                  "TR",
+	"east timor":               "TL",
+	"swaziland":                "SZ",
+	"cape verde":               "CV",
+	"north macedonia":          "MK",
+	"vatican":                  "VA",
+	"the vatican":              "VA",
+	"russian federation":       "RU",
+}
+
+// Match returns the alpha-2 code of the country answer refers to, such as
+// "Germany", "German", "Deutschland" or "Holland", ignoring case and accents
+func (countries Country) Match(answer string) (string, bool) {
+	answer = normalize(answer)
+	if answer == "" {
+		return "", false
+	}
+	if code, ok := synonyms[answer]; ok {
+		return code, true
+	}
+	for _, country := range countries {
+		for _, name := range []string{country.Name, country.Demonym, country.NativeName, country.Code} {
+			if name != "" && normalize(name) == answer {
+				return country.Code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// normalize lowercases text, strips the accents of latin letters and
+// collapses its spaces, so spoken answers match the written names
+func normalize(text string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		if folded, ok := accents[r]; ok {
+			r = folded
+		}
+		if unicode.IsLetter(r) || unicode.IsSpace(r) || r == '\'' {
+			builder.WriteRune(r)
+		} else if r == '-' {
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// accents maps accented latin letters to the letters they are spoken like
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o', 'ø': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ý': 'y', 'ÿ': 'y',
+}
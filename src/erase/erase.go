@@ -0,0 +1,131 @@
+// Package erase deletes everything the skill stored in DynamoDB about an
+// account, for every member of its household, when the user asks for it.
+//
+// The items of a household are spread over a partition per person, so every
+// item keyed by person is written with AccountAttribute, and every such table
+// has the global secondary index AccountIndex on it. Items written before the
+// attribute existed must be backfilled with it to be found
+package erase
+
+import (
+	"alexa-skill-test/src/retry"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// AccountAttribute is the attribute holding the account an item belongs to
+const AccountAttribute = "accountId"
+
+// AccountIndex is the global secondary index partitioned by AccountAttribute
+const AccountIndex = "accountId-index"
+
+// batchSize is the number of items DynamoDB deletes in one batch
+const batchSize = 25
+
+// unprocessedPolicy is how patiently the items DynamoDB left unprocessed,
+// when throttled, are deleted again
+var unprocessedPolicy = retry.Policy{Attempts: 5, Backoff: 50 * time.Millisecond, MaxBackoff: time.Second}
+
+// Client is the part of the DynamoDB client deleting items
+type Client interface {
+	dynamodb.QueryAPIClient
+	BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, options ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// AccountOf returns the account of key, which is either the account itself
+// or the key of a person of its household built by history.Key
+func AccountOf(key string) string {
+	account, _, _ := strings.Cut(key, "#")
+	return account
+}
+
+// Account deletes the items of table belonging to the account userID, found through
+// AccountIndex. keys names the key attributes of the table, the partition key first,
+// such as "userId"
+func Account(ctx context.Context, client Client, table string, keys []string, userID string) error {
+	return deleteQueried(ctx, client, table, keys, &dynamodb.QueryInput{
+		TableName:                aws.String(table),
+		IndexName:                aws.String(AccountIndex),
+		KeyConditionExpression:   aws.String("#account = :account"),
+		ExpressionAttributeNames: map[string]string{"#account": AccountAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":account": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+}
+
+// Partition deletes the items of table whose partition key is userID, for tables
+// keeping a whole household in the partition of its account. keys names the key
+// attributes of the table, the partition key first
+func Partition(ctx context.Context, client Client, table string, keys []string, userID string) error {
+	return deleteQueried(ctx, client, table, keys, &dynamodb.QueryInput{
+		TableName: aws.String(table),
+		// #k0 is the partition key, aliased by deleteQueried
+		KeyConditionExpression: aws.String("#k0 = :account"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":account": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+}
+
+// deleteQueried deletes the items query finds, reading only their keys
+func deleteQueried(ctx context.Context, client Client, table string, keys []string, query *dynamodb.QueryInput) error {
+	if query.ExpressionAttributeNames == nil {
+		query.ExpressionAttributeNames = map[string]string{}
+	}
+	// key attributes may be reserved words, so they are always aliased, as #k0, #k1 and so on
+	var projection []string
+	for i, key := range keys {
+		name := "#k" + strconv.Itoa(i)
+		query.ExpressionAttributeNames[name] = key
+		projection = append(projection, name)
+	}
+	query.ProjectionExpression = aws.String(strings.Join(projection, ", "))
+
+	paginator := dynamodb.NewQueryPaginator(client, query)
+	var deletes []types.WriteRequest
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: item}})
+		}
+	}
+	return Delete(ctx, client, table, deletes)
+}
+
+// Delete sends deletes to table in batches. The items DynamoDB leaves
+// unprocessed when throttled are sent again, backing off between attempts,
+// and given up on after a few of them
+func Delete(ctx context.Context, client Client, table string, deletes []types.WriteRequest) error {
+	for start := 0; start < len(deletes); start += batchSize {
+		end := start + batchSize
+		if end > len(deletes) {
+			end = len(deletes)
+		}
+		requests := map[string][]types.WriteRequest{table: deletes[start:end]}
+		for attempt := 1; ; attempt++ {
+			output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: requests})
+			if err != nil {
+				return err
+			}
+			requests = output.UnprocessedItems
+			if len(requests) == 0 {
+				break
+			}
+			if err := unprocessedPolicy.Wait(ctx, attempt); err != nil {
+				return fmt.Errorf("erase: %d items of %s left unprocessed: %w", len(requests[table]), table, err)
+			}
+		}
+	}
+	return nil
+}
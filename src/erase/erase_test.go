@@ -0,0 +1,155 @@
+package erase
+
+import (
+	"alexa-skill-test/src/retry"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// client is a DynamoDB client answering queries with items, and leaving
+// the first unprocessed batches it is sent unprocessed
+type client struct {
+	items       []map[string]types.AttributeValue
+	unprocessed int
+
+	mutex   sync.Mutex
+	queries []*dynamodb.QueryInput
+	batches [][]types.WriteRequest
+}
+
+func (client *client) Query(ctx context.Context, input *dynamodb.QueryInput, options ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.queries = append(client.queries, input)
+	return &dynamodb.QueryOutput{Items: client.items}, nil
+}
+
+func (client *client) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, options ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	for _, requests := range input.RequestItems {
+		client.batches = append(client.batches, requests)
+	}
+	if client.unprocessed > 0 {
+		client.unprocessed--
+		return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// items returns count items of the household of user
+func items(count int) []map[string]types.AttributeValue {
+	var found []map[string]types.AttributeValue
+	for i := 0; i < count; i++ {
+		found = append(found, map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: "user#person" + strconv.Itoa(i)},
+		})
+	}
+	return found
+}
+
+// quickly makes the unprocessed items be sent again without waiting for the length of the test
+func quickly(t *testing.T, attempts int) {
+	saved := unprocessedPolicy
+	unprocessedPolicy = retry.Policy{Attempts: attempts, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	t.Cleanup(func() { unprocessedPolicy = saved })
+}
+
+func TestAccountQueriesTheAccountIndex(t *testing.T) {
+	fake := &client{items: items(30)}
+	if err := Account(context.Background(), fake, "history", []string{"userId", "guessedAt"}, "user"); err != nil {
+		t.Fatalf("Account failed: %v", err)
+	}
+
+	if len(fake.queries) != 1 {
+		t.Fatalf("%d queries, want 1", len(fake.queries))
+	}
+	query := fake.queries[0]
+	if aws.ToString(query.IndexName) != AccountIndex || query.ExpressionAttributeNames["#account"] != AccountAttribute {
+		t.Errorf("query of index %q on %v, want %s on %s", aws.ToString(query.IndexName), query.ExpressionAttributeNames, AccountIndex, AccountAttribute)
+	}
+	if account, _ := query.ExpressionAttributeValues[":account"].(*types.AttributeValueMemberS); account == nil || account.Value != "user" {
+		t.Errorf("query for account %v, want user", query.ExpressionAttributeValues[":account"])
+	}
+	if aws.ToString(query.ProjectionExpression) != "#k0, #k1" || query.ExpressionAttributeNames["#k1"] != "guessedAt" {
+		t.Errorf("projection %q of %v, want the keys only", aws.ToString(query.ProjectionExpression), query.ExpressionAttributeNames)
+	}
+	if len(fake.batches) != 2 || len(fake.batches[0]) != batchSize || len(fake.batches[1]) != 5 {
+		t.Errorf("deleted in %d batches, want one of %d and one of 5", len(fake.batches), batchSize)
+	}
+}
+
+func TestPartitionQueriesTheTable(t *testing.T) {
+	fake := &client{items: items(3)}
+	if err := Partition(context.Background(), fake, "leaderboard", []string{"userId", "personId"}, "user"); err != nil {
+		t.Fatalf("Partition failed: %v", err)
+	}
+	query := fake.queries[0]
+	if query.IndexName != nil || aws.ToString(query.KeyConditionExpression) != "#k0 = :account" || query.ExpressionAttributeNames["#k0"] != "userId" {
+		t.Errorf("query %+v, want the partition of the account", query)
+	}
+	if len(fake.batches) != 1 || len(fake.batches[0]) != 3 {
+		t.Errorf("batches %v, want the 3 items deleted", fake.batches)
+	}
+}
+
+func TestDeleteRetriesUnprocessedItems(t *testing.T) {
+	quickly(t, 5)
+	fake := &client{items: items(3), unprocessed: 2}
+	if err := Account(context.Background(), fake, "history", []string{"userId"}, "user"); err != nil {
+		t.Fatalf("Account failed: %v", err)
+	}
+	if len(fake.batches) != 3 {
+		t.Errorf("batch sent %d times, want 3", len(fake.batches))
+	}
+}
+
+func TestDeleteGivesUpOnUnprocessedItems(t *testing.T) {
+	quickly(t, 3)
+	fake := &client{items: items(3), unprocessed: 100}
+	err := Account(context.Background(), fake, "history", []string{"userId"}, "user")
+	if !errors.Is(err, retry.ErrExhausted) {
+		t.Errorf("Account error = %v, want the retries exhausted", err)
+	}
+	if len(fake.batches) != 3 {
+		t.Errorf("batch sent %d times, want 3", len(fake.batches))
+	}
+}
+
+func TestDeleteStopsWhenContextIsDone(t *testing.T) {
+	saved := unprocessedPolicy
+	unprocessedPolicy = retry.Policy{Attempts: 5, Backoff: time.Hour, MaxBackoff: time.Hour}
+	t.Cleanup(func() { unprocessedPolicy = saved })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	fake := &client{items: items(3), unprocessed: 100}
+	started := time.Now()
+	if err := Account(ctx, fake, "history", []string{"userId"}, "user"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Account error = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Account took %s", elapsed)
+	}
+}
+
+func TestAccountOf(t *testing.T) {
+	tests := map[string]string{
+		"amzn1.ask.account.A":                      "amzn1.ask.account.A",
+		"amzn1.ask.account.A#amzn1.ask.person.B":   "amzn1.ask.account.A",
+		"amzn1.ask.account.A#amzn1.ask.person.B#C": "amzn1.ask.account.A",
+	}
+	for key, want := range tests {
+		if got := AccountOf(key); got != want {
+			t.Errorf("AccountOf(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package facts holds curated fun facts about countries, offered to
+// users after their guess
+package facts
+
+import (
+	"embed"
+	"encoding/json"
+	"math/rand"
+	"path"
+	"strings"
+)
+
+// embedded holds one json file per language, mapping alpha-2 country codes to facts
+//
+//go:embed *.json
+var embedded embed.FS
+
+// byLanguage maps languages to the facts known about each country in that language
+var byLanguage = load()
+
+// load reads the embedded facts, keyed by the language named by each file
+func load() map[string]map[string][]string {
+	files, err := embedded.ReadDir(".")
+	if err != nil {
+		panic("facts: unreadable facts: " + err.Error())
+	}
+	loaded := map[string]map[string][]string{}
+	for _, file := range files {
+		data, err := embedded.ReadFile(file.Name())
+		if err != nil {
+			panic("facts: unreadable " + file.Name() + ": " + err.Error())
+		}
+		var facts map[string][]string
+		if err := json.Unmarshal(data, &facts); err != nil {
+			panic("facts: malformed " + file.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = facts
+	}
+	return loaded
+}
+
+// For returns the facts about the country having code in language, if any
+func For(language string, code string) []string {
+	return byLanguage[strings.ToLower(language)][strings.ToUpper(code)]
+}
+
+// Random returns one of the facts about the country having code in language chosen using rng,
+// reporting whether there was any
+func Random(rng *rand.Rand, language string, code string) (string, bool) {
+	facts := For(language, code)
+	if len(facts) == 0 {
+		return "", false
+	}
+	return facts[rng.Intn(len(facts))], true
+}
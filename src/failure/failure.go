@@ -0,0 +1,113 @@
+// Package failure sorts what went wrong handling a request into a few kinds,
+// each explained to the user by its own message along with a short support
+// code they can quote when reporting the problem
+package failure
+
+import (
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/upstream"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Kind is a class of failure the user is told about
+type Kind string
+
+// The kinds of failure, see Code and Message for how each is reported
+const (
+	// ProviderUnavailable means an upstream api failed or answered nonsense
+	ProviderUnavailable Kind = "ProviderUnavailable"
+	// ProviderThrottled means an upstream api refuses requests until later
+	ProviderThrottled Kind = "ProviderThrottled"
+	// Timeout means the request couldn't be answered in time
+	Timeout Kind = "Timeout"
+	// NameNotRecognized means an upstream api refused the name asked about
+	NameNotRecognized Kind = "NameNotRecognized"
+	// PermissionMissing means the user hasn't granted a permission the request needs
+	PermissionMissing Kind = "PermissionMissing"
+	// Internal means the skill itself is broken
+	Internal Kind = "Internal"
+)
+
+// entry is how a kind of failure is reported
+type entry struct {
+	code    string
+	message string
+}
+
+// table maps every kind to its support code and the id of its message.
+// Codes are only ever added, since users may quote old ones
+var table = map[Kind]entry{
+	ProviderUnavailable: {code: "101", message: "error.upstream"},
+	ProviderThrottled:   {code: "102", message: "error.busy"},
+	Timeout:             {code: "103", message: "error.timeout"},
+	NameNotRecognized:   {code: "201", message: "error.rejected"},
+	PermissionMissing:   {code: "301", message: "error.permission"},
+	Internal:            {code: "900", message: "error.generic"},
+}
+
+// Error is an error of a known kind
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+// Error describes the kind of failure and its cause
+func (err *Error) Error() string {
+	if err.Err == nil {
+		return string(err.Kind)
+	}
+	return string(err.Kind) + ": " + err.Err.Error()
+}
+
+// Unwrap returns the cause of the failure
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Wrap returns err marked as a failure of kind
+func Wrap(kind Kind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// Classify returns the kind of err. Errors wrapped by Wrap keep their kind,
+// others are classified by what failed, upstream errors by their status
+func Classify(err error) Kind {
+	var failed *Error
+	var netErr net.Error
+	var statusErr *upstream.StatusError
+	switch {
+	case errors.As(err, &failed):
+		return failed.Kind
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return Timeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	case errors.Is(err, nationality.ErrQuotaExhausted):
+		return ProviderThrottled
+	case errors.As(err, &statusErr) && statusErr.Throttled():
+		return ProviderThrottled
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusBadRequest || statusErr.StatusCode == http.StatusUnprocessableEntity):
+		return NameNotRecognized
+	default:
+		return ProviderUnavailable
+	}
+}
+
+// Code returns the support code of kind
+func Code(kind Kind) string {
+	if reported, ok := table[kind]; ok {
+		return reported.code
+	}
+	return table[Internal].code
+}
+
+// Message returns the id of the message explaining kind to the user
+func Message(kind Kind) string {
+	if reported, ok := table[kind]; ok {
+		return reported.message
+	}
+	return table[Internal].message
+}
@@ -0,0 +1,186 @@
+// Package flags toggles features of the skill per environment, or for a
+// share of the users so a feature can be ramped up gradually, without a
+// redeploy. Flags are set in the environment and optionally overridden by
+// an AWS AppConfig feature flag profile, read through the AppConfig lambda
+// extension so no aws sdk call is made while handling a request
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the flags the skill checks
+const (
+	// Ensemble averages the guesses of the configured ensemble providers
+	Ensemble = "ensemble"
+	// Quiz lets users start a quiz
+	Quiz = "quiz"
+	// APL shows the guesses on the screen of devices having one
+	APL = "apl"
+	// PremiumFacts offers the curated facts about countries, rather than
+	// the facts made up from what is known of each country
+	PremiumFacts = "premium_facts"
+)
+
+// Flag is the state of one feature
+type Flag struct {
+	Enabled bool `json:"enabled"`
+	// Percent, when between 1 and 99, limits an enabled flag to that share of the users
+	Percent int `json:"percent,omitempty"`
+}
+
+// Set maps flag names to their state
+type Set map[string]Flag
+
+// Defaults are the flags of a skill configuring none, every feature is on
+var Defaults = Set{
+	Ensemble:     {Enabled: true},
+	Quiz:         {Enabled: true},
+	APL:          {Enabled: true},
+	PremiumFacts: {Enabled: true},
+}
+
+// Parse returns the flags given as entries such as "quiz=off", "apl=on" or
+// "ensemble=25%", which enables the ensemble for a quarter of the users,
+// along with the entries that couldn't be parsed
+func Parse(entries []string) (Set, []string) {
+	set := Set{}
+	var invalid []string
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.ToLower(strings.TrimSpace(value))
+		if !ok || name == "" {
+			invalid = append(invalid, entry)
+			continue
+		}
+		if percent, err := strconv.Atoi(strings.TrimSuffix(value, "%")); err == nil && percent >= 0 && percent <= 100 {
+			set[name] = Flag{Enabled: percent > 0, Percent: percent}
+			continue
+		}
+		switch value {
+		case "on", "true", "1":
+			set[name] = Flag{Enabled: true}
+		case "off", "false", "0":
+			set[name] = Flag{Enabled: false}
+		default:
+			invalid = append(invalid, entry)
+		}
+	}
+	return set, invalid
+}
+
+// Merge returns a copy of set with the flags of overlay replacing its own
+func (set Set) Merge(overlay Set) Set {
+	merged := Set{}
+	for name, flag := range set {
+		merged[name] = flag
+	}
+	for name, flag := range overlay {
+		merged[name] = flag
+	}
+	return merged
+}
+
+// On reports whether flag is enabled for userID. Users are bucketed by a hash
+// of the flag name and their id, so each user keeps the same state while a
+// flag is ramped up and the users of different flags don't overlap
+func (flag Flag) On(name string, userID string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if flag.Percent <= 0 || flag.Percent >= 100 {
+		return true
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(name + ":" + userID))
+	return int(hash.Sum32()%100) < flag.Percent
+}
+
+// Flags answers whether features are enabled. The flags of Local are
+// overridden by the ones fetched from AppConfigURL, which are fetched again
+// once older than Refresh. When AppConfig fails, the flags fetched last keep
+// being used. It is safe for concurrent use
+type Flags struct {
+	Local Set
+	// AppConfigURL is the url of the feature flag profile served by the AppConfig
+	// lambda extension, e.g. http://localhost:2772/applications/skill/environments/prod/configurations/flags.
+	// Only Local is used when empty
+	AppConfigURL string
+	// HTTP fetches the profile, it should time out quickly since the extension runs locally
+	HTTP    *http.Client
+	Refresh time.Duration
+
+	mutex     sync.Mutex
+	remote    Set
+	fetchedAt time.Time
+}
+
+// Enabled reports whether the flag called name is on for the user ctx was
+// given by WithUser. Unknown flags are off
+func (flags *Flags) Enabled(ctx context.Context, name string) bool {
+	flag, ok := flags.Current(ctx)[name]
+	return ok && flag.On(name, userFrom(ctx))
+}
+
+// Current returns the state of every flag, fetching the AppConfig profile
+// first when it is stale
+func (flags *Flags) Current(ctx context.Context) Set {
+	flags.mutex.Lock()
+	defer flags.mutex.Unlock()
+	if flags.AppConfigURL != "" && time.Since(flags.fetchedAt) >= flags.Refresh {
+		// failures are retried once Refresh elapsed, not on every request
+		flags.fetchedAt = time.Now()
+		if remote, err := flags.fetch(ctx); err == nil {
+			flags.remote = remote
+		}
+	}
+	return flags.Local.Merge(flags.remote)
+}
+
+// fetch gets the feature flag profile from the AppConfig extension, which
+// serves it as an object mapping each flag name to its attributes
+func (flags *Flags) fetch(ctx context.Context) (Set, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, flags.AppConfigURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := flags.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("flags: appconfig responded %s", response.Status)
+	}
+	var remote Set
+	if err := json.NewDecoder(response.Body).Decode(&remote); err != nil {
+		return nil, fmt.Errorf("flags: malformed appconfig profile: %w", err)
+	}
+	return remote, nil
+}
+
+// contextKey is the key the user of a request is stored under in its context
+type contextKey struct{}
+
+// WithUser returns ctx carrying the id of the user flags are evaluated for
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// userFrom returns the id of the user ctx carries, empty outside of a request
+func userFrom(ctx context.Context) string {
+	userID, _ := ctx.Value(contextKey{}).(string)
+	return userID
+}
@@ -0,0 +1,117 @@
+// Package format writes numbers, percentages, ordinals and lists the way
+// the language of a locale does, e.g. "40 %" and "Ethan, Maria und Wei" in
+// German, so responses don't sound like english translated word for word
+package format
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Formatter formats values for one locale
+type Formatter struct {
+	tag     language.Tag
+	printer *message.Printer
+}
+
+// For returns the formatter of locale, e.g. "de-DE".
+// An unparsable locale is formatted as english
+func For(locale string) Formatter {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.AmericanEnglish
+	}
+	return Formatter{tag: tag, printer: message.NewPrinter(tag)}
+}
+
+// Number returns n with the digits grouped as the locale does, e.g. "1,234" or "1.234"
+func (f Formatter) Number(n int) string {
+	return f.printer.Sprint(number.Decimal(n))
+}
+
+// Percent returns probability, from 0 to 1, as a whole percentage,
+// e.g. "40%" or "40 %"
+func (f Formatter) Percent(probability float64) string {
+	return f.printer.Sprint(number.Percent(probability, number.MaxFractionDigits(0)))
+}
+
+// ordinalSuffixes maps the ordinal plural form of a number to its written
+// suffix, by language. Languages missing here write ordinals as numbers
+var ordinalSuffixes = map[string]map[plural.Form]string{
+	"en": {plural.One: "st", plural.Two: "nd", plural.Few: "rd", plural.Other: "th"},
+	"de": {plural.Other: "."},
+	"fr": {plural.One: "er", plural.Other: "e"},
+	"es": {plural.Other: ".º"},
+}
+
+// Ordinal returns the written ordinal of n, e.g. "2nd", "2." or "2e",
+// which Alexa reads as an ordinal in the language of the locale.
+// Japanese ordinals are prefixed instead, e.g. "第2"
+func (f Formatter) Ordinal(n int) string {
+	base, _ := f.tag.Base()
+	if base.String() == "ja" {
+		return "第" + strconv.Itoa(n)
+	}
+	suffixes, ok := ordinalSuffixes[base.String()]
+	if !ok {
+		return strconv.Itoa(n)
+	}
+	form := plural.Ordinal.MatchPlural(f.tag, n, 0, 0, 0, 0)
+	suffix, ok := suffixes[form]
+	if !ok {
+		suffix = suffixes[plural.Other]
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// conjunctions holds the word joining the last two items of a list and the
+// separator of the others, by language
+var conjunctions = map[string]struct{ and, separator string }{
+	"en": {" and ", ", "},
+	"de": {" und ", ", "},
+	"fr": {" et ", ", "},
+	"es": {" y ", ", "},
+	"ja": {"と", "、"},
+}
+
+// List joins items into a list read as a whole, e.g. "Ethan, Maria and Wei"
+// or "Ethan, Maria et Wei"
+func (f Formatter) List(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	base, _ := f.tag.Base()
+	conjunction, ok := conjunctions[base.String()]
+	if !ok {
+		conjunction = conjunctions["en"]
+	}
+	last := items[len(items)-1]
+	and := conjunction.and
+	// spanish "y" becomes "e" before a word starting with the sound "i", e.g. "Francia e Italia"
+	if base.String() == "es" && startsWithISound(last) {
+		and = " e "
+	}
+	return strings.Join(items[:len(items)-1], conjunction.separator) + and + last
+}
+
+// startsWithISound reports whether the spanish word starts with the vowel
+// "i", written "i" or "hi", but not the diphthongs of "hie" or "hia"
+func startsWithISound(word string) bool {
+	lower := strings.ToLower(word)
+	for _, prefix := range []string{"hie", "hia"} {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range []string{"i", "í", "hi", "hí"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package friends
+
+import (
+	"alexa-skill-test/src/erase"
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Dynamo stores the friends in a DynamoDB table having the string partition
+// key "userId" and the string sort key "friendId", holding the ID of each name,
+// and the index erase.AccountIndex
+type Dynamo struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// List returns the names of the friends of the user
+func (store Dynamo) List(ctx context.Context, userID string) ([]string, error) {
+	paginator := dynamodb.NewQueryPaginator(store.Client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.Table),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	var names []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return names, err
+		}
+		for _, item := range page.Items {
+			if name, ok := item["name"].(*types.AttributeValueMemberS); ok {
+				names = append(names, name.Value)
+			}
+		}
+	}
+	return names, nil
+}
+
+// Add saves name as a friend of the user
+func (store Dynamo) Add(ctx context.Context, userID string, name string) error {
+	_, err := store.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item: map[string]types.AttributeValue{
+			"userId":               &types.AttributeValueMemberS{Value: userID},
+			erase.AccountAttribute: &types.AttributeValueMemberS{Value: erase.AccountOf(userID)},
+			"friendId":             &types.AttributeValueMemberS{Value: ID(name)},
+			"name":                 &types.AttributeValueMemberS{Value: name},
+		},
+	})
+	return err
+}
+
+// Clear forgets every friend of the user
+func (store Dynamo) Clear(ctx context.Context, userID string) error {
+	friends, err := store.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var deletes []types.WriteRequest
+	for _, name := range friends {
+		deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+			"userId":   &types.AttributeValueMemberS{Value: userID},
+			"friendId": &types.AttributeValueMemberS{Value: ID(name)},
+		}}})
+	}
+
+	return erase.Delete(ctx, store.Client, store.Table, deletes)
+}
+
+// Forget deletes the friends of every person of the account userID
+func (store Dynamo) Forget(ctx context.Context, userID string) error {
+	return erase.Account(ctx, store.Client, store.Table, []string{"userId", "friendId"}, userID)
+}
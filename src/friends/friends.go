@@ -0,0 +1,68 @@
+// Package friends remembers the names of the friends of each user,
+// so they can ask for their guesses again by saying who they are
+package friends
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Max is the number of friends a user can save, which is as many
+// values as Alexa accepts as dynamic entities
+const Max = 100
+
+// attribute is the session attribute caching the friends of the user
+const attribute = "friends"
+
+// Store keeps the friends of every user
+type Store interface {
+	// List returns the names of the friends of the user
+	List(ctx context.Context, userID string) ([]string, error)
+	// Add saves name as a friend of the user
+	Add(ctx context.Context, userID string, name string) error
+	// Clear forgets every friend of the user
+	Clear(ctx context.Context, userID string) error
+	// Forget deletes the friends of every person of the account userID
+	Forget(ctx context.Context, userID string) error
+}
+
+// ID returns the id a friend is stored and recognized under,
+// which doesn't depend on how the name was capitalized
+func ID(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Find returns the friend called name, or whose id is name, if any
+func Find(friends []string, name string) (string, bool) {
+	for _, friend := range friends {
+		if ID(friend) == ID(name) {
+			return friend, true
+		}
+	}
+	return "", false
+}
+
+// Cached returns the friends cached in attributes, if any.
+// Attributes decoded from a request hold them as a generic json array,
+// so they are converted back through json
+func Cached(attributes map[string]interface{}) ([]string, bool) {
+	var friends []string
+	saved, ok := attributes[attribute]
+	if !ok || saved == nil {
+		return friends, false
+	}
+	data, err := json.Marshal(saved)
+	if err != nil || json.Unmarshal(data, &friends) != nil {
+		return friends, false
+	}
+	return friends, true
+}
+
+// Cache stores friends in attributes
+func Cache(attributes map[string]interface{}, friends []string) {
+	if friends == nil {
+		friends = []string{}
+	}
+	attributes[attribute] = friends
+}
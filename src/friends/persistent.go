@@ -0,0 +1,49 @@
+package friends
+
+import (
+	"alexa-skill-test/src/storage"
+	"context"
+)
+
+// persistentKey is the persistent attribute keeping the friends
+const persistentKey = "friends"
+
+// Persistent stores the friends in the persistent attributes of each user
+type Persistent struct {
+	Attributes storage.PersistentAttributes
+}
+
+// List returns the names of the friends of the user
+func (store Persistent) List(ctx context.Context, userID string) ([]string, error) {
+	var names []string
+	_, err := storage.Load(ctx, store.Attributes, userID, persistentKey, &names)
+	return names, err
+}
+
+// Add saves name as a friend of the user, replacing a friend of the same id
+func (store Persistent) Add(ctx context.Context, userID string, name string) error {
+	names, err := store.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if friend, ok := Find(names, name); ok {
+		for i := range names {
+			if names[i] == friend {
+				names[i] = name
+			}
+		}
+	} else {
+		names = append(names, name)
+	}
+	return storage.Update(ctx, store.Attributes, userID, persistentKey, names)
+}
+
+// Clear forgets every friend of the user
+func (store Persistent) Clear(ctx context.Context, userID string) error {
+	return storage.Update(ctx, store.Attributes, userID, persistentKey, nil)
+}
+
+// Forget deletes the friends of every person of the account userID
+func (store Persistent) Forget(ctx context.Context, userID string) error {
+	return store.Attributes.Forget(ctx, userID)
+}
@@ -0,0 +1,8 @@
+package gender
+
+type Response struct {
+	Name        string  `json:"name"`
+	Gender      string  `json:"gender"`
+	Probability float64 `json:"probability"`
+	Count       int     `json:"count"`
+}
@@ -0,0 +1,118 @@
+// Package golden compares responses of the skill to the golden responses
+// recorded in testdata/golden. Speech is phrased at random among variants, so
+// only the shape of responses is compared: their fields, the kinds of their
+// values, their types and booleans
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// keptStrings are the keys whose string values are part of the shape of a response
+var keptStrings = map[string]bool{
+	"type":          true,
+	"slotToElicit":  true,
+	"slotToConfirm": true,
+	"permissions":   true,
+	"playBehavior":  true,
+	"clearBehavior": true,
+}
+
+// Check compares the shape of the json response to the golden response in file
+func Check(file string, response []byte) error {
+	var actual interface{}
+	if err := json.Unmarshal(response, &actual); err != nil {
+		return fmt.Errorf("malformed response: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("no golden response, run cmd/golden with -update to record it: %v", err)
+	}
+	var golden interface{}
+	if err := json.Unmarshal(data, &golden); err != nil {
+		return fmt.Errorf("malformed golden response: %v", err)
+	}
+	return Compare(Shape(golden), Shape(actual))
+}
+
+// Shape flattens value into sorted lines describing each of its leaves by
+// path, e.g. `response.directives[0].type = "Dialog.ElicitSlot"`. Only the
+// kind of most strings and numbers is kept, see keptStrings, and only the
+// names of the session attributes
+func Shape(value interface{}) []string {
+	var lines []string
+	var walk func(path string, key string, value interface{})
+	walk = func(path string, key string, value interface{}) {
+		// session attributes are the state of the skill, which may differ
+		// from a run to the other, so only the attributes set are compared
+		if parent, _, nested := strings.Cut(path, "."); parent == "sessionAttributes" && nested {
+			lines = append(lines, path+" is set")
+			return
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				lines = append(lines, path+" = {}")
+			}
+			for child, item := range v {
+				walk(strings.TrimPrefix(path+"."+child, "."), child, item)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				lines = append(lines, path+" = []")
+			}
+			for i, item := range v {
+				// the items of a list are described under the key of the list
+				walk(fmt.Sprintf("%s[%d]", path, i), key, item)
+			}
+		case string:
+			if keptStrings[key] {
+				lines = append(lines, fmt.Sprintf("%s = %q", path, v))
+			} else {
+				lines = append(lines, path+" = string")
+			}
+		case float64:
+			lines = append(lines, path+" = number")
+		case bool:
+			lines = append(lines, fmt.Sprintf("%s = %t", path, v))
+		case nil:
+			lines = append(lines, path+" = null")
+		}
+	}
+	walk("", "", value)
+	sort.Strings(lines)
+	return lines
+}
+
+// Compare returns an error listing the lines only in golden, prefixed
+// with "-", and those only in actual, prefixed with "+"
+func Compare(golden []string, actual []string) error {
+	count := map[string]int{}
+	for _, line := range golden {
+		count[line]++
+	}
+	for _, line := range actual {
+		count[line]--
+	}
+	var diff []string
+	for _, line := range golden {
+		if count[line] > 0 {
+			diff = append(diff, "- "+line)
+			count[line]--
+		}
+	}
+	for _, line := range actual {
+		if count[line] < 0 {
+			diff = append(diff, "+ "+line)
+			count[line]++
+		}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(diff, "\n"))
+}
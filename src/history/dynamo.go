@@ -0,0 +1,100 @@
+package history
+
+import (
+	"alexa-skill-test/src/erase"
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Dynamo stores the guesses in a DynamoDB table having the string partition
+// key "userId" and the string sort key "guessedAt", holding RFC 3339 times,
+// and the index erase.AccountIndex
+type Dynamo struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// Save remembers guess for the user
+func (store Dynamo) Save(ctx context.Context, userID string, guess Guess) error {
+	countries := make([]types.AttributeValue, 0, len(guess.Countries))
+	for _, code := range guess.Countries {
+		countries = append(countries, &types.AttributeValueMemberS{Value: code})
+	}
+	_, err := store.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item: map[string]types.AttributeValue{
+			"userId":               &types.AttributeValueMemberS{Value: userID},
+			erase.AccountAttribute: &types.AttributeValueMemberS{Value: erase.AccountOf(userID)},
+			"guessedAt":            &types.AttributeValueMemberS{Value: guess.GuessedAt.UTC().Format(time.RFC3339Nano)},
+			"name":                 &types.AttributeValueMemberS{Value: guess.Name},
+			"countries":            &types.AttributeValueMemberL{Value: countries},
+		},
+	})
+	return err
+}
+
+// Last returns the most recent guess of the user, false if they have none
+func (store Dynamo) Last(ctx context.Context, userID string) (Guess, bool, error) {
+	output, err := store.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(store.Table),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil || len(output.Items) == 0 {
+		return Guess{}, false, err
+	}
+
+	item := output.Items[0]
+	var guess Guess
+	if name, ok := item["name"].(*types.AttributeValueMemberS); ok {
+		guess.Name = name.Value
+	}
+	if guessedAt, ok := item["guessedAt"].(*types.AttributeValueMemberS); ok {
+		guess.GuessedAt, _ = time.Parse(time.RFC3339Nano, guessedAt.Value)
+	}
+	if countries, ok := item["countries"].(*types.AttributeValueMemberL); ok {
+		for _, code := range countries.Value {
+			if code, ok := code.(*types.AttributeValueMemberS); ok {
+				guess.Countries = append(guess.Countries, code.Value)
+			}
+		}
+	}
+	return guess, true, nil
+}
+
+// Clear forgets every guess of the user
+func (store Dynamo) Clear(ctx context.Context, userID string) error {
+	paginator := dynamodb.NewQueryPaginator(store.Client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.Table),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userID},
+		},
+		ProjectionExpression: aws.String("userId, guessedAt"),
+	})
+	var deletes []types.WriteRequest
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: item}})
+		}
+	}
+
+	return erase.Delete(ctx, store.Client, store.Table, deletes)
+}
+
+// Forget deletes the guesses of every person of the account userID
+func (store Dynamo) Forget(ctx context.Context, userID string) error {
+	return erase.Account(ctx, store.Client, store.Table, []string{"userId", "guessedAt"}, userID)
+}
@@ -0,0 +1,37 @@
+// Package history remembers the names each user asked the skill to guess
+// across sessions, so they can ask what their last guess was
+package history
+
+import (
+	"context"
+	"time"
+)
+
+// Guess is a name the skill guessed the nationality of
+type Guess struct {
+	Name string
+	// Countries are the codes of the most likely countries, most likely first
+	Countries []string
+	GuessedAt time.Time
+}
+
+// Store keeps the guesses of every user
+type Store interface {
+	// Save remembers guess for the user
+	Save(ctx context.Context, userID string, guess Guess) error
+	// Last returns the most recent guess of the user, false if they have none
+	Last(ctx context.Context, userID string) (Guess, bool, error)
+	// Clear forgets every guess of the user
+	Clear(ctx context.Context, userID string) error
+	// Forget deletes the guesses of every person of the account userID
+	Forget(ctx context.Context, userID string) error
+}
+
+// Key returns the id guesses are stored under for the speaker personID of the
+// account userID, so each member of a household has their own history
+func Key(userID string, personID string) string {
+	if personID == "" {
+		return userID
+	}
+	return userID + "#" + personID
+}
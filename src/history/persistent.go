@@ -0,0 +1,60 @@
+package history
+
+import (
+	"alexa-skill-test/src/storage"
+	"context"
+	"time"
+)
+
+// persistentKey is the persistent attribute keeping the guesses
+const persistentKey = "history"
+
+// persistentMax is the number of guesses kept in the persistent attributes,
+// the oldest being dropped first, so the attributes of a user stay small
+const persistentMax = 50
+
+// persistentGuess is a guess as it is kept in the persistent attributes
+type persistentGuess struct {
+	Name      string    `json:"name"`
+	Countries []string  `json:"countries,omitempty"`
+	GuessedAt time.Time `json:"guessedAt"`
+}
+
+// Persistent stores the guesses in the persistent attributes of each user,
+// oldest first
+type Persistent struct {
+	Attributes storage.PersistentAttributes
+}
+
+// Save remembers guess for the user
+func (store Persistent) Save(ctx context.Context, userID string, guess Guess) error {
+	var guesses []persistentGuess
+	if _, err := storage.Load(ctx, store.Attributes, userID, persistentKey, &guesses); err != nil {
+		return err
+	}
+	guesses = append(guesses, persistentGuess{Name: guess.Name, Countries: guess.Countries, GuessedAt: guess.GuessedAt.UTC()})
+	if len(guesses) > persistentMax {
+		guesses = guesses[len(guesses)-persistentMax:]
+	}
+	return storage.Update(ctx, store.Attributes, userID, persistentKey, guesses)
+}
+
+// Last returns the most recent guess of the user, false if they have none
+func (store Persistent) Last(ctx context.Context, userID string) (Guess, bool, error) {
+	var guesses []persistentGuess
+	if _, err := storage.Load(ctx, store.Attributes, userID, persistentKey, &guesses); err != nil || len(guesses) == 0 {
+		return Guess{}, false, err
+	}
+	last := guesses[len(guesses)-1]
+	return Guess{Name: last.Name, Countries: last.Countries, GuessedAt: last.GuessedAt}, true, nil
+}
+
+// Clear forgets every guess of the user
+func (store Persistent) Clear(ctx context.Context, userID string) error {
+	return storage.Update(ctx, store.Attributes, userID, persistentKey, nil)
+}
+
+// Forget deletes the guesses of every person of the account userID
+func (store Persistent) Forget(ctx context.Context, userID string) error {
+	return store.Attributes.Forget(ctx, userID)
+}
@@ -0,0 +1,87 @@
+// Package i18n holds the phrasing of the skill's responses in every
+// locale it supports, as message bundles keyed by message id
+package i18n
+
+import (
+	"alexa-skill-test/src/messages"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// DefaultLocale is the locale used for requests in an unsupported language,
+// its bundle also provides the messages missing from the other bundles
+const DefaultLocale = "en-US"
+
+// languageDefaults is the locale standing in for every locale of a language
+// that has no bundle of its own, e.g. de-DE for de-AT
+var languageDefaults = map[string]string{
+	"en": "en-US",
+	"de": "de-DE",
+	"fr": "fr-FR",
+	"es": "es-ES",
+	"ja": "ja-JP",
+}
+
+// bundles holds the messages of every embedded locale as they are written
+var bundles = loadBundles()
+
+// loadBundles decodes the embedded bundles, which are
+// validated at build time so a failure here is a programming error
+func loadBundles() map[string]messages.Set {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic("i18n: unreadable locales: " + err.Error())
+	}
+	loaded := map[string]messages.Set{}
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic("i18n: unreadable bundle " + file.Name() + ": " + err.Error())
+		}
+		var bundle messages.Set
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			panic("i18n: malformed bundle " + file.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = bundle
+	}
+	return loaded
+}
+
+// Language returns the language of locale, e.g. "de" for "de-DE"
+func Language(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return strings.ToLower(language)
+}
+
+// Supported reports whether the skill speaks the language of locale,
+// requests in any other language are answered in DefaultLocale
+func Supported(locale string) bool {
+	_, ok := languageDefaults[Language(locale)]
+	return ok
+}
+
+// Locales lists the locales having a bundle
+func Locales() []string {
+	var locales []string
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// For returns the messages of locale. Messages missing from its bundle
+// come from the default locale of its language, then from DefaultLocale
+func For(locale string) messages.Set {
+	set := bundles[DefaultLocale]
+	if fallback, ok := languageDefaults[Language(locale)]; ok {
+		set = set.Merge(bundles[fallback])
+	}
+	return set.Merge(bundles[locale])
+}
@@ -0,0 +1,139 @@
+package leaderboard
+
+import (
+	"alexa-skill-test/src/erase"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// distributionKey is the partition holding one counter per percentage,
+// alongside the partitions of the households
+const distributionKey = "#distribution"
+
+// Dynamo stores the leaderboard in a DynamoDB table having the string
+// partition key "userId" and the string sort key "personId"
+type Dynamo struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// Record saves the score of the person when it beats their best, and counts
+// it in the distribution of all scores either way
+func (store Dynamo) Record(ctx context.Context, entry Entry) error {
+	_, err := store.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(store.Table),
+		Key: map[string]types.AttributeValue{
+			"userId":   &types.AttributeValueMemberS{Value: entry.UserID},
+			"personId": &types.AttributeValueMemberS{Value: entry.PersonID},
+		},
+		// percent is a reserved word of DynamoDB expressions
+		UpdateExpression:         aws.String("SET #percent = :percent, playedAt = :playedAt"),
+		ConditionExpression:      aws.String("attribute_not_exists(#percent) OR #percent < :percent"),
+		ExpressionAttributeNames: map[string]string{"#percent": "percent"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":percent":  &types.AttributeValueMemberN{Value: strconv.Itoa(entry.Percent)},
+			":playedAt": &types.AttributeValueMemberS{Value: entry.PlayedAt.UTC().Format(time.RFC3339)},
+		},
+	})
+	var notBest *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &notBest) {
+		return err
+	}
+
+	_, err = store.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(store.Table),
+		Key: map[string]types.AttributeValue{
+			"userId":   &types.AttributeValueMemberS{Value: distributionKey},
+			"personId": &types.AttributeValueMemberS{Value: strconv.Itoa(entry.Percent)},
+		},
+		UpdateExpression: aws.String("ADD quizzes :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	return err
+}
+
+// Household returns the best score of every member of the household of userID
+func (store Dynamo) Household(ctx context.Context, userID string) ([]Entry, error) {
+	items, err := store.query(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, item := range items {
+		playedAt, _ := time.Parse(time.RFC3339, stringValue(item["playedAt"]))
+		entries = append(entries, Entry{
+			UserID:   userID,
+			PersonID: stringValue(item["personId"]),
+			Percent:  numberValue(item["percent"]),
+			PlayedAt: playedAt,
+		})
+	}
+	return entries, nil
+}
+
+// Distribution returns how many quizzes ended with each percentage of correct answers
+func (store Dynamo) Distribution(ctx context.Context) (map[int]int, error) {
+	items, err := store.query(ctx, distributionKey)
+	if err != nil {
+		return nil, err
+	}
+	distribution := map[int]int{}
+	for _, item := range items {
+		percent, err := strconv.Atoi(stringValue(item["personId"]))
+		if err != nil {
+			continue
+		}
+		distribution[percent] = numberValue(item["quizzes"])
+	}
+	return distribution, nil
+}
+
+// query returns every item of the partition userID
+func (store Dynamo) query(ctx context.Context, userID string) ([]map[string]types.AttributeValue, error) {
+	paginator := dynamodb.NewQueryPaginator(store.Client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.Table),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	var items []map[string]types.AttributeValue
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+	}
+	return items, nil
+}
+
+// stringValue returns the value of a string attribute, or an empty string
+func stringValue(value types.AttributeValue) string {
+	if s, ok := value.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+// numberValue returns the value of a whole number attribute, or zero
+func numberValue(value types.AttributeValue) int {
+	if n, ok := value.(*types.AttributeValueMemberN); ok {
+		number, _ := strconv.Atoi(n.Value)
+		return number
+	}
+	return 0
+}
+
+// Forget deletes the scores of the household of userID
+func (store Dynamo) Forget(ctx context.Context, userID string) error {
+	return erase.Partition(ctx, store.Client, store.Table, []string{"userId", "personId"}, userID)
+}
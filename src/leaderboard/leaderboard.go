@@ -0,0 +1,70 @@
+// Package leaderboard keeps the best quiz score of every member of a
+// household, along with how all quiz scores are distributed so a player
+// can be told how they compare to everyone else
+package leaderboard
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Entry is the best quiz score of a member of a household
+type Entry struct {
+	// UserID is the account of the household
+	UserID string
+	// PersonID is the recognized speaker, or the account owner
+	PersonID string
+	// Percent is the share of questions answered correctly, from 0 to 100
+	Percent  int
+	PlayedAt time.Time
+}
+
+// Store keeps the leaderboard
+type Store interface {
+	// Record saves the result of a quiz, keeping the best score of the person
+	Record(ctx context.Context, entry Entry) error
+	// Household returns the best score of every member of the household of userID
+	Household(ctx context.Context, userID string) ([]Entry, error)
+	// Distribution returns how many quizzes ended with each percentage of correct answers
+	Distribution(ctx context.Context) (map[int]int, error)
+	// Forget deletes the scores of the household of userID. They stay
+	// counted in the distribution, which doesn't tell whose they were
+	Forget(ctx context.Context, userID string) error
+}
+
+// Percent returns the share of the rounds answered correctly, from 0 to 100
+func Percent(score int, rounds int) int {
+	if rounds <= 0 {
+		return 0
+	}
+	return score * 100 / rounds
+}
+
+// Percentile returns the share of quizzes, from 0 to 100, that ended with
+// fewer correct answers than percent according to distribution
+func Percentile(distribution map[int]int, percent int) int {
+	var below, total int
+	for bucket, count := range distribution {
+		total += count
+		if bucket < percent {
+			below += count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return below * 100 / total
+}
+
+// Top sorts entries from the best to the worst score and keeps the first limit
+func Top(entries []Entry, limit int) []Entry {
+	entries = append([]Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Percent > entries[j].Percent
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,92 @@
+// Package localskill runs the skill binary as a local http server
+// and sends it requests, so the skill can be exercised without
+// deploying it, as cmd/simulate and cmd/golden do
+package localskill
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OfflineEnv configures the skill to guess from its embedded
+// datasets only, instead of calling the upstream apis
+var OfflineEnv = []string{
+	"NATIONALITY_PROVIDER=offline",
+	"NATIONALITY_FALLBACK=",
+	"ENSEMBLE_PROVIDERS=",
+	"DISABLE_COUNTRY_API=true",
+}
+
+// startTimeout is how long the skill has to load its configuration and datasets
+const startTimeout = 10 * time.Second
+
+// Skill is a skill binary running as a local http server
+type Skill struct {
+	// Endpoint is the url Alexa requests are posted to
+	Endpoint string
+	cmd      *exec.Cmd
+}
+
+// Start runs binary serving http on a free local port, with env added to
+// its environment, and returns once it accepts connections. Requests aren't
+// verified since they aren't signed by Alexa. The logs of the skill are
+// written to logs, or dropped when it is nil
+func Start(binary string, env []string, logs io.Writer) (*Skill, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cmd := exec.Command(binary)
+	cmd.Env = append(os.Environ(), "HTTP_ADDR="+addr, "VERIFY_REQUESTS=false", "SKILL_IDS=")
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout, cmd.Stderr = logs, logs
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	skill := &Skill{Endpoint: "http://" + addr + "/alexa", cmd: cmd}
+
+	for deadline := time.Now().Add(startTimeout); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return skill, nil
+		}
+	}
+	skill.Stop()
+	return nil, errors.New("localskill: the skill didn't start listening in time")
+}
+
+// Stop stops the skill, letting it finish the requests in flight
+func (skill *Skill) Stop() {
+	skill.cmd.Process.Signal(os.Interrupt)
+	skill.cmd.Wait()
+}
+
+// Post sends the json request envelope to the alexa endpoint of a skill
+// and returns the json response. Statuses other than 200 OK are errors
+func Post(endpoint string, envelope []byte) ([]byte, error) {
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(envelope))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("localskill: the skill responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,57 @@
+// Package logging writes structured json logs. Lines logged while handling a
+// request carry the ids of that request, and the names users ask about are
+// redacted according to the configured pii mode
+package logging
+
+import (
+	"alexa-skill-test/src/pii"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NameKey is the attribute names are logged under, which is the only one
+// redacted, so every name must be logged under it and never in a message
+const NameKey = "name"
+
+// New returns a logger writing json lines of level and above to w,
+// with names redacted as mode says
+func New(w io.Writer, level slog.Level, mode pii.Mode) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == NameKey {
+				return slog.String(NameKey, mode.Redact(attr.Value.String()))
+			}
+			return attr
+		},
+	}))
+}
+
+// ParseLevel returns the level named by value, e.g. "debug" or "warn",
+// defaulting to info for unknown values
+func ParseLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// contextKey is the key the logger of a request is stored under in its context
+type contextKey struct{}
+
+// WithLogger returns ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+// when there is none, e.g. outside of a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
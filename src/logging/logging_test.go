@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"alexa-skill-test/src/pii"
+	"alexa-skill-test/src/upstream"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewRedactsNames(t *testing.T) {
+	const name = "Zoë"
+	tests := []struct {
+		mode pii.Mode
+		want string
+	}{
+		{pii.Full, name},
+		{pii.Hash, pii.Hash.Redact(name)},
+		{pii.None, "[omitted]"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			var buffer bytes.Buffer
+			logger := New(&buffer, slog.LevelInfo, test.mode)
+			// the error an http.Client returns carries the url, the name included
+			err := upstream.Transport("nationalize", &url.Error{
+				Op:  "Get",
+				URL: "https://api.nationalize.io/?name=" + url.QueryEscape(name),
+				Err: context.DeadlineExceeded,
+			})
+			logger.Error("nationality guess failed", NameKey, name, "error", err)
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+				t.Fatalf("log line %q isn't json: %v", buffer.String(), err)
+			}
+			if line[NameKey] != test.want {
+				t.Errorf("logged name %q, want %q", line[NameKey], test.want)
+			}
+			if test.mode != pii.Full && strings.Contains(buffer.String(), "Zo") {
+				t.Errorf("log line %q carries the name", buffer.String())
+			}
+			if !strings.Contains(line["error"].(string), "nationalize") {
+				t.Errorf("logged error %q doesn't name the service", line["error"])
+			}
+		})
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	if pii.Hash.Redact("Hans") != pii.Hash.Redact("hans") {
+		t.Error("hashes of the same name differ by case")
+	}
+	if pii.Hash.Redact("Hans") == pii.Hash.Redact("Anna") {
+		t.Error("hashes of different names are the same")
+	}
+}
+
+func TestNewFiltersLevel(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(&buffer, slog.LevelWarn, pii.Hash)
+	logger.Info("ignored")
+	if buffer.Len() != 0 {
+		t.Errorf("info line logged at warn level: %q", buffer.String())
+	}
+	logger.Warn("kept")
+	if !strings.Contains(buffer.String(), "kept") {
+		t.Errorf("warn line not logged: %q", buffer.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	logger := New(&bytes.Buffer{}, slog.LevelInfo, pii.None)
+	if FromContext(WithLogger(context.Background(), logger)) != logger {
+		t.Error("FromContext doesn't return the logger of the context")
+	}
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext doesn't default to the default logger")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for value, want := range map[string]slog.Level{"debug": slog.LevelDebug, " WARN ": slog.LevelWarn, "bogus": slog.LevelInfo} {
+		if got := ParseLevel(value); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
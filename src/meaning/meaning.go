@@ -0,0 +1,47 @@
+// Package meaning tells where first names come from and what they mean,
+// from an embedded etymology dataset or an etymology api
+package meaning
+
+import (
+	"alexa-skill-test/src/names"
+	_ "embed"
+	"strings"
+)
+
+//go:embed meanings.txt
+var embeddedMeanings string
+
+// Meaning is where a first name comes from and what it means
+type Meaning struct {
+	Name string `json:"name"`
+	// Origin is the language the name comes from. The embedded dataset gives
+	// the key of its "origin.<key>" message, e.g. "hebrew", while apis give
+	// it in words, in the language they were asked for
+	Origin string `json:"origin"`
+	// Meaning is what the name means, in english for the embedded dataset
+	Meaning string `json:"meaning"`
+}
+
+// embedded maps the folded names of the dataset to their meaning
+var embedded = func() map[string]Meaning {
+	meanings := map[string]Meaning{}
+	for _, line := range strings.Split(embeddedMeanings, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		meanings[names.Fold(fields[0])] = Meaning{Name: fields[0], Origin: fields[1], Meaning: strings.Join(fields[2:], " ")}
+	}
+	return meanings
+}()
+
+// Embedded returns the meaning of name from the embedded dataset, ignoring
+// case and accents
+func Embedded(name string) (Meaning, bool) {
+	meaning, ok := embedded[names.Fold(name)]
+	return meaning, ok
+}
@@ -0,0 +1,146 @@
+// Package messages holds the templates of the skill's responses and the
+// named variants overlaid on them, so different phrasings can be A/B
+// tested against each other
+package messages
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Set maps message ids to the templates of one phrasing variant.
+// Templates may contain placeholders such as {name} or {percent}.
+// A message may have a pool of alternative phrasings under the ids
+// "<id>#2", "<id>#3" and so on, one of which is chosen by Pick
+type Set map[string]string
+
+// alternative returns the id of the n-th phrasing of message id, n starting at 1
+func alternative(id string, n int) string {
+	if n == 1 {
+		return id
+	}
+	return id + "#" + strconv.Itoa(n)
+}
+
+// Control keeps the phrasing of the locale bundle as it is
+var Control = Set{}
+
+// Playful is a more casual english phrasing of the guess messages
+var Playful = Set{
+	"guess.heard":     "Got it, {name}!",
+	"guess.surprise":  "Let's go with {name}!",
+	"guess.none":      "Hmm, that name has me stumped. Try again with your friends' names!",
+	"guess.uncommon":  "I don't hear that name often, so take this with a grain of salt.",
+	"guess.lead":      "My hunch says there's a",
+	"guess.item":      "{percent} percent chance you're {demonym}.",
+	"guess.truncated": "Plus a few more I'll skip to keep it short.",
+	"guess.native":    "Or, as the locals put it, {native}!",
+	"guess.followup":  "Got another name for me? Say, what about, and then the name.",
+	"guess.reprompt":  "Try saying, what about Maria, or say stop if you're done.",
+	"fact.offer":      "Want a fun fact about {country}?",
+	"fact.reprompt":   "Want a fun fact about {country}? Just say yes or no.",
+	"fact.declined":   "Fair enough!",
+	"dialog.confused": "Hmm, I'm not sure what you're answering.",
+}
+
+// Variants lists the template sets in bucket order
+var Variants = []string{"control", "playful"}
+
+// Sets holds every template set by variant name
+var Sets = map[string]Set{
+	"control": Control,
+	"playful": Playful,
+}
+
+// Bucket deterministically assigns userID to one of buckets buckets
+func Bucket(userID string, buckets int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return int(hash.Sum32() % uint32(buckets))
+}
+
+// Merge returns a copy of set with the messages of overlay replacing its own.
+// A message of overlay replaces the whole pool of phrasings of set, so
+// alternatives of another locale or variant never leak into its phrasing
+func (set Set) Merge(overlay Set) Set {
+	merged := Set{}
+	for id, template := range set {
+		merged[id] = template
+	}
+	for id := range overlay {
+		if !strings.Contains(id, "#") {
+			for n := 2; merged[alternative(id, n)] != ""; n++ {
+				delete(merged, alternative(id, n))
+			}
+		}
+	}
+	for id, template := range overlay {
+		merged[id] = template
+	}
+	return merged
+}
+
+// Pick returns a copy of set where every message having a pool of phrasings
+// is one of them chosen using rng, so repeated responses don't sound identical
+func (set Set) Pick(rng *rand.Rand) Set {
+	picked := Set{}
+	for id, template := range set {
+		if !strings.Contains(id, "#") {
+			picked[id] = template
+		}
+	}
+	for id := range picked {
+		count := 1
+		for set[alternative(id, count+1)] != "" {
+			count++
+		}
+		if count > 1 {
+			picked[id] = set[alternative(id, rng.Intn(count)+1)]
+		}
+	}
+	return picked
+}
+
+// Select returns the variant userID is bucketed into along with its template set.
+// A non empty forced variant that exists is returned for every user instead
+func Select(userID string, forced string) (string, Set) {
+	if set, ok := Sets[forced]; ok {
+		return forced, set
+	}
+	variant := Variants[Bucket(userID, len(Variants))]
+	return variant, Sets[variant]
+}
+
+// Render returns the template of message id with its placeholders replaced
+// by values, given as pairs such as "name", "Ethan"
+func (set Set) Render(id string, values ...string) string {
+	template := set[id]
+	var pairs []string
+	for i := 0; i+1 < len(values); i += 2 {
+		pairs = append(pairs, "{"+values[i]+"}", values[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// Split returns the template of message id split into literal text and
+// placeholders such as "{percent}", so each part can be spoken differently
+func (set Set) Split(id string) []string {
+	template := set[id]
+	var parts []string
+	for template != "" {
+		start := strings.Index(template, "{")
+		end := strings.Index(template, "}")
+		if start < 0 || end < start {
+			parts = append(parts, template)
+			break
+		}
+		if start > 0 {
+			parts = append(parts, template[:start])
+		}
+		parts = append(parts, template[start:end+1])
+		template = template[end+1:]
+	}
+	return parts
+}
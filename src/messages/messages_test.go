@@ -0,0 +1,100 @@
+package messages
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBucketIsDeterministic(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		userID := fmt.Sprintf("amzn1.ask.account.%d", i)
+		bucket := Bucket(userID, 3)
+		if bucket < 0 || bucket >= 3 {
+			t.Fatalf("Bucket(%q, 3) = %d, out of range", userID, bucket)
+		}
+		if again := Bucket(userID, 3); again != bucket {
+			t.Errorf("Bucket(%q, 3) = %d then %d", userID, bucket, again)
+		}
+	}
+}
+
+func TestBucketSpreadsUsers(t *testing.T) {
+	counts := make([]int, len(Variants))
+	for i := 0; i < 1000; i++ {
+		counts[Bucket(fmt.Sprintf("amzn1.ask.account.%d", i), len(Variants))]++
+	}
+	for bucket, count := range counts {
+		if count < 400 {
+			t.Errorf("bucket %d got %d of 1000 users", bucket, count)
+		}
+	}
+}
+
+func TestSelectPerBucket(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		userID := fmt.Sprintf("amzn1.ask.account.%d", i)
+		want := Variants[Bucket(userID, len(Variants))]
+		variant, set := Select(userID, "")
+		if variant != want || !reflect.DeepEqual(set, Sets[want]) {
+			t.Errorf("Select(%q) = %s, want %s", userID, variant, want)
+		}
+	}
+}
+
+func TestSelectForcedVariant(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		userID := fmt.Sprintf("amzn1.ask.account.%d", i)
+		if variant, set := Select(userID, "playful"); variant != "playful" || set["guess.lead"] != Playful["guess.lead"] {
+			t.Errorf("Select(%q, playful) = %s", userID, variant)
+		}
+		// an unknown variant is ignored rather than serving no templates
+		if variant, _ := Select(userID, "shouty"); variant != Variants[Bucket(userID, len(Variants))] {
+			t.Errorf("Select(%q, shouty) = %s, want the bucketed variant", userID, variant)
+		}
+	}
+}
+
+func TestMergeReplacesPools(t *testing.T) {
+	set := Set{"greeting": "Hello", "greeting#2": "Hi", "farewell": "Bye"}
+	merged := set.Merge(Set{"greeting": "Howdy"})
+	want := Set{"greeting": "Howdy", "farewell": "Bye"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("Merge = %v, want %v", merged, want)
+	}
+	if set["greeting"] != "Hello" {
+		t.Errorf("Merge modified the set it was called on")
+	}
+}
+
+func TestPickChoosesFromPool(t *testing.T) {
+	set := Set{"greeting": "Hello", "greeting#2": "Hi", "greeting#3": "Hey"}
+	seen := map[string]bool{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		picked := set.Pick(rng)
+		if len(picked) != 1 {
+			t.Fatalf("Pick = %v, want the alternatives left out", picked)
+		}
+		seen[picked["greeting"]] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Pick chose %v, want every phrasing of the pool", seen)
+	}
+}
+
+func TestRender(t *testing.T) {
+	set := Set{"guess.item": "{percent} percent {demonym}"}
+	if got := set.Render("guess.item", "percent", "45", "demonym", "German"); got != "45 percent German" {
+		t.Errorf("Render = %q", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	set := Set{"guess.item": "a {percent} percent chance {name}"}
+	want := []string{"a ", "{percent}", " percent chance ", "{name}"}
+	if got := set.Split("guess.item"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+// Package messaging authenticates the messages backend jobs send the skill
+// out of session through the Skill Messaging API. Alexa only checks the
+// jobs hold the credentials of the skill, so each message is also signed
+// with a key shared with the jobs, for the user it is sent to, and refused
+// once it is too old to be anything but a replay
+package messaging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Types of the messages the skill handles
+const (
+	// CacheRefresh drops the cached upstream responses and fetches the
+	// usual countries again, e.g. after a dataset was corrected
+	CacheRefresh = "cache.refresh"
+	// DeletionConfirmed tells the data of the user was erased elsewhere, e.g.
+	// through the website, so the skill wipes whatever it still stores
+	DeletionConfirmed = "deletion.confirmed"
+	// ScheduleReminder asks to set a reminder for the user
+	ScheduleReminder = "reminder.schedule"
+)
+
+var (
+	// ErrUnsigned means the message carries no signature
+	ErrUnsigned = errors.New("messaging: message isn't signed")
+	// ErrSignature means the message wasn't signed with the shared key, was
+	// altered or was signed for another user
+	ErrSignature = errors.New("messaging: signature mismatch")
+	// ErrExpired means the message was issued too long ago, or too far in the future
+	ErrExpired = errors.New("messaging: message expired")
+)
+
+// Message is a signed message sent by a backend job
+type Message struct {
+	Type string `json:"type"`
+	// IssuedAt is when the message was signed, in unix seconds
+	IssuedAt int64 `json:"issuedAt"`
+	// Payload is the json of the data of the message. It is kept as a string
+	// so the bytes signed are the bytes received
+	Payload   string `json:"payload,omitempty"`
+	Signature string `json:"signature"`
+}
+
+// Sign returns the message of type kind carrying payload for the user userID,
+// signed with key at issuedAt
+func Sign(key []byte, userID string, kind string, payload string, issuedAt time.Time) Message {
+	message := Message{Type: kind, IssuedAt: issuedAt.Unix(), Payload: payload}
+	message.Signature = message.sign(key, userID)
+	return message
+}
+
+// Verify returns the message held by data, the message of a
+// Messaging.MessageReceived request sent to the user userID, once it checked
+// the message was signed with key for that user no more than maxAge before now.
+// A message signed for someone else is refused, so it can't be replayed
+// against other users
+func Verify(key []byte, userID string, data map[string]interface{}, maxAge time.Duration, now time.Time) (Message, error) {
+	var message Message
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return message, err
+	}
+	if err := json.Unmarshal(encoded, &message); err != nil {
+		return message, err
+	}
+	if message.Signature == "" {
+		return message, ErrUnsigned
+	}
+	if !hmac.Equal([]byte(message.Signature), []byte(message.sign(key, userID))) {
+		return message, ErrSignature
+	}
+	age := now.Sub(time.Unix(message.IssuedAt, 0))
+	if age > maxAge || age < -maxAge {
+		return message, ErrExpired
+	}
+	return message, nil
+}
+
+// Decode decodes the payload of message into target
+func (message Message) Decode(target interface{}) error {
+	if message.Payload == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(message.Payload), target)
+}
+
+// sign returns the hex encoded signature of the user, type, time and payload of message
+func (message Message) sign(key []byte, userID string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(userID + "\n" + message.Type + "\n" + strconv.FormatInt(message.IssuedAt, 10) + "\n" + message.Payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
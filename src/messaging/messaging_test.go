@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	key     = []byte("shared key")
+	issued  = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	maxAge  = 5 * time.Minute
+	user    = "amzn1.ask.account.anna"
+	payload = `{"reason":"deleted on the website"}`
+)
+
+// received returns message as the skill receives it in a Messaging.MessageReceived request
+func received(t *testing.T, message Message) map[string]interface{} {
+	t.Helper()
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVerify(t *testing.T) {
+	signed := Sign(key, user, DeletionConfirmed, payload, issued)
+	tampered := func(change func(message *Message)) Message {
+		message := signed
+		change(&message)
+		return message
+	}
+
+	tests := map[string]struct {
+		message Message
+		user    string
+		key     []byte
+		now     time.Time
+		err     error
+	}{
+		"signed":             {message: signed, user: user, key: key, now: issued.Add(time.Minute)},
+		"at the maximum age": {message: signed, user: user, key: key, now: issued.Add(maxAge)},
+		"slightly ahead":     {message: signed, user: user, key: key, now: issued.Add(-time.Minute)},
+		"unsigned": {
+			message: tampered(func(message *Message) { message.Signature = "" }),
+			user:    user, key: key, now: issued, err: ErrUnsigned,
+		},
+		"other key": {message: signed, user: user, key: []byte("other key"), now: issued, err: ErrSignature},
+		"tampered payload": {
+			message: tampered(func(message *Message) { message.Payload = `{"reason":"forged"}` }),
+			user:    user, key: key, now: issued, err: ErrSignature,
+		},
+		"tampered type": {
+			message: tampered(func(message *Message) { message.Type = CacheRefresh }),
+			user:    user, key: key, now: issued, err: ErrSignature,
+		},
+		"tampered time": {
+			message: tampered(func(message *Message) { message.IssuedAt += 60 }),
+			user:    user, key: key, now: issued, err: ErrSignature,
+		},
+		"other user": {message: signed, user: "amzn1.ask.account.ben", key: key, now: issued, err: ErrSignature},
+		"expired":    {message: signed, user: user, key: key, now: issued.Add(maxAge + time.Second), err: ErrExpired},
+		"too far ahead": {
+			message: signed, user: user, key: key, now: issued.Add(-maxAge - time.Second), err: ErrExpired,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message, err := Verify(test.key, test.user, received(t, test.message), maxAge, test.now)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Verify = %v, want %v", err, test.err)
+			}
+			if err == nil && message != signed {
+				t.Errorf("Verify = %+v, want %+v", message, signed)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	if err := Sign(key, user, DeletionConfirmed, payload, issued).Decode(&reason); err != nil || reason.Reason != "deleted on the website" {
+		t.Errorf("Decode = %q, %v, want the reason of the payload", reason.Reason, err)
+	}
+	if err := Sign(key, user, CacheRefresh, "", issued).Decode(&reason); err != nil {
+		t.Errorf("Decode without payload = %v", err)
+	}
+}
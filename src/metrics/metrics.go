@@ -0,0 +1,62 @@
+// Package metrics publishes measurements to CloudWatch by logging them in the
+// embedded metric format, which lambda turns into metrics without any api call
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Units of the measurements
+const (
+	Count        = "Count"
+	Milliseconds = "Milliseconds"
+	None         = "None"
+)
+
+// Recorder logs measurements under a CloudWatch namespace. It is safe for concurrent use
+type Recorder struct {
+	Namespace string
+	// Out receives one json line per measurement, standard output by default
+	Out   io.Writer
+	mutex sync.Mutex
+}
+
+// New returns a recorder logging measurements under namespace to standard output
+func New(namespace string) *Recorder {
+	return &Recorder{Namespace: namespace, Out: os.Stdout}
+}
+
+// Record logs the measurement called name, with dimensions as pairs
+// such as "Provider", "nationalize" to tell apart its series
+func (recorder *Recorder) Record(name string, value float64, unit string, dimensions ...string) {
+	line := map[string]interface{}{name: value}
+	var keys []string
+	for i := 0; i+1 < len(dimensions); i += 2 {
+		line[dimensions[i]] = dimensions[i+1]
+		keys = append(keys, dimensions[i])
+	}
+	directive := map[string]interface{}{
+		"Namespace": recorder.Namespace,
+		"Metrics":   []map[string]string{{"Name": name, "Unit": unit}},
+	}
+	if len(keys) > 0 {
+		directive["Dimensions"] = [][]string{keys}
+	}
+	line["_aws"] = map[string]interface{}{
+		"Timestamp":         time.Now().UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []interface{}{directive},
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	fmt.Fprintln(recorder.Out, string(data))
+}
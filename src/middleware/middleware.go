@@ -0,0 +1,133 @@
+// Package middleware composes cross-cutting concerns such as logging,
+// panic recovery, deadlines and skill id validation around the intent dispatcher,
+// so that no intent handler has to deal with them
+package middleware
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Handler responds to an alexa request, giving up on any work left once ctx is done
+type Handler func(ctx context.Context, request alexa.Request) (alexa.Response, error)
+
+// Middleware wraps a handler with behaviour run around it
+type Middleware func(next Handler) Handler
+
+// ErrUnknownSkill is returned for requests sent to a skill id that isn't allowed
+var ErrUnknownSkill = errors.New("middleware: request sent to an unknown skill id")
+
+// Chain wraps handler with middlewares, the first one being the outermost
+// so Chain(h, a, b) runs a, then b, then h
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Logging gives the handlers a logger tagging every line with the request and
+// session ids and the intent of the request, see logging.FromContext, and logs
+// the outcome of every request along with how long it took
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+			requestLogger := logger.With(
+				"request_id", request.Body.RequestID,
+				"session_id", request.Session.SessionID,
+				"type", request.Body.Type,
+				"intent", request.Body.Intent.Name,
+			)
+			start := time.Now()
+			response, err := next(logging.WithLogger(ctx, requestLogger), request)
+			latency := slog.Int64("latency_ms", time.Since(start).Milliseconds())
+			if err != nil {
+				requestLogger.Warn("request failed", latency, "error", err)
+			} else {
+				requestLogger.Info("request handled", latency)
+			}
+			return response, err
+		}
+	}
+}
+
+// Recovery turns a panic in the handlers into the fallback response,
+// logging the stack so the bug can be found
+func Recovery(fallback func(ctx context.Context, request alexa.Request) alexa.Response) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request alexa.Request) (response alexa.Response, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logging.FromContext(ctx).Error("recovered from panic", "panic", recovered, "stack", string(debug.Stack()))
+					response, err = fallback(ctx, request), nil
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// SkillID rejects requests sent to a skill other than the ones in ids.
+// Every request is accepted when ids is empty
+func SkillID(ids []string) Middleware {
+	allowed := map[string]bool{}
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+			if len(allowed) > 0 && !allowed[skillIDOf(request)] {
+				return alexa.Response{}, ErrUnknownSkill
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// skillIDOf returns the id of the skill request was sent to. Requests
+// outside of a session, e.g. from the AudioPlayer, only carry it in the context
+func skillIDOf(request alexa.Request) string {
+	if id := request.Context.System.Application.ApplicationID; id != "" {
+		return id
+	}
+	return request.Session.Application.ApplicationID
+}
+
+// Deadline bounds the handling of every request by timeout, ending it margin
+// before the deadline of the incoming context when that comes first, so
+// a response is sent before Alexa or lambda give up on the request
+func Deadline(timeout time.Duration, margin time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+			deadline := time.Now().Add(timeout)
+			if parent, ok := ctx.Deadline(); ok && parent.Add(-margin).Before(deadline) {
+				deadline = parent.Add(-margin)
+			}
+			ctx, cancel := context.WithDeadline(ctx, deadline)
+			defer cancel()
+			return next(ctx, request)
+		}
+	}
+}
+
+// Metrics reports the intent, duration and outcome of every request to record.
+// Requests without an intent, such as LaunchRequest, are reported by their type
+func Metrics(record func(intent string, elapsed time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+			start := time.Now()
+			response, err := next(ctx, request)
+			name := request.Body.Intent.Name
+			if name == "" {
+				name = request.Body.Type
+			}
+			record(name, time.Since(start), err)
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"alexa-skill-test/src/alexa"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetricsReportsEveryRequest(t *testing.T) {
+	failed := errors.New("failed")
+	tests := map[string]struct {
+		kind, intent string
+		err          error
+		want         string
+	}{
+		"intent":         {kind: "IntentRequest", intent: "GuessIntent", want: "GuessIntent"},
+		"without intent": {kind: "LaunchRequest", want: "LaunchRequest"},
+		"failed request": {kind: "IntentRequest", intent: "GuessIntent", err: failed, want: "GuessIntent"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var request alexa.Request
+			request.Body.Type = test.kind
+			request.Body.Intent.Name = test.intent
+
+			var reported string
+			var reportedErr error
+			handler := Chain(func(ctx context.Context, request alexa.Request) (alexa.Response, error) {
+				return alexa.Response{}, test.err
+			}, Metrics(func(intent string, elapsed time.Duration, err error) {
+				reported, reportedErr = intent, err
+			}))
+			if _, err := handler(context.Background(), request); err != test.err {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if reported != test.want || reportedErr != test.err {
+				t.Errorf("reported %q, %v, want %q, %v", reported, reportedErr, test.want, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package model declares the interaction model of the skill. Each handler
+// declares the intent it handles next to it, as a package level Intent
+// literal, and cmd/model generates the model the Alexa developer console
+// takes from those declarations so the two can't drift apart
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Intent declares an intent along with the slots it reads
+// and the sample utterances invoking it
+type Intent struct {
+	Name  string
+	Slots []Slot
+	// Samples are the utterances invoking the intent,
+	// referring to slots by their name in braces, e.g. "what about {first_name}"
+	Samples []string
+}
+
+// Slot declares a slot of an intent
+type Slot struct {
+	Name string
+	// Type is a built-in slot type such as "AMAZON.FirstName" or a declared SlotType
+	Type string
+	// Samples are the utterances answering a prompt for the slot
+	Samples []string
+}
+
+// SlotType declares a custom slot type and its values
+type SlotType struct {
+	Name   string
+	Values []Value
+}
+
+// Value is a value of a custom slot type, resolved to ID from its name or any synonym
+type Value struct {
+	ID       string
+	Name     string
+	Synonyms []string
+}
+
+// Document is the interaction model as the Alexa developer console and the ask cli take it
+type Document struct {
+	InteractionModel InteractionModel `json:"interactionModel"`
+}
+
+// InteractionModel holds the language model of a locale
+type InteractionModel struct {
+	LanguageModel LanguageModel `json:"languageModel"`
+}
+
+// LanguageModel is the invocation name, the intents and the custom slot types of a locale
+type LanguageModel struct {
+	InvocationName string         `json:"invocationName"`
+	Intents        []jsonIntent   `json:"intents"`
+	Types          []jsonSlotType `json:"types,omitempty"`
+}
+
+type jsonIntent struct {
+	Name    string     `json:"name"`
+	Slots   []jsonSlot `json:"slots,omitempty"`
+	Samples []string   `json:"samples"`
+}
+
+type jsonSlot struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+type jsonSlotType struct {
+	Name   string      `json:"name"`
+	Values []jsonValue `json:"values"`
+}
+
+type jsonValue struct {
+	ID   string `json:"id,omitempty"`
+	Name struct {
+		Value    string   `json:"value"`
+		Synonyms []string `json:"synonyms,omitempty"`
+	} `json:"name"`
+}
+
+// Required are the built-in intents every skill must declare
+var Required = []Intent{
+	{Name: "AMAZON.CancelIntent"},
+	{Name: "AMAZON.HelpIntent"},
+	{Name: "AMAZON.StopIntent"},
+	{Name: "AMAZON.NavigateHomeIntent"},
+	{Name: "AMAZON.FallbackIntent"},
+}
+
+// Build returns the interaction model invoked by invocation, made of intents,
+// the Required ones and the slot types. Intents and types are sorted by name
+// so the generated model only changes when the declarations do
+func Build(invocation string, intents []Intent, types []SlotType) Document {
+	declared := map[string]Intent{}
+	for _, intent := range append(append([]Intent(nil), Required...), intents...) {
+		// a declaration replaces the bare required intent, e.g. to add samples to the help intent
+		declared[intent.Name] = intent
+	}
+
+	model := LanguageModel{InvocationName: invocation, Intents: []jsonIntent{}}
+	for _, intent := range declared {
+		converted := jsonIntent{Name: intent.Name, Samples: intent.Samples}
+		if converted.Samples == nil {
+			converted.Samples = []string{}
+		}
+		for _, slot := range intent.Slots {
+			converted.Slots = append(converted.Slots, jsonSlot{Name: slot.Name, Type: slot.Type, Samples: slot.Samples})
+		}
+		model.Intents = append(model.Intents, converted)
+	}
+	sort.Slice(model.Intents, func(i, j int) bool { return model.Intents[i].Name < model.Intents[j].Name })
+
+	for _, slotType := range types {
+		converted := jsonSlotType{Name: slotType.Name, Values: []jsonValue{}}
+		for _, value := range slotType.Values {
+			var v jsonValue
+			v.ID, v.Name.Value, v.Name.Synonyms = value.ID, value.Name, value.Synonyms
+			converted.Values = append(converted.Values, v)
+		}
+		model.Types = append(model.Types, converted)
+	}
+	sort.Slice(model.Types, func(i, j int) bool { return model.Types[i].Name < model.Types[j].Name })
+
+	return Document{InteractionModel: InteractionModel{LanguageModel: model}}
+}
+
+// Check reports the first mistake in the declarations that the developer
+// console would reject: an intent declared twice, a sample referring to an
+// undeclared slot or a slot of an undeclared custom type
+func Check(intents []Intent, types []SlotType) error {
+	declaredTypes := map[string]bool{}
+	for _, slotType := range types {
+		declaredTypes[slotType.Name] = true
+	}
+	seen := map[string]bool{}
+	for _, intent := range intents {
+		if seen[intent.Name] {
+			return fmt.Errorf("intent %s is declared twice", intent.Name)
+		}
+		seen[intent.Name] = true
+
+		slots := map[string]bool{}
+		for _, slot := range intent.Slots {
+			if !strings.HasPrefix(slot.Type, "AMAZON.") && !declaredTypes[slot.Type] {
+				return fmt.Errorf("slot %s of %s has the undeclared type %q", slot.Name, intent.Name, slot.Type)
+			}
+			slots[slot.Name] = true
+		}
+		for _, sample := range intent.Samples {
+			for _, match := range slotReference.FindAllStringSubmatch(sample, -1) {
+				if !slots[match[1]] {
+					return fmt.Errorf("sample %q of %s refers to the undeclared slot %s", sample, intent.Name, match[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// slotReference matches the slots samples refer to, e.g. "{first_name}"
+var slotReference = regexp.MustCompile(`\{(\w+)\}`)
@@ -0,0 +1,60 @@
+package names
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed blocked.txt
+var embeddedBlocklist string
+
+// Blocklist is a set of names, folded by fold, that the skill refuses to guess
+type Blocklist map[string]bool
+
+// NewBlocklist builds a blocklist from the embedded list of names
+// along with any extra names configured by the operator
+func NewBlocklist(extra []string) Blocklist {
+	blocklist := Blocklist{}
+	for _, line := range strings.Split(embeddedBlocklist, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[fold(line)] = true
+	}
+	for _, name := range extra {
+		if name = strings.TrimSpace(name); name != "" {
+			blocklist[fold(name)] = true
+		}
+	}
+	return blocklist
+}
+
+// Contains reports whether name, or any single word of it, is blocked,
+// ignoring case and diacritics so a blocked name can't be spelled around
+func (blocklist Blocklist) Contains(name string) bool {
+	name = fold(name)
+	if blocklist[name] {
+		return true
+	}
+	for _, word := range strings.Fields(name) {
+		if blocklist[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// fold lowercases name and strips the diacritics of its letters, e.g. "Zoë" as "zoe"
+func fold(name string) string {
+	var builder strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		if !unicode.Is(unicode.Mn, r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
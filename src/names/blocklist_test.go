@@ -0,0 +1,34 @@
+package names
+
+import "testing"
+
+func TestBlocklistContains(t *testing.T) {
+	blocklist := NewBlocklist([]string{" Voldemort ", "Zoë", ""})
+	tests := map[string]bool{
+		"bitch":          true,
+		"BITCH":          true,
+		"Bítch":          true,
+		"bi\u0301tch":    true,
+		"voldemort":      true,
+		"Lord Voldemort": true,
+		"Zoë":            true,
+		"ZOE":            true,
+		"Hans":           false,
+		"Bitchy":         false,
+		"":               false,
+	}
+	for name, want := range tests {
+		if got := blocklist.Contains(name); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestBlocklistSkipsComments(t *testing.T) {
+	blocklist := NewBlocklist(nil)
+	for name := range blocklist {
+		if name == "" || name[0] == '#' {
+			t.Errorf("blocklist holds %q", name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package names
+
+import (
+	_ "embed"
+	"sort"
+	"strings"
+)
+
+//go:embed catalog.txt
+var embeddedCatalog string
+
+// SlotType is the name of the custom slot type of first names the model
+// generator builds from the catalog
+const SlotType = "FirstName"
+
+// Entry is a first name of the catalog along with its synonyms
+type Entry struct {
+	Name string
+	// Synonyms are nicknames and other spellings resolving to Name
+	Synonyms []string
+}
+
+// Catalog lists the first names the interaction model resolves, sorted by
+// name: the names of catalog.txt along with every common name
+var Catalog = func() []Entry {
+	entries := map[string]Entry{}
+	for _, line := range strings.Split(embeddedCatalog, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		entries[strings.ToLower(fields[0])] = Entry{Name: fields[0], Synonyms: fields[1:]}
+	}
+	for _, name := range Common {
+		if _, ok := entries[strings.ToLower(name)]; !ok {
+			entries[strings.ToLower(name)] = Entry{Name: name}
+		}
+	}
+
+	catalog := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		catalog = append(catalog, entry)
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Name < catalog[j].Name })
+	return catalog
+}()
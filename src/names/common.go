@@ -0,0 +1,18 @@
+package names
+
+import (
+	_ "embed"
+	"math/rand"
+	"strings"
+)
+
+//go:embed common.txt
+var embeddedCommon string
+
+// Common lists popular first names from around the world
+var Common = strings.Fields(embeddedCommon)
+
+// RandomCommon returns one of the common names chosen using rng
+func RandomCommon(rng *rand.Rand) string {
+	return Common[rng.Intn(len(Common))]
+}
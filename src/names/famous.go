@@ -0,0 +1,60 @@
+package names
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed famous.txt
+var embeddedFamous string
+
+// famous maps the folded famous names to the id of their message
+var famous = func() map[string]string {
+	ids := map[string]string{}
+	for _, line := range strings.Split(embeddedFamous, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if id, name, ok := strings.Cut(line, " "); ok {
+			ids[Fold(name)] = id
+		}
+	}
+	return ids
+}()
+
+// Famous returns the id of the playful message answering name, if name or
+// its first word is famous. Case and accents are ignored, so "Beyoncé"
+// matches however it was transcribed
+func Famous(name string) (string, bool) {
+	folded := Fold(name)
+	if id, ok := famous[folded]; ok {
+		return id, true
+	}
+	first, _, _ := strings.Cut(folded, " ")
+	id, ok := famous[first]
+	return id, ok
+}
+
+// Fold lowercases name and strips the accents of its latin letters, so names
+// spelled differently by Alexa and by the datasets compare equal
+func Fold(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.Join(strings.Fields(name), " ")) {
+		if folded, ok := accents[r]; ok {
+			r = folded
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// accents maps accented latin letters to the letters they are spoken like
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o', 'ø': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ý': 'y', 'ÿ': 'y',
+}
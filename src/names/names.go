@@ -0,0 +1,25 @@
+// Package names prepares names received from users before
+// they are spoken back or sent to any upstream API
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sanitize trims a name, collapses repeated whitespace and drops any
+// character that can't be part of a first name (digits, punctuation, markup)
+func Sanitize(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			builder.WriteRune(r)
+		case r == '-' || r == '\'':
+			builder.WriteRune(r)
+		case unicode.IsSpace(r):
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
@@ -0,0 +1,36 @@
+package names
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"  Hans  ":           "Hans",
+		"Mary   Ann":         "Mary Ann",
+		"O'Brien":            "O'Brien",
+		"Jean-Luc":           "Jean-Luc",
+		"Zoë":                "Zoë",
+		"<speak>Eve</speak>": "speakEvespeak",
+		"R2D2 & C3PO":        "RD CPO",
+		"":                   "",
+	}
+	for name, want := range tests {
+		if got := Sanitize(name); got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRandomCommonIsSeeded(t *testing.T) {
+	if len(Common) == 0 {
+		t.Fatal("no common names embedded")
+	}
+	for seed := int64(1); seed <= 5; seed++ {
+		first, second := RandomCommon(rand.New(rand.NewSource(seed))), RandomCommon(rand.New(rand.NewSource(seed)))
+		if first != second {
+			t.Errorf("seed %d picked %q then %q", seed, first, second)
+		}
+	}
+}
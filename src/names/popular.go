@@ -0,0 +1,64 @@
+package names
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed popular.txt
+var embeddedPopular string
+
+// popular maps the alpha-2 code of each country to its lists of popular
+// names, by "m" for boys and "f" for girls, the most popular first
+var popular = func() map[string]map[string][]string {
+	lists := map[string]map[string][]string{}
+	for _, line := range strings.Split(embeddedPopular, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if lists[fields[0]] == nil {
+			lists[fields[0]] = map[string][]string{}
+		}
+		lists[fields[0]][fields[1]] = fields[2:]
+	}
+	return lists
+}()
+
+// Typical returns up to count of the most popular names of the country
+// having the alpha-2 code, alternating between the names of boys and girls
+// from the most popular down. It is empty for the countries without data
+func Typical(code string, count int) []string {
+	boys, girls := popular[code]["m"], popular[code]["f"]
+	var typical []string
+	for i := 0; len(typical) < count && (i < len(boys) || i < len(girls)); i++ {
+		if i < len(boys) {
+			typical = append(typical, boys[i])
+		}
+		if i < len(girls) && len(typical) < count {
+			typical = append(typical, girls[i])
+		}
+	}
+	return typical
+}
+
+// Rank returns how popular name is in the country having the alpha-2 code,
+// 1 being the most popular, along with "m" when it ranks among the names of
+// boys or "f" among those of girls. Case and accents are ignored. Names
+// given to both rank where they are the most popular
+func Rank(code string, name string) (int, string, bool) {
+	folded := Fold(name)
+	best, gender := 0, ""
+	for _, list := range []string{"m", "f"} {
+		for i, popularName := range popular[code][list] {
+			if Fold(popularName) == folded && (best == 0 || i+1 < best) {
+				best, gender = i+1, list
+			}
+		}
+	}
+	return best, gender, best > 0
+}
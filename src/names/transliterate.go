@@ -0,0 +1,173 @@
+package names
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+//go:embed romanized.txt
+var embeddedRomanized string
+
+// romanized maps names to their usual latin spelling
+var romanized = func() map[string]string {
+	spellings := map[string]string{}
+	for _, line := range strings.Split(embeddedRomanized, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) == 2 {
+			spellings[fields[0]] = fields[1]
+		}
+	}
+	return spellings
+}()
+
+// cyrillic romanizes the Russian, Ukrainian, Belarusian, Bulgarian and Serbian alphabets
+var cyrillic = strings.NewReplacer(
+	"а", "a", "б", "b", "в", "v", "г", "g", "ґ", "g", "д", "d", "ђ", "dj", "е", "e",
+	"ё", "yo", "є", "ye", "ж", "zh", "з", "z", "и", "i", "і", "i", "ї", "yi", "й", "y",
+	"ј", "j", "к", "k", "л", "l", "љ", "lj", "м", "m", "н", "n", "њ", "nj", "о", "o",
+	"п", "p", "р", "r", "с", "s", "т", "t", "ћ", "c", "у", "u", "ў", "u", "ф", "f",
+	"х", "kh", "ц", "ts", "ч", "ch", "џ", "dz", "ш", "sh", "щ", "shch", "ъ", "", "ы", "y",
+	"ь", "", "э", "e", "ю", "yu", "я", "ya",
+)
+
+// greek romanizes the Greek alphabet, the digraphs coming first so they take precedence
+var greek = strings.NewReplacer(
+	"ου", "ou", "ού", "ou", "αυ", "av", "ευ", "ev", "γγ", "ng", "γκ", "gk",
+	"α", "a", "ά", "a", "β", "v", "γ", "g", "δ", "d", "ε", "e", "έ", "e", "ζ", "z",
+	"η", "i", "ή", "i", "θ", "th", "ι", "i", "ί", "i", "ϊ", "i", "ΐ", "i", "κ", "k",
+	"λ", "l", "μ", "m", "ν", "n", "ξ", "x", "ο", "o", "ό", "o", "π", "p", "ρ", "r",
+	"σ", "s", "ς", "s", "τ", "t", "υ", "y", "ύ", "y", "ϋ", "y", "ΰ", "y", "φ", "f",
+	"χ", "ch", "ψ", "ps", "ω", "o", "ώ", "o",
+)
+
+// arabic romanizes the Arabic and Persian alphabets. Short vowels
+// are only spelled out when the name is written with its diacritics
+var arabic = strings.NewReplacer(
+	"ا", "a", "أ", "a", "إ", "i", "آ", "a", "ب", "b", "پ", "p", "ت", "t", "ث", "th",
+	"ج", "j", "چ", "ch", "ح", "h", "خ", "kh", "د", "d", "ذ", "dh", "ر", "r", "ز", "z",
+	"ژ", "zh", "س", "s", "ش", "sh", "ص", "s", "ض", "d", "ط", "t", "ظ", "z", "ع", "",
+	"غ", "gh", "ف", "f", "ق", "q", "ك", "k", "ک", "k", "گ", "g", "ل", "l", "م", "m",
+	"ن", "n", "ه", "h", "ة", "a", "و", "w", "ي", "y", "ی", "y", "ى", "a", "ء", "",
+	"ئ", "", "ؤ", "", "َ", "a", "ُ", "u", "ِ", "i", "ّ", "", "ْ", "",
+)
+
+// kana romanizes hiragana using the Hepburn system, katakana being
+// converted to hiragana first. Built by newKana
+var kana = newKana()
+
+// newKana returns the replacer romanizing hiragana. Contracted sounds like
+// "きゃ" come first so they take precedence over their first kana, and every
+// syllable is repeated after a small "っ", which doubles its consonant
+func newKana() *strings.Replacer {
+	syllables := [][2]string{
+		{"きゃ", "kya"}, {"きゅ", "kyu"}, {"きょ", "kyo"}, {"しゃ", "sha"}, {"しゅ", "shu"}, {"しょ", "sho"},
+		{"ちゃ", "cha"}, {"ちゅ", "chu"}, {"ちょ", "cho"}, {"にゃ", "nya"}, {"にゅ", "nyu"}, {"にょ", "nyo"},
+		{"ひゃ", "hya"}, {"ひゅ", "hyu"}, {"ひょ", "hyo"}, {"みゃ", "mya"}, {"みゅ", "myu"}, {"みょ", "myo"},
+		{"りゃ", "rya"}, {"りゅ", "ryu"}, {"りょ", "ryo"}, {"ぎゃ", "gya"}, {"ぎゅ", "gyu"}, {"ぎょ", "gyo"},
+		{"じゃ", "ja"}, {"じゅ", "ju"}, {"じょ", "jo"}, {"びゃ", "bya"}, {"びゅ", "byu"}, {"びょ", "byo"},
+		{"ぴゃ", "pya"}, {"ぴゅ", "pyu"}, {"ぴょ", "pyo"},
+		{"あ", "a"}, {"い", "i"}, {"う", "u"}, {"え", "e"}, {"お", "o"},
+		{"か", "ka"}, {"き", "ki"}, {"く", "ku"}, {"け", "ke"}, {"こ", "ko"},
+		{"さ", "sa"}, {"し", "shi"}, {"す", "su"}, {"せ", "se"}, {"そ", "so"},
+		{"た", "ta"}, {"ち", "chi"}, {"つ", "tsu"}, {"て", "te"}, {"と", "to"},
+		{"な", "na"}, {"に", "ni"}, {"ぬ", "nu"}, {"ね", "ne"}, {"の", "no"},
+		{"は", "ha"}, {"ひ", "hi"}, {"ふ", "fu"}, {"へ", "he"}, {"ほ", "ho"},
+		{"ま", "ma"}, {"み", "mi"}, {"む", "mu"}, {"め", "me"}, {"も", "mo"},
+		{"や", "ya"}, {"ゆ", "yu"}, {"よ", "yo"},
+		{"ら", "ra"}, {"り", "ri"}, {"る", "ru"}, {"れ", "re"}, {"ろ", "ro"},
+		{"わ", "wa"}, {"を", "o"}, {"ん", "n"},
+		{"が", "ga"}, {"ぎ", "gi"}, {"ぐ", "gu"}, {"げ", "ge"}, {"ご", "go"},
+		{"ざ", "za"}, {"じ", "ji"}, {"ず", "zu"}, {"ぜ", "ze"}, {"ぞ", "zo"},
+		{"だ", "da"}, {"ぢ", "ji"}, {"づ", "zu"}, {"で", "de"}, {"ど", "do"},
+		{"ば", "ba"}, {"び", "bi"}, {"ぶ", "bu"}, {"べ", "be"}, {"ぼ", "bo"},
+		{"ぱ", "pa"}, {"ぴ", "pi"}, {"ぷ", "pu"}, {"ぺ", "pe"}, {"ぽ", "po"},
+	}
+	var pairs []string
+	for _, syllable := range syllables {
+		if romaji := syllable[1]; !strings.ContainsAny(romaji[:1], "aeiou") {
+			doubled := romaji[:1] + romaji
+			if strings.HasPrefix(romaji, "ch") {
+				doubled = "t" + romaji
+			}
+			pairs = append(pairs, "っ"+syllable[0], doubled)
+		}
+	}
+	for _, syllable := range syllables {
+		pairs = append(pairs, syllable[0], syllable[1])
+	}
+	// long vowel marks and a trailing small "っ" aren't spelled in names
+	pairs = append(pairs, "ー", "", "っ", "")
+	return strings.NewReplacer(pairs...)
+}
+
+// Revised Romanization of the initial consonants, vowels and final consonants of Hangul syllables
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulVowels   = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// Romanize returns the latin spelling of a name written in another script,
+// since the upstream APIs only know names by their latin spelling. Cyrillic,
+// Greek, Arabic, kana and Hangul are romanized letter by letter, while names
+// written with Han characters are looked up among a few well known ones.
+// Latin names are returned as they are, and so are names that can't be romanized
+func Romanize(name string) string {
+	if isLatin(name) {
+		return name
+	}
+	words := strings.Fields(name)
+	for i, word := range words {
+		romanizedWord, ok := romanizeWord(word)
+		if !ok {
+			return name
+		}
+		words[i] = romanizedWord
+	}
+	return strings.Join(words, " ")
+}
+
+// romanizeWord returns the latin spelling of word, reporting whether every letter could be romanized
+func romanizeWord(word string) (string, bool) {
+	if spelling, ok := romanized[word]; ok {
+		return spelling, true
+	}
+	lower := strings.ToLower(word)
+	var builder strings.Builder
+	for _, r := range lower {
+		switch {
+		case r >= 0x30A1 && r <= 0x30F6:
+			// katakana sit 0x60 code points after their hiragana
+			builder.WriteRune(r - 0x60)
+		case r >= 0xAC00 && r <= 0xD7A3:
+			syllable := int(r - 0xAC00)
+			builder.WriteString(hangulInitials[syllable/588] + hangulVowels[syllable%588/28] + hangulFinals[syllable%28])
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	spelling := builder.String()
+	for _, replacer := range []*strings.Replacer{cyrillic, greek, arabic, kana} {
+		spelling = replacer.Replace(spelling)
+	}
+	if spelling == "" || !isLatin(spelling) {
+		return "", false
+	}
+	runes := []rune(spelling)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes), true
+}
+
+// isLatin reports whether every letter of text is written in the latin script
+func isLatin(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package names
+
+import "strings"
+
+// commonSet holds the lowercased common names, which never look unusual
+var commonSet = func() map[string]bool {
+	set := map[string]bool{}
+	for _, name := range Common {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}()
+
+// LooksUnusual reports whether name looks like it may have been
+// transcribed wrongly: it is very short, has no vowels or repeats
+// the same letter three times in a row. Common names never look unusual,
+// and names in other scripts are judged by their latin spelling
+func LooksUnusual(name string) bool {
+	lower := strings.ToLower(Romanize(name))
+	if commonSet[lower] {
+		return false
+	}
+	if len([]rune(lower)) < 2 || !strings.ContainsAny(lower, "aeiouy") {
+		return true
+	}
+	runes := []rune(lower)
+	for i := 2; i < len(runes); i++ {
+		if runes[i] == runes[i-1] && runes[i] == runes[i-2] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package nationality
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Member is a provider taking part in an ensemble, its predictions
+// counting Weight times as much as those of a member of weight 1
+type Member struct {
+	Name     string
+	Provider Provider
+	Weight   float64
+}
+
+// Ensemble queries its members concurrently and averages their predictions,
+// weighted by member. Members that fail are left out of the average
+type Ensemble struct {
+	Members []Member
+}
+
+// Predict returns the averaged predictions of the members, most likely first
+func (ensemble Ensemble) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	response, err := ensemble.Lookup(ctx, name)
+	return response.Predictions, err
+}
+
+// Lookup returns the averaged predictions of the members, normalized so they add up
+// to 1, along with the records behind them and the names of the members that answered
+func (ensemble Ensemble) Lookup(ctx context.Context, name string) (Response, error) {
+	responses := make([]Response, len(ensemble.Members))
+	errs := make([]error, len(ensemble.Members))
+	var wg sync.WaitGroup
+	for i, member := range ensemble.Members {
+		wg.Add(1)
+		go func(i int, member Member) {
+			defer wg.Done()
+			responses[i], errs[i] = Lookup(ctx, member.Provider, name)
+		}(i, member)
+	}
+	wg.Wait()
+
+	merged := Response{Name: name}
+	probabilities := map[string]float64{}
+	var lastErr error
+	for i, member := range ensemble.Members {
+		if errs[i] != nil {
+			lastErr = fmt.Errorf("nationality: %s failed: %w", member.Name, errs[i])
+			continue
+		}
+		merged.Count += responses[i].Count
+		merged.Sources = append(merged.Sources, member.Name)
+		for _, prediction := range responses[i].Predictions {
+			probabilities[prediction.Country_id] += member.Weight * prediction.Probability
+		}
+	}
+	if len(merged.Sources) == 0 {
+		return merged, lastErr
+	}
+
+	var total float64
+	for _, probability := range probabilities {
+		total += probability
+	}
+	for code, probability := range probabilities {
+		merged.Predictions = append(merged.Predictions, Prediction{Country_id: code, Probability: probability / total})
+	}
+	sort.Slice(merged.Predictions, func(i, j int) bool {
+		return merged.Predictions[i].Probability > merged.Predictions[j].Probability
+	})
+	return merged, nil
+}
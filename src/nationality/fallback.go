@@ -0,0 +1,154 @@
+package nationality
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// healthWeight is how much the outcome of the latest lookup moves the failure
+// rate of a provider, so recent outcomes count more than older ones
+const healthWeight = 0.2
+
+// healthHalfLife is how long the failure rate of a provider takes to halve while it
+// isn't tried, so a provider demoted by an outage is tried first again once it
+// is healthy again instead of staying demoted for good
+const healthHalfLife = time.Minute
+
+// healthyFailureRate is the failure rate under which a provider counts as healthy,
+// so the remains of an old outage don't keep it ranked behind the others
+const healthyFailureRate = 0.05
+
+// Named is a provider along with the name its health is reported under
+type Named struct {
+	Name     string
+	Provider Provider
+}
+
+// Fallback tries its providers in turn until one of them answers. It tracks
+// the recent failure rate of each provider and tries the healthiest first,
+// in the order they were given when they are as healthy. Failure rates decay
+// over time, so a provider that recovered gets tried again. It is safe for concurrent use
+type Fallback struct {
+	providers []Named
+	// Offline, if not nil, answers when every provider failed
+	Offline Provider
+	// AttemptTimeout bounds each attempt so a slow provider leaves time for the next one
+	AttemptTimeout time.Duration
+	// Observe, if not nil, is called after every lookup of a provider with
+	// its failure rate updated by the outcome of the lookup and its error, if any
+	Observe func(ctx context.Context, name string, failureRate float64, err error)
+	// Now returns the current time, it can be replaced to control the decay of failure rates
+	Now func() time.Time
+
+	mutex  sync.Mutex
+	health map[string]health
+}
+
+// health is the failure rate of a provider as of when it was last updated
+type health struct {
+	failureRate float64
+	updatedAt   time.Time
+}
+
+// decayed returns the failure rate at now, halved for every healthHalfLife since it was updated
+func (h health) decayed(now time.Time) float64 {
+	elapsed := now.Sub(h.updatedAt)
+	if elapsed <= 0 {
+		return h.failureRate
+	}
+	return h.failureRate * math.Pow(0.5, float64(elapsed)/float64(healthHalfLife))
+}
+
+// NewFallback returns a fallback trying providers, the first one being preferred
+func NewFallback(providers ...Named) *Fallback {
+	return &Fallback{providers: providers, Now: time.Now, health: map[string]health{}}
+}
+
+// Predict returns the predictions of the healthiest provider that answers
+func (fallback *Fallback) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	response, err := fallback.Lookup(ctx, name)
+	return response.Predictions, err
+}
+
+// Lookup returns the predictions of the healthiest provider that answers, with the
+// number of records behind them when that provider tells it. When none of them
+// answered and there is no offline provider the error of the last one is returned
+func (fallback *Fallback) Lookup(ctx context.Context, name string) (Response, error) {
+	lastErr := fmt.Errorf("nationality: no provider configured")
+	for _, named := range fallback.ranked() {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if fallback.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, fallback.AttemptTimeout)
+		}
+		response, err := Lookup(attemptCtx, named.Provider, name)
+		cancel()
+		rate := fallback.record(named.Name, err)
+		if fallback.Observe != nil {
+			fallback.Observe(ctx, named.Name, rate, err)
+		}
+		if err == nil {
+			if len(response.Sources) == 0 {
+				response.Sources = []string{named.Name}
+			}
+			return response, nil
+		}
+		lastErr = fmt.Errorf("nationality: %s failed: %w", named.Name, err)
+	}
+	if fallback.Offline != nil {
+		response, err := Lookup(ctx, fallback.Offline, name)
+		response.Sources = []string{OfflineSource}
+		return response, err
+	}
+	return Response{}, lastErr
+}
+
+// Health returns the recent failure rate of each provider, from 0 when all
+// of its recent lookups succeeded to 1 when they all failed
+func (fallback *Fallback) Health() map[string]float64 {
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	now := fallback.Now()
+	rates := map[string]float64{}
+	for _, named := range fallback.providers {
+		rates[named.Name] = fallback.health[named.Name].decayed(now)
+	}
+	return rates
+}
+
+// ranked returns the providers, the healthiest first
+func (fallback *Fallback) ranked() []Named {
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	now := fallback.Now()
+	ranked := append([]Named(nil), fallback.providers...)
+	rank := func(name string) float64 {
+		if rate := fallback.health[name].decayed(now); rate >= healthyFailureRate {
+			return rate
+		}
+		return 0
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return rank(ranked[i].Name) < rank(ranked[j].Name)
+	})
+	return ranked
+}
+
+// record moves the failure rate of the provider called name towards the outcome of a lookup,
+// returning the updated rate
+func (fallback *Fallback) record(name string, err error) float64 {
+	outcome := 0.0
+	if err != nil {
+		outcome = 1
+	}
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	now := fallback.Now()
+	rate := fallback.health[name].decayed(now)
+	rate += healthWeight * (outcome - rate)
+	fallback.health[name] = health{failureRate: rate, updatedAt: now}
+	return rate
+}
@@ -0,0 +1,33 @@
+package nationality
+
+import (
+	"alexa-skill-test/src/surname"
+	"context"
+	"strings"
+)
+
+// NamSor guesses nationalities using the origin api of NamSor, which takes
+// the surname into account when the name has one and distinguishes
+// countries more finely, but only reports its two most likely countries
+type NamSor struct {
+	Client surname.NamSor
+}
+
+// Predict returns the countries name may come from, most likely first.
+// The first word of name is its first name and any other words its surname
+func (namsor NamSor) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	firstName, lastName, _ := strings.Cut(strings.Join(strings.Fields(name), " "), " ")
+	origin, err := namsor.Client.Origin(ctx, firstName, lastName)
+	if err != nil {
+		return nil, err
+	}
+
+	var predictions []Prediction
+	if origin.CountryOrigin != "" {
+		predictions = append(predictions, Prediction{Country_id: origin.CountryOrigin, Probability: origin.ProbabilityCalibrated})
+	}
+	if origin.CountryOriginAlt != "" && origin.CountryOriginAlt != origin.CountryOrigin {
+		predictions = append(predictions, Prediction{Country_id: origin.CountryOriginAlt, Probability: origin.ProbabilityAltCalibrated})
+	}
+	return predictions, nil
+}
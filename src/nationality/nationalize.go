@@ -0,0 +1,71 @@
+package nationality
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Nationalize queries nationalize.io, which guesses nationalities from
+// how often a first name appears in the records of each country
+type Nationalize struct {
+	// BaseURL is the endpoint, the name is sent in its "name" query parameter
+	BaseURL string
+	HTTP    *http.Client
+	// Quota, if not nil, tracks the requests left today. Lookups fail with
+	// ErrQuotaExhausted without sending anything once it is used up
+	Quota *Quota
+	// Vintage is when the records behind the predictions were collected,
+	// which the api doesn't report, as documented by nationalize.io
+	Vintage string
+}
+
+// Predict returns the countries name may come from, most likely first
+func (nationalize Nationalize) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	response, err := nationalize.Lookup(ctx, name)
+	return response.Predictions, err
+}
+
+// Lookup returns the predictions for name along with the number of records behind them
+func (nationalize Nationalize) Lookup(ctx context.Context, name string) (Response, error) {
+	var predictions Response
+	if nationalize.Quota != nil && nationalize.Quota.Exhausted() {
+		return predictions, ErrQuotaExhausted
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nationalize.URL(name), nil)
+	if err != nil {
+		return predictions, err
+	}
+	response, err := nationalize.HTTP.Do(req)
+	if err != nil {
+		return predictions, upstream.Transport("nationalize", err)
+	}
+	defer response.Body.Close()
+	if nationalize.Quota != nil {
+		nationalize.Quota.Update(response.Header, response.StatusCode == http.StatusTooManyRequests)
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return predictions, ErrQuotaExhausted
+	}
+	if err := upstream.Check("nationalize", response); err != nil {
+		return predictions, err
+	}
+	err = json.NewDecoder(response.Body).Decode(&predictions)
+	predictions.Vintage = nationalize.Vintage
+	return predictions, upstream.Malformed("nationalize", err)
+}
+
+// URL returns the url guessing the nationality of name,
+// with the name properly query encoded
+func (nationalize Nationalize) URL(name string) string {
+	u, err := url.Parse(nationalize.BaseURL)
+	if err != nil {
+		return nationalize.BaseURL + "?" + url.Values{"name": {name}}.Encode()
+	}
+	query := u.Query()
+	query.Set("name", name)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,131 @@
+package nationality
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// serve returns a nationalize client calling a server that runs handler
+func serve(t *testing.T, handler http.HandlerFunc) Nationalize {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return Nationalize{BaseURL: server.URL, HTTP: server.Client(), Quota: NewQuota(), Vintage: "2021"}
+}
+
+func TestNationalizeLookup(t *testing.T) {
+	nationalize := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("name"); got != "Hans" {
+			t.Errorf("name sent as %q", got)
+		}
+		w.Header().Set("X-Rate-Limit-Remaining", "42")
+		w.Write([]byte(`{"name":"Hans","count":1234,"country":[{"country_id":"DE","probability":0.6},{"country_id":"AT","probability":0.2}]}`))
+	})
+
+	response, err := nationalize.Lookup(context.Background(), "Hans")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if response.Count != 1234 || len(response.Predictions) != 2 || response.Predictions[0].Country_id != "DE" {
+		t.Errorf("Lookup = %+v", response)
+	}
+	if response.Vintage != "2021" {
+		t.Errorf("vintage %q, want 2021", response.Vintage)
+	}
+	if remaining, known := nationalize.Quota.Remaining(); !known || remaining != 42 {
+		t.Errorf("quota remaining %d, %v, want 42", remaining, known)
+	}
+}
+
+func TestNationalizeStatusError(t *testing.T) {
+	nationalize := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := nationalize.Lookup(context.Background(), "Hans")
+	var statusErr *upstream.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway || statusErr.Service != "nationalize" {
+		t.Errorf("Lookup error = %v, want a 502 StatusError from nationalize", err)
+	}
+}
+
+func TestNationalizeMalformed(t *testing.T) {
+	nationalize := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"Hans","country":[`))
+	})
+
+	_, err := nationalize.Lookup(context.Background(), "Hans")
+	var malformed *upstream.MalformedError
+	if !errors.As(err, &malformed) || malformed.Service != "nationalize" {
+		t.Errorf("Lookup error = %v, want a MalformedError from nationalize", err)
+	}
+}
+
+func TestNationalizeQuotaExhausted(t *testing.T) {
+	var requests atomic.Int32
+	nationalize := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-Rate-Limit-Reset", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := nationalize.Lookup(context.Background(), "Hans"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Lookup error = %v, want ErrQuotaExhausted", err)
+	}
+	// nothing is sent again until the quota resets
+	if _, err := nationalize.Lookup(context.Background(), "Anna"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Lookup error = %v, want ErrQuotaExhausted", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("api was called %d times, want once", got)
+	}
+}
+
+func TestNationalizeTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	nationalize := Nationalize{BaseURL: server.URL, HTTP: http.DefaultClient}
+
+	_, err := nationalize.Lookup(context.Background(), "Hans")
+	var transportErr *upstream.TransportError
+	if !errors.As(err, &transportErr) || transportErr.Service != "nationalize" {
+		t.Errorf("Lookup error = %v, want a TransportError from nationalize", err)
+	}
+}
+
+func TestNationalizeURL(t *testing.T) {
+	tests := []struct {
+		base string
+		name string
+		want string
+	}{
+		{"https://api.nationalize.io", "Mary Ann", "https://api.nationalize.io?name=Mary+Ann"},
+		{"https://api.nationalize.io/", "Zoë", "https://api.nationalize.io/?name=Zo%C3%AB"},
+		{"https://api.nationalize.io", "Tom&Jerry", "https://api.nationalize.io?name=Tom%26Jerry"},
+		{"https://api.nationalize.io/?apikey=k", "José María", "https://api.nationalize.io/?apikey=k&name=Jos%C3%A9+Mar%C3%ADa"},
+	}
+	for _, test := range tests {
+		got := Nationalize{BaseURL: test.base}.URL(test.name)
+		if got != test.want {
+			t.Errorf("URL(%q) = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNationalizeSendsNameEscaped(t *testing.T) {
+	for _, name := range []string{"Mary Ann", "Zoë", "Tom&Jerry", "a=b;c"} {
+		nationalize := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query(); got.Get("name") != name || len(got) != 1 {
+				t.Errorf("query %v, want the name %q alone", got, name)
+			}
+			w.Write([]byte(`{"country":[]}`))
+		})
+		if _, err := nationalize.Lookup(context.Background(), name); err != nil {
+			t.Errorf("Lookup(%q) failed: %v", name, err)
+		}
+	}
+}
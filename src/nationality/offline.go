@@ -0,0 +1,74 @@
+package nationality
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// OfflineSource is the source of the predictions made by Offline
+const OfflineSource = "offline"
+
+//go:embed offline.json
+var embeddedOffline []byte
+
+// offline maps lowercased first names to their approximate predictions, most likely first
+var offline = loadOffline()
+
+// offlineVintage is when the offline predictions were compiled, read from
+// the "_vintage" entry of the file
+var offlineVintage = loadOfflineVintage()
+
+// loadOffline decodes the embedded predictions, skipping the comment at the top of the
+// file. The file is validated at build time so a failure here is a programming error
+func loadOffline() map[string][]Prediction {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(embeddedOffline, &raw); err != nil {
+		panic("nationality: malformed offline predictions: " + err.Error())
+	}
+	loaded := map[string][]Prediction{}
+	for name, data := range raw {
+		var probabilities map[string]float64
+		if strings.HasPrefix(name, "_") || json.Unmarshal(data, &probabilities) != nil {
+			continue
+		}
+		var predictions []Prediction
+		for code, probability := range probabilities {
+			predictions = append(predictions, Prediction{Country_id: code, Probability: probability})
+		}
+		sort.Slice(predictions, func(i, j int) bool {
+			return predictions[i].Probability > predictions[j].Probability
+		})
+		loaded[name] = predictions
+	}
+	return loaded
+}
+
+// loadOfflineVintage returns the "_vintage" entry of the embedded predictions
+func loadOfflineVintage() string {
+	var header struct {
+		Vintage string `json:"_vintage"`
+	}
+	json.Unmarshal(embeddedOffline, &header)
+	return header.Vintage
+}
+
+// Offline guesses from a small embedded dataset of common first names,
+// so a guess can still be made when no provider can be reached.
+// Names it doesn't know have no predictions
+type Offline struct{}
+
+// Predict returns the countries name may come from, most likely first
+func (Offline) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	first, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(name)), " ")
+	return offline[first], nil
+}
+
+// Lookup returns the predictions for name along with when the dataset was
+// compiled. The dataset doesn't keep the number of records behind them
+func (offline Offline) Lookup(ctx context.Context, name string) (Response, error) {
+	predictions, err := offline.Predict(ctx, name)
+	return Response{Name: name, Predictions: predictions, Vintage: offlineVintage}, err
+}
@@ -0,0 +1,26 @@
+package nationality
+
+import "context"
+
+// Provider guesses the countries a first name is most common in
+type Provider interface {
+	// Predict returns the countries name may come from along with their probabilities
+	Predict(ctx context.Context, name string) ([]Prediction, error)
+}
+
+// Detailed is implemented by providers that can tell how many records
+// their predictions are based on, so shaky guesses can be flagged
+type Detailed interface {
+	// Lookup returns the predictions for name along with the number of records behind them
+	Lookup(ctx context.Context, name string) (Response, error)
+}
+
+// Lookup returns the predictions of provider for name, with the number
+// of records behind them when the provider is Detailed and zero otherwise
+func Lookup(ctx context.Context, provider Provider, name string) (Response, error) {
+	if detailed, ok := provider.(Detailed); ok {
+		return detailed.Lookup(ctx, name)
+	}
+	predictions, err := provider.Predict(ctx, name)
+	return Response{Name: name, Predictions: predictions}, err
+}
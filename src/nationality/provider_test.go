@@ -0,0 +1,227 @@
+package nationality
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake is a provider only able to predict, answering predictions or failing
+// with err, and recording the names it was asked about
+type fake struct {
+	predictions []Prediction
+	err         error
+	delay       time.Duration
+
+	mutex sync.Mutex
+	asked []string
+}
+
+func (fake *fake) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	fake.mutex.Lock()
+	fake.asked = append(fake.asked, name)
+	fake.mutex.Unlock()
+	if fake.delay > 0 {
+		select {
+		case <-time.After(fake.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return fake.predictions, fake.err
+}
+
+func (fake *fake) calls() int {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return len(fake.asked)
+}
+
+var errDown = errors.New("provider down")
+
+func TestLookupOfPredictingProvider(t *testing.T) {
+	provider := &fake{predictions: []Prediction{{Country_id: "DE", Probability: 0.5}}}
+	response, err := Lookup(context.Background(), provider, "Hans")
+	if err != nil || response.Name != "Hans" || response.Count != 0 || len(response.Predictions) != 1 {
+		t.Errorf("Lookup = %+v, %v", response, err)
+	}
+}
+
+func TestFallbackPrefersFirstProvider(t *testing.T) {
+	first := &fake{predictions: []Prediction{{Country_id: "DE", Probability: 0.5}}}
+	second := &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.5}}}
+	fallback := NewFallback(Named{"first", first}, Named{"second", second})
+
+	response, err := fallback.Lookup(context.Background(), "Hans")
+	if err != nil || response.Predictions[0].Country_id != "DE" {
+		t.Fatalf("Lookup = %+v, %v, want the first provider's guess", response, err)
+	}
+	if !reflect.DeepEqual(response.Sources, []string{"first"}) || second.calls() != 0 {
+		t.Errorf("sources %v and the second provider asked %d times", response.Sources, second.calls())
+	}
+}
+
+func TestFallbackTriesNextProvider(t *testing.T) {
+	first := &fake{err: errDown}
+	second := &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.5}}}
+	fallback := NewFallback(Named{"first", first}, Named{"second", second})
+	var observed []string
+	fallback.Observe = func(ctx context.Context, name string, failureRate float64, err error) {
+		observed = append(observed, name)
+	}
+
+	response, err := fallback.Lookup(context.Background(), "Hans")
+	if err != nil || response.Predictions[0].Country_id != "FR" || !reflect.DeepEqual(response.Sources, []string{"second"}) {
+		t.Errorf("Lookup = %+v, %v, want the second provider's guess", response, err)
+	}
+	if !reflect.DeepEqual(observed, []string{"first", "second"}) {
+		t.Errorf("observed %v", observed)
+	}
+	if health := fallback.Health(); health["first"] <= 0 || health["second"] != 0 {
+		t.Errorf("health %v, want only the first provider failing", health)
+	}
+}
+
+func TestFallbackRanksByHealth(t *testing.T) {
+	first := &fake{err: errDown}
+	second := &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.5}}}
+	fallback := NewFallback(Named{"first", first}, Named{"second", second})
+
+	fallback.Lookup(context.Background(), "Hans")
+	fallback.Lookup(context.Background(), "Anna")
+	// the failing provider is tried after the healthy one once it failed
+	if first.calls() != 1 || second.calls() != 2 {
+		t.Errorf("first asked %d times and second %d times, want 1 and 2", first.calls(), second.calls())
+	}
+
+	first.err = nil
+	second.err = errDown
+	fallback.Lookup(context.Background(), "Ethan")
+	if first.calls() != 2 {
+		t.Errorf("first asked %d times, want it tried again once the second failed", first.calls())
+	}
+}
+
+func TestFallbackRecoveredProviderRankedFirstAgain(t *testing.T) {
+	primary := &fake{err: errDown}
+	secondary := &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.5}}}
+	fallback := NewFallback(Named{"primary", primary}, Named{"secondary", secondary})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fallback.Now = func() time.Time { return now }
+
+	// an outage demotes the primary behind the secondary
+	fallback.Lookup(context.Background(), "Hans")
+	primary.err = nil
+	primary.predictions = []Prediction{{Country_id: "DE", Probability: 0.5}}
+	now = now.Add(time.Second)
+	if response, _ := fallback.Lookup(context.Background(), "Anna"); response.Sources[0] != "secondary" {
+		t.Fatalf("answered by %v right after the outage, want the secondary", response.Sources)
+	}
+
+	// while the secondary keeps answering, the failures of the primary fade away
+	now = now.Add(10 * healthHalfLife)
+	if health := fallback.Health(); health["primary"] > 0.001 {
+		t.Errorf("primary failure rate %v after %s, want it decayed", health["primary"], 10*healthHalfLife)
+	}
+	response, err := fallback.Lookup(context.Background(), "Ethan")
+	if err != nil || response.Sources[0] != "primary" {
+		t.Errorf("Lookup = %+v, %v, want the recovered primary tried first again", response, err)
+	}
+	if primary.calls() != 2 {
+		t.Errorf("primary asked %d times, want 2", primary.calls())
+	}
+}
+
+func TestFallbackAttemptTimeout(t *testing.T) {
+	slow := &fake{delay: time.Second, predictions: []Prediction{{Country_id: "DE", Probability: 0.5}}}
+	quick := &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.5}}}
+	fallback := NewFallback(Named{"slow", slow}, Named{"quick", quick})
+	fallback.AttemptTimeout = 20 * time.Millisecond
+
+	response, err := fallback.Lookup(context.Background(), "Hans")
+	if err != nil || response.Predictions[0].Country_id != "FR" {
+		t.Errorf("Lookup = %+v, %v, want the quick provider's guess", response, err)
+	}
+}
+
+func TestFallbackOffline(t *testing.T) {
+	fallback := NewFallback(Named{"first", &fake{err: errDown}}, Named{"second", &fake{err: errDown}})
+	fallback.Offline = Offline{}
+
+	response, err := fallback.Lookup(context.Background(), "Aiko")
+	if err != nil || len(response.Predictions) == 0 || response.Predictions[0].Country_id != "JP" {
+		t.Errorf("Lookup = %+v, %v, want the offline guess", response, err)
+	}
+	if !reflect.DeepEqual(response.Sources, []string{OfflineSource}) {
+		t.Errorf("sources %v, want offline", response.Sources)
+	}
+}
+
+func TestFallbackAllFailing(t *testing.T) {
+	fallback := NewFallback(Named{"first", &fake{err: errDown}}, Named{"second", &fake{err: ErrQuotaExhausted}})
+
+	_, err := fallback.Lookup(context.Background(), "Hans")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Lookup error = %v, want the error of the last provider", err)
+	}
+	if _, err := NewFallback().Lookup(context.Background(), "Hans"); err == nil {
+		t.Errorf("Lookup without providers succeeded")
+	}
+}
+
+func TestEnsembleWeightedAverage(t *testing.T) {
+	ensemble := Ensemble{Members: []Member{
+		{Name: "heavy", Weight: 3, Provider: &fake{predictions: []Prediction{{Country_id: "DE", Probability: 1}}}},
+		{Name: "light", Weight: 1, Provider: &fake{predictions: []Prediction{{Country_id: "FR", Probability: 1}}}},
+	}}
+
+	response, err := ensemble.Lookup(context.Background(), "Hans")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	want := []Prediction{{Country_id: "DE", Probability: 0.75}, {Country_id: "FR", Probability: 0.25}}
+	if len(response.Predictions) != len(want) {
+		t.Fatalf("predictions %+v, want %+v", response.Predictions, want)
+	}
+	for i, prediction := range response.Predictions {
+		if prediction.Country_id != want[i].Country_id || math.Abs(prediction.Probability-want[i].Probability) > 1e-9 {
+			t.Errorf("predictions %+v, want %+v", response.Predictions, want)
+		}
+	}
+	if !reflect.DeepEqual(response.Sources, []string{"heavy", "light"}) {
+		t.Errorf("sources %v", response.Sources)
+	}
+}
+
+func TestEnsembleLeavesOutFailedMembers(t *testing.T) {
+	ensemble := Ensemble{Members: []Member{
+		{Name: "down", Weight: 3, Provider: &fake{err: errDown}},
+		{Name: "up", Weight: 1, Provider: &fake{predictions: []Prediction{{Country_id: "FR", Probability: 0.4}, {Country_id: "BE", Probability: 0.1}}}},
+	}}
+
+	response, err := ensemble.Lookup(context.Background(), "Hans")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if !reflect.DeepEqual(response.Sources, []string{"up"}) {
+		t.Errorf("sources %v, want only the member that answered", response.Sources)
+	}
+	if len(response.Predictions) != 2 || math.Abs(response.Predictions[0].Probability-0.8) > 1e-9 {
+		t.Errorf("predictions %+v, want the answering member's renormalized", response.Predictions)
+	}
+}
+
+func TestEnsembleAllFailing(t *testing.T) {
+	ensemble := Ensemble{Members: []Member{
+		{Name: "down", Weight: 1, Provider: &fake{err: errDown}},
+		{Name: "also down", Weight: 1, Provider: &fake{err: errDown}},
+	}}
+
+	if _, err := ensemble.Lookup(context.Background(), "Hans"); !errors.Is(err, errDown) {
+		t.Errorf("Lookup error = %v, want the members' error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package nationality
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted means the daily quota of requests was used up, so no
+// request is sent until it resets
+var ErrQuotaExhausted = errors.New("nationality: request quota exhausted")
+
+// Quota tracks the requests left before an api starts rejecting them, as reported
+// by its rate limit headers. It is safe for concurrent use
+type Quota struct {
+	mutex     sync.Mutex
+	known     bool
+	remaining int
+	resetAt   time.Time
+	// Now returns the current time, it can be replaced to control resets
+	Now func() time.Time
+	// Report, if not nil, is called with the number of requests left whenever it is updated
+	Report func(remaining int)
+}
+
+// NewQuota returns a quota that is unknown until the first response
+func NewQuota() *Quota {
+	return &Quota{Now: time.Now}
+}
+
+// Remaining returns the number of requests left, false until a response reported it
+func (quota *Quota) Remaining() (int, bool) {
+	quota.mutex.Lock()
+	defer quota.mutex.Unlock()
+	return quota.remaining, quota.known
+}
+
+// Exhausted reports whether no request is left until the quota resets
+func (quota *Quota) Exhausted() bool {
+	quota.mutex.Lock()
+	defer quota.mutex.Unlock()
+	return quota.known && quota.remaining <= 0 && quota.Now().Before(quota.resetAt)
+}
+
+// Update reads the requests left and when the quota resets from the headers of a
+// response, as "X-Rate-Limit-Remaining" and "X-Rate-Limit-Reset" or "X-Rate-Reset"
+// in seconds. A rejected response exhausts the quota even if its headers don't say so
+func (quota *Quota) Update(header http.Header, rejected bool) {
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err != nil && !rejected {
+		return
+	}
+	if rejected {
+		remaining = 0
+	}
+	reset := header.Get("X-Rate-Limit-Reset")
+	if reset == "" {
+		reset = header.Get("X-Rate-Reset")
+	}
+
+	quota.mutex.Lock()
+	now := quota.Now()
+	quota.known = true
+	quota.remaining = remaining
+	if seconds, err := strconv.Atoi(reset); err == nil {
+		quota.resetAt = now.Add(time.Duration(seconds) * time.Second)
+	} else {
+		// nationalize resets its quotas at midnight utc
+		quota.resetAt = now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	report := quota.Report
+	quota.mutex.Unlock()
+
+	if report != nil {
+		report(remaining)
+	}
+}
@@ -1,10 +1,35 @@
 package nationality
 
 type Response struct {
+	Name        string       `json:"name"`
+	Count       int          `json:"count"`
 	Predictions []Prediction `json:"country"`
+	// Sources names the providers the predictions come from
+	Sources []string `json:"-"`
+	// Vintage is when the records behind the predictions were collected,
+	// empty when the provider doesn't tell
+	Vintage string `json:"-"`
 }
 
 type Prediction struct {
 	Country_id  string
 	Probability float64
 }
+
+// Normalize returns a copy of predictions with their probabilities scaled
+// to sum to 1, so they read as shares of the countries guessed.
+// Predictions summing to zero are returned as they are
+func Normalize(predictions []Prediction) []Prediction {
+	var sum float64
+	for _, v := range predictions {
+		sum += v.Probability
+	}
+	normalized := append([]Prediction(nil), predictions...)
+	if sum <= 0 {
+		return normalized
+	}
+	for i := range normalized {
+		normalized[i].Probability /= sum
+	}
+	return normalized
+}
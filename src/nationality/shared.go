@@ -0,0 +1,56 @@
+package nationality
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Shared lets concurrent lookups of the same name share a single lookup of its
+// provider, so a burst of identical requests, such as after the skill is featured,
+// sends one request upstream per container instead of one per request.
+// It is safe for concurrent use
+type Shared struct {
+	Provider Provider
+	// Timeout bounds the shared lookup, which outlives the caller that started it
+	// when that caller gives up, so the others still get its predictions
+	Timeout time.Duration
+	group   singleflight.Group
+}
+
+// NewShared returns provider with its concurrent lookups of a name shared,
+// each shared lookup taking at most timeout
+func NewShared(provider Provider, timeout time.Duration) *Shared {
+	return &Shared{Provider: provider, Timeout: timeout}
+}
+
+// Predict returns the predictions of the provider for name
+func (shared *Shared) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	response, err := shared.Lookup(ctx, name)
+	return response.Predictions, err
+}
+
+// Lookup returns the predictions of the provider for name, made once for every
+// caller asking about it at the same time. Each caller gets its own copy of them,
+// and stops waiting for them when its own ctx is done
+func (shared *Shared) Lookup(ctx context.Context, name string) (Response, error) {
+	results := shared.group.DoChan(name, func() (interface{}, error) {
+		// the lookup doesn't end with the caller that started it, since others may wait for it
+		lookupCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+		if shared.Timeout > 0 {
+			lookupCtx, cancel = context.WithTimeout(lookupCtx, shared.Timeout)
+		}
+		defer cancel()
+		return Lookup(lookupCtx, shared.Provider, name)
+	})
+	select {
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case result := <-results:
+		response, _ := result.Val.(Response)
+		response.Predictions = append([]Prediction(nil), response.Predictions...)
+		response.Sources = append([]string(nil), response.Sources...)
+		return response, result.Err
+	}
+}
@@ -0,0 +1,104 @@
+package nationality
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gated is a provider whose lookups wait for release, or for their ctx to be done
+type gated struct {
+	started chan struct{}
+	release chan struct{}
+	lookups atomic.Int32
+}
+
+func (gated *gated) Predict(ctx context.Context, name string) ([]Prediction, error) {
+	if gated.lookups.Add(1) == 1 {
+		close(gated.started)
+	}
+	select {
+	case <-gated.release:
+		return []Prediction{{Country_id: "DE", Probability: 0.5}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newGated() *gated {
+	return &gated{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+// lookupAsync looks name up with shared in the background, handing the outcome to the returned channel
+func lookupAsync(ctx context.Context, shared *Shared, name string) chan error {
+	done := make(chan error, 1)
+	go func() {
+		response, err := shared.Lookup(ctx, name)
+		if err == nil && len(response.Predictions) != 1 {
+			err = errors.New("no predictions")
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestSharedSurvivesTheFirstCallerGivingUp(t *testing.T) {
+	provider := newGated()
+	shared := NewShared(provider, 5*time.Second)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := lookupAsync(firstCtx, shared, "Hans")
+	<-provider.started
+	second := lookupAsync(context.Background(), shared, "Hans")
+	// leaves time for the second caller to join the lookup in flight
+	time.Sleep(20 * time.Millisecond)
+
+	cancelFirst()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller got %v, want its own cancellation", err)
+	}
+	close(provider.release)
+	if err := <-second; err != nil {
+		t.Errorf("second caller got %v, want the predictions", err)
+	}
+	if got := provider.lookups.Load(); got != 1 {
+		t.Errorf("provider looked up %d times, want once", got)
+	}
+}
+
+func TestSharedCallerStopsWaitingAtItsDeadline(t *testing.T) {
+	provider := newGated()
+	defer close(provider.release)
+	shared := NewShared(provider, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := shared.Lookup(ctx, "Hans"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lookup error = %v, want the deadline of the caller", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Lookup took %s past the deadline of the caller", elapsed)
+	}
+}
+
+func TestSharedLookupIsBoundedByTimeout(t *testing.T) {
+	provider := newGated()
+	defer close(provider.release)
+	shared := NewShared(provider, 20*time.Millisecond)
+
+	if _, err := shared.Lookup(context.Background(), "Hans"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lookup error = %v, want the shared lookup timed out", err)
+	}
+}
+
+func TestSharedCallersGetTheirOwnCopy(t *testing.T) {
+	shared := NewShared(&fake{predictions: []Prediction{{Country_id: "DE", Probability: 0.5}}}, time.Second)
+	first, _ := shared.Lookup(context.Background(), "Hans")
+	first.Predictions[0].Country_id = "FR"
+	if second, _ := shared.Lookup(context.Background(), "Hans"); second.Predictions[0].Country_id != "DE" {
+		t.Errorf("second caller got %+v, changed by the first one", second.Predictions)
+	}
+}
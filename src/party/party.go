@@ -0,0 +1,67 @@
+// Package party holds the players of a party round, where the people in
+// the room pass the mic around and have their names guessed in turn,
+// which the session state keeps between turns
+package party
+
+import "sort"
+
+// MaxPlayers is the number of players of a round, the round ending once
+// they all had their turn so the session state stays small
+const MaxPlayers = 12
+
+// Player is a player of the round whose name was guessed
+type Player struct {
+	Name string `json:"name"`
+	// Country is the code of the most likely country of the player
+	Country string `json:"country"`
+	// Countries maps the codes of the guessed countries to their
+	// probabilities, normalized so they add up to 1
+	Countries map[string]float64 `json:"countries"`
+}
+
+// State is a party round in progress
+type State struct {
+	// Players are the players guessed so far, in the order they played
+	Players []Player `json:"players"`
+}
+
+// Full reports whether every player of the round had their turn
+func (state State) Full() bool {
+	return len(state.Players) >= MaxPlayers
+}
+
+// Share is the part of the room belonging to a group of countries
+type Share struct {
+	Group       string
+	Probability float64
+}
+
+// Summarize returns the part of the room belonging to each group of
+// countries, from the largest to the smallest, every player counting the
+// same. group returns the group of a country code, countries of an empty
+// group being left out
+func (state State) Summarize(group func(code string) string) []Share {
+	if len(state.Players) == 0 {
+		return nil
+	}
+	sums := map[string]float64{}
+	for _, player := range state.Players {
+		for code, probability := range player.Countries {
+			if key := group(code); key != "" {
+				sums[key] += probability / float64(len(state.Players))
+			}
+		}
+	}
+
+	shares := make([]Share, 0, len(sums))
+	for key, probability := range sums {
+		shares = append(shares, Share{Group: key, Probability: probability})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Probability != shares[j].Probability {
+			return shares[i].Probability > shares[j].Probability
+		}
+		return shares[i].Group < shares[j].Group
+	})
+	return shares
+}
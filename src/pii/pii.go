@@ -0,0 +1,45 @@
+// Package pii controls how personal information, such as
+// the names users ask about, shows up in the logs
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Mode is how names are written to the logs
+type Mode string
+
+const (
+	// Full logs names as they are
+	Full Mode = "full"
+	// Hash logs a short sha-256 prefix of names, enough to
+	// correlate log lines without exposing the names
+	Hash Mode = "hash"
+	// None omits names from the logs entirely
+	None Mode = "none"
+)
+
+// ParseMode returns the mode named by value, defaulting to Hash for unknown values
+func ParseMode(value string) Mode {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case Full, None:
+		return mode
+	default:
+		return Hash
+	}
+}
+
+// Redact returns name as it should be written to the logs under mode
+func (mode Mode) Redact(name string) string {
+	switch mode {
+	case Full:
+		return name
+	case None:
+		return "[omitted]"
+	default:
+		sum := sha256.Sum256([]byte(strings.ToLower(name)))
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	}
+}
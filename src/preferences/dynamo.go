@@ -0,0 +1,61 @@
+package preferences
+
+import (
+	"alexa-skill-test/src/erase"
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Dynamo stores the preferences in a DynamoDB table having the string partition
+// key "userId" and the index erase.AccountIndex, as json in the "preferences"
+// attribute so new settings need no migration
+type Dynamo struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// Load returns the preferences of the user, empty if they never chose any
+func (store Dynamo) Load(ctx context.Context, userID string) (Preferences, error) {
+	var preferences Preferences
+	output, err := store.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(store.Table),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if err != nil {
+		return preferences, err
+	}
+	saved, ok := output.Item["preferences"].(*types.AttributeValueMemberS)
+	if !ok {
+		return preferences, nil
+	}
+	err = json.Unmarshal([]byte(saved.Value), &preferences)
+	return preferences, err
+}
+
+// Save replaces the preferences of the user
+func (store Dynamo) Save(ctx context.Context, userID string, preferences Preferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+	_, err = store.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item: map[string]types.AttributeValue{
+			"userId":               &types.AttributeValueMemberS{Value: userID},
+			erase.AccountAttribute: &types.AttributeValueMemberS{Value: erase.AccountOf(userID)},
+			"preferences":          &types.AttributeValueMemberS{Value: string(data)},
+		},
+	})
+	return err
+}
+
+// Forget deletes the preferences of every person of the account userID
+func (store Dynamo) Forget(ctx context.Context, userID string) error {
+	return erase.Account(ctx, store.Client, store.Table, []string{"userId"}, userID)
+}
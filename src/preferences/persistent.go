@@ -0,0 +1,31 @@
+package preferences
+
+import (
+	"alexa-skill-test/src/storage"
+	"context"
+)
+
+// persistentKey is the persistent attribute keeping the preferences
+const persistentKey = "preferences"
+
+// Persistent stores the preferences in the persistent attributes of each user
+type Persistent struct {
+	Attributes storage.PersistentAttributes
+}
+
+// Load returns the preferences of the user, empty if they never chose any
+func (store Persistent) Load(ctx context.Context, userID string) (Preferences, error) {
+	var preferences Preferences
+	_, err := storage.Load(ctx, store.Attributes, userID, persistentKey, &preferences)
+	return preferences, err
+}
+
+// Save replaces the preferences of the user
+func (store Persistent) Save(ctx context.Context, userID string, preferences Preferences) error {
+	return storage.Update(ctx, store.Attributes, userID, persistentKey, preferences)
+}
+
+// Forget deletes the preferences of every person of the account userID
+func (store Persistent) Forget(ctx context.Context, userID string) error {
+	return store.Attributes.Forget(ctx, userID)
+}
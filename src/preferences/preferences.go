@@ -0,0 +1,106 @@
+// Package preferences holds how each user likes the skill to respond,
+// kept across sessions and cached in the session attributes
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Ways of speaking how likely a guess is
+const (
+	// Percent speaks the probability of each guess, e.g. "40 percent"
+	Percent = "percent"
+	// Words speaks how likely each guess is in words, e.g. "very likely"
+	Words = "words"
+)
+
+// Lengths of the responses speaking guesses
+const (
+	// Short only speaks the guesses, leaving out the notes around them
+	Short = "short"
+	// Detailed speaks the guesses along with every note about them, such as
+	// the capital and currency of the most likely country
+	Detailed = "detailed"
+)
+
+// Numbers of guesses spoken
+const (
+	// TopGuess only speaks the most likely country
+	TopGuess = "top"
+	// AllGuesses speaks the few most likely countries
+	AllGuesses = "all"
+)
+
+// attribute is the session attribute caching the preferences of the users, by speaker
+// key since the people of a household may take turns in a session
+const attribute = "preferences"
+
+// Preferences are the settings a user chose, empty fields use the configured defaults
+type Preferences struct {
+	// Confidence is how likely guesses are spoken, either Percent or Words
+	Confidence string `json:"confidence,omitempty"`
+	// Length is how much is said around the guesses, either Short or Detailed
+	Length string `json:"length,omitempty"`
+	// Guesses is how many guesses are spoken, either TopGuess or AllGuesses
+	Guesses string `json:"guesses,omitempty"`
+	// RememberName is the consent of the user to keeping their given name across sessions
+	RememberName bool `json:"rememberName,omitempty"`
+	// GivenName is the name read from the linked account, only kept with RememberName
+	GivenName string `json:"givenName,omitempty"`
+}
+
+// Set changes the setting that value is one of the choices of,
+// e.g. Words or Short, reporting false when value is no known choice
+func (preferences *Preferences) Set(value string) bool {
+	switch value {
+	case Percent, Words:
+		preferences.Confidence = value
+	case Short, Detailed:
+		preferences.Length = value
+	case TopGuess, AllGuesses:
+		preferences.Guesses = value
+	default:
+		return false
+	}
+	return true
+}
+
+// Store keeps the preferences of every user
+type Store interface {
+	// Load returns the preferences of the user, empty if they never chose any
+	Load(ctx context.Context, userID string) (Preferences, error)
+	// Save replaces the preferences of the user
+	Save(ctx context.Context, userID string, preferences Preferences) error
+	// Forget deletes the preferences of every person of the account userID
+	Forget(ctx context.Context, userID string) error
+}
+
+// Cached returns the preferences of speaker cached in attributes, if any
+func Cached(attributes map[string]interface{}, speaker string) (Preferences, bool) {
+	preferences, ok := cached(attributes)[speaker]
+	return preferences, ok
+}
+
+// Cache stores the preferences of speaker in attributes, along with those of the other speakers
+func (preferences Preferences) Cache(attributes map[string]interface{}, speaker string) {
+	all := cached(attributes)
+	all[speaker] = preferences
+	attributes[attribute] = all
+}
+
+// cached returns the preferences cached in attributes by speaker.
+// Attributes decoded from a request hold them as a generic json object,
+// so they are converted back through json
+func cached(attributes map[string]interface{}) map[string]Preferences {
+	all := map[string]Preferences{}
+	saved, ok := attributes[attribute]
+	if !ok || saved == nil {
+		return all
+	}
+	data, err := json.Marshal(saved)
+	if err != nil || json.Unmarshal(data, &all) != nil {
+		return map[string]Preferences{}
+	}
+	return all
+}
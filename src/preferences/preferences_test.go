@@ -0,0 +1,58 @@
+package preferences
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCacheBySpeaker(t *testing.T) {
+	attributes := map[string]interface{}{}
+	Preferences{Length: Short}.Cache(attributes, "user#a")
+	Preferences{Confidence: Words}.Cache(attributes, "user#b")
+
+	// the attributes of the next turn are decoded from json
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if cached, ok := Cached(decoded, "user#a"); !ok || cached != (Preferences{Length: Short}) {
+		t.Errorf("Cached(user#a) = %+v, %v", cached, ok)
+	}
+	if cached, ok := Cached(decoded, "user#b"); !ok || cached != (Preferences{Confidence: Words}) {
+		t.Errorf("Cached(user#b) = %+v, %v", cached, ok)
+	}
+	if cached, ok := Cached(decoded, "user#c"); ok {
+		t.Errorf("Cached(user#c) = %+v, want nothing cached", cached)
+	}
+}
+
+func TestCachedIgnoresMalformedAttribute(t *testing.T) {
+	attributes := map[string]interface{}{"preferences": "short"}
+	if cached, ok := Cached(attributes, "user"); ok {
+		t.Errorf("Cached = %+v, want nothing cached", cached)
+	}
+	Preferences{Length: Short}.Cache(attributes, "user")
+	if cached, ok := Cached(attributes, "user"); !ok || cached.Length != Short {
+		t.Errorf("Cached = %+v, %v after caching over a malformed attribute", cached, ok)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var preferences Preferences
+	for _, value := range []string{Words, Short, TopGuess} {
+		if !preferences.Set(value) {
+			t.Errorf("Set(%q) failed", value)
+		}
+	}
+	if want := (Preferences{Confidence: Words, Length: Short, Guesses: TopGuess}); preferences != want {
+		t.Errorf("preferences %+v, want %+v", preferences, want)
+	}
+	if preferences.Set("loud") {
+		t.Errorf("Set accepted an unknown choice")
+	}
+}
@@ -0,0 +1,37 @@
+// Package printout renders the page printer skills print when a user asks
+// for their result on paper, listing the guessed countries with their flags
+package printout
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+)
+
+//go:embed page.html
+var pageSource string
+
+// page is the template of the printed page, escaping every value it is given
+var page = template.Must(template.New("page").Parse(pageSource))
+
+// Sheet is what is printed, already phrased in the language of the user
+type Sheet struct {
+	// Lang is the language tag of the page, e.g. "en-US"
+	Lang  string
+	Title string
+	// Empty is printed instead of the countries when there are none
+	Empty     string
+	Countries []Country
+}
+
+// Country is one guessed country, the most likely first
+type Country struct {
+	Name    string
+	Percent string
+	FlagURL string
+}
+
+// Render writes the page printing sheet to w
+func Render(w io.Writer, sheet Sheet) error {
+	return page.Execute(w, sheet)
+}
@@ -0,0 +1,49 @@
+// Package quiz holds the names the quiz asks about and the progress of
+// a quiz, which the session state keeps between questions
+package quiz
+
+import (
+	_ "embed"
+	"math/rand"
+	"strings"
+)
+
+//go:embed pool.txt
+var embeddedPool string
+
+// Pool lists names strongly tied to one country, so the answer
+// to each question is clear cut
+var Pool = strings.Fields(embeddedPool)
+
+// State is the progress of a quiz
+type State struct {
+	// Name is the name of the current question
+	Name string `json:"name"`
+	// Answer is the code of the country the name is most common in
+	Answer string `json:"answer"`
+	// Score is the number of questions answered correctly
+	Score int `json:"score"`
+	// Round is the number of questions answered so far
+	Round int `json:"round"`
+	// Asked lists the names already asked so they aren't asked twice
+	Asked []string `json:"asked"`
+}
+
+// Pick returns a name from the pool that isn't in asked, chosen using rng.
+// Names are repeated once every one of them was asked
+func Pick(rng *rand.Rand, asked []string) string {
+	seen := map[string]bool{}
+	for _, name := range asked {
+		seen[name] = true
+	}
+	var fresh []string
+	for _, name := range Pool {
+		if !seen[name] {
+			fresh = append(fresh, name)
+		}
+	}
+	if len(fresh) == 0 {
+		fresh = Pool
+	}
+	return fresh[rng.Intn(len(fresh))]
+}
@@ -0,0 +1,177 @@
+// Package retry resends upstream api calls that failed for a transient
+// reason, waiting exponentially longer between attempts, so a blip on
+// the apis doesn't reach the user
+package retry
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy says how many times and how patiently a request is attempted
+type Policy struct {
+	// Attempts is the number of times a request is sent at most, including the first one
+	Attempts int
+	// Backoff is the longest wait before the second attempt, doubled for every following one
+	Backoff time.Duration
+	// MaxBackoff caps the wait between two attempts
+	MaxBackoff time.Duration
+	// AttemptTimeout bounds every single attempt, zero leaves them unbounded
+	AttemptTimeout time.Duration
+}
+
+// ErrExhausted is returned by Policy.Wait once the policy allows no more attempts
+var ErrExhausted = errors.New("retry: no attempts left")
+
+// Wait waits before the attempt following the failed attempt number attempt, the
+// first one being 1, for apis that aren't called over http.Transport. It returns
+// ErrExhausted when the policy allows no more attempts, and the error of ctx when
+// ctx is done first or its deadline leaves no time for the wait
+func (policy Policy) Wait(ctx context.Context, attempt int) error {
+	if attempt >= policy.Attempts {
+		return ErrExhausted
+	}
+	wait := policy.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return context.DeadlineExceeded
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns how long to wait after the failed attempt number attempt, picked
+// at random up to the exponential backoff so that clients failing together don't retry together
+func (policy Policy) backoff(attempt int) time.Duration {
+	limit := policy.Backoff << (attempt - 1)
+	if limit <= 0 || (policy.MaxBackoff > 0 && limit > policy.MaxBackoff) {
+		limit = policy.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)))
+}
+
+// Transport is an http.RoundTripper retrying idempotent requests according
+// to its policy when they time out or are answered with 429 or a 5xx status.
+// Requests with other methods are sent once
+type Transport struct {
+	// Base sends the attempts, http.DefaultTransport when nil
+	Base   http.RoundTripper
+	Policy Policy
+}
+
+// NewClient returns an http client retrying with policy whose requests,
+// retries included, are bounded by timeout
+func NewClient(policy Policy, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{Policy: policy}}
+}
+
+// RoundTrip sends req, retrying it while the failure is transient and
+// the context of req leaves time for another attempt
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !idempotent(req.Method) {
+		return base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		response, err := transport.attempt(base, req)
+		if attempt >= transport.Policy.Attempts || !retryable(ctx, response, err) {
+			return response, err
+		}
+
+		wait := transport.backoff(attempt, response)
+		if response != nil {
+			// drain the body so the connection can be reused by the next attempt
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, context.DeadlineExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends req once, bounded by the attempt timeout of the policy
+func (transport *Transport) attempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if transport.Policy.AttemptTimeout <= 0 {
+		return base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), transport.Policy.AttemptTimeout)
+	response, err := base.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the body is read after RoundTrip returns, so the attempt ends when it is closed
+	response.Body = cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// backoff returns how long to wait after the failed attempt number attempt.
+// A Retry-After header is honoured, otherwise it is the backoff of the policy
+func (transport *Transport) backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return transport.Policy.backoff(attempt)
+}
+
+// idempotent reports whether a request with method may safely be sent twice
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == ""
+}
+
+// retryable reports whether the outcome of an attempt is worth another one
+func retryable(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	}
+	return upstream.Temporary(response.StatusCode)
+}
+
+// cancelOnClose is a response body releasing the context of its attempt once closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and ends its attempt
+func (body cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
@@ -0,0 +1,241 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// quick retries right away, up to three attempts
+var quick = Policy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+// serve returns a client retrying with policy against a server answering every
+// request with statuses in turn, the last one once they run out, along with the
+// number of requests the server received
+func serve(t *testing.T, policy Policy, statuses ...int) (*http.Client, string, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(requests.Add(1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[i])
+		io.WriteString(w, http.StatusText(statuses[i]))
+	}))
+	t.Cleanup(server.Close)
+	return &http.Client{Transport: &Transport{Policy: policy}}, server.URL, &requests
+}
+
+func TestTransportRetriesTransientFailures(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			client, url, requests := serve(t, quick, status, http.StatusOK)
+			response, err := client.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			if response.StatusCode != http.StatusOK || requests.Load() != 2 {
+				t.Errorf("status %d after %d requests, want 200 after 2", response.StatusCode, requests.Load())
+			}
+		})
+	}
+}
+
+func TestTransportStopsAtTheAttemptLimit(t *testing.T) {
+	client, url, requests := serve(t, quick, http.StatusServiceUnavailable)
+	response, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusServiceUnavailable || requests.Load() != 3 {
+		t.Errorf("status %d after %d requests, want 503 after 3", response.StatusCode, requests.Load())
+	}
+	// the last failure is handed over whole, to be reported
+	if body, _ := io.ReadAll(response.Body); string(body) != "Service Unavailable" {
+		t.Errorf("body %q, want the body of the last attempt", body)
+	}
+}
+
+func TestTransportDoesntRetryPermanentFailures(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			client, url, requests := serve(t, quick, status, http.StatusOK)
+			response, err := client.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+			if response.StatusCode != status || requests.Load() != 1 {
+				t.Errorf("status %d after %d requests, want %d after 1", response.StatusCode, requests.Load(), status)
+			}
+		})
+	}
+}
+
+func TestTransportSendsOtherMethodsOnce(t *testing.T) {
+	client, url, requests := serve(t, quick, http.StatusServiceUnavailable, http.StatusOK)
+	response, err := client.Post(url, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if requests.Load() != 1 {
+		t.Errorf("POST sent %d times, want once", requests.Load())
+	}
+}
+
+func TestTransportStopsWhenContextIsDone(t *testing.T) {
+	// the backoff is long enough that only ctx can end the wait
+	patient := Policy{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}
+	client, url, requests := serve(t, patient, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	start := time.Now()
+	_, err := client.Do(request)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want as soon as ctx is done", elapsed)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("%d requests, want 1", requests.Load())
+	}
+}
+
+func TestTransportDoesntWaitPastTheDeadline(t *testing.T) {
+	patient := Policy{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &Transport{Policy: patient}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	start := time.Now()
+	_, err := client.Do(request)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("gave up after %s, want right away", elapsed)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("%d requests, want 1", requests.Load())
+	}
+}
+
+func TestTransportHonoursRetryAfter(t *testing.T) {
+	patient := Policy{Attempts: 2, Backoff: time.Hour, MaxBackoff: time.Hour}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &Transport{Policy: patient}, Timeout: 5 * time.Second}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err = %v, want the second attempt right away", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("status %d after %d requests, want 200 after 2", response.StatusCode, requests.Load())
+	}
+}
+
+func TestTransportRetriesAttemptsTimingOut(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+	policy := quick
+	policy.AttemptTimeout = 50 * time.Millisecond
+	client := &http.Client{Transport: &Transport{Policy: policy}, Timeout: 5 * time.Second}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("status %d after %d requests, want 200 after 2", response.StatusCode, requests.Load())
+	}
+}
+
+func TestBackoffIsJitteredWithinBounds(t *testing.T) {
+	policy := Policy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	limits := map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		70: time.Second, // shifted past the size of a duration
+	}
+	for attempt, limit := range limits {
+		var longest time.Duration
+		for i := 0; i < 1000; i++ {
+			wait := policy.backoff(attempt)
+			if wait < 0 || wait >= limit {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s)", attempt, wait, limit)
+			}
+			if wait > longest {
+				longest = wait
+			}
+		}
+		// waits are spread up to the limit, not all the same
+		if longest < limit/2 {
+			t.Errorf("longest backoff(%d) = %s out of 1000, want spread up to %s", attempt, longest, limit)
+		}
+	}
+	if wait := (Policy{}).backoff(1); wait != 0 {
+		t.Errorf("backoff without any = %s, want 0", wait)
+	}
+}
+
+func TestWait(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	short, cancelShort := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelShort()
+
+	tests := map[string]struct {
+		policy  Policy
+		ctx     context.Context
+		attempt int
+		err     error
+	}{
+		"attempts left":      {policy: quick, ctx: context.Background(), attempt: 2},
+		"no attempts left":   {policy: quick, ctx: context.Background(), attempt: 3, err: ErrExhausted},
+		"context done":       {policy: Policy{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}, ctx: cancelled, attempt: 1, err: context.Canceled},
+		"deadline too close": {policy: Policy{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}, ctx: short, attempt: 1, err: context.DeadlineExceeded},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.policy.Wait(test.ctx, test.attempt); !errors.Is(err, test.err) {
+				t.Errorf("Wait = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
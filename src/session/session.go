@@ -0,0 +1,217 @@
+// Package session keeps the state of a conversation across its turns in
+// one versioned object of the session attributes, so handlers read and
+// write it through typed accessors rather than loose attribute names
+package session
+
+import (
+	"alexa-skill-test/src/party"
+	"alexa-skill-test/src/quiz"
+	"encoding/json"
+)
+
+// Version is the version of the state written to the session attributes.
+// It is raised whenever a field changes meaning, and Load converts the
+// states of older versions still held by sessions in progress
+const Version = 1
+
+// attribute is the session attribute holding the state
+const attribute = "state"
+
+// legacy attributes are the ones the state was kept in before it was versioned
+const (
+	legacyLastSpeech    = "lastSpeech"
+	legacyLastCountry   = "lastCountry"
+	legacyDialogState   = "dialogState"
+	legacyDialogCountry = "dialogCountry"
+	legacyQuiz          = "quiz"
+	legacyNameAttempts  = "nameAttempts"
+)
+
+// LastGuess is the last guess spoken in the session
+type LastGuess struct {
+	// Name is the name guessed
+	Name string `json:"name,omitempty"`
+	// Speech is the ssml the guess was spoken with
+	Speech string `json:"speech,omitempty"`
+	// Country is the code of the most likely country of the guess, if any
+	Country string `json:"country,omitempty"`
+	// Sources names the providers the guess comes from, Count is the number
+	// of records behind it and Vintage when they were collected, as far as
+	// the providers told, so the guess can be explained later
+	Sources []string `json:"sources,omitempty"`
+	Count   int      `json:"count,omitempty"`
+	Vintage string   `json:"vintage,omitempty"`
+	// Favoured is the code of the country the guess was leaned towards
+	// because the user is likely there, if it changed the guess
+	Favoured string `json:"favoured,omitempty"`
+}
+
+// Dialog is the question the user was asked last, if any
+type Dialog struct {
+	// State names the pending question, empty when there is none
+	State string `json:"state,omitempty"`
+	// Country is the code of the country the question is about, if any
+	Country string `json:"country,omitempty"`
+}
+
+// State is everything the skill remembers between the turns of a session
+type State struct {
+	Version   int         `json:"version"`
+	LastGuess LastGuess   `json:"lastGuess"`
+	Dialog    Dialog      `json:"dialog"`
+	Quiz      *quiz.State `json:"quiz,omitempty"`
+	// Party is the party round in progress, if any
+	Party *party.State `json:"party,omitempty"`
+	// NameAttempts counts how many times in a row a name was asked for
+	NameAttempts int `json:"nameAttempts,omitempty"`
+}
+
+// Store reads and writes the state kept in the attributes it was loaded
+// from. The state is decoded on every read and written back by every
+// setter, so stores of the same attributes never overwrite each other
+type Store struct {
+	attributes map[string]interface{}
+}
+
+// Load returns the store of the state held by attributes. Setters write to
+// attributes, so they must not be called on the attributes of a request
+func Load(attributes map[string]interface{}) *Store {
+	return &Store{attributes: attributes}
+}
+
+// state returns the state held by the attributes, empty when they hold none.
+// Attributes decoded from a request hold the state as a generic json object,
+// so it is converted back through json. A malformed state is dropped rather
+// than failing the request, and the loose attributes used before the state
+// was versioned are read when there is no state yet
+func (store *Store) state() State {
+	var state State
+	switch saved := store.attributes[attribute].(type) {
+	case nil:
+		return fromLegacy(store.attributes)
+	case State:
+		return saved
+	default:
+		data, err := json.Marshal(saved)
+		if err != nil || json.Unmarshal(data, &state) != nil {
+			state = State{}
+		}
+	}
+	return migrate(state)
+}
+
+// migrate converts state from the version it was written with to Version.
+// Versions newer than this one, written by a newer deployment while it was
+// rolled out, are read as far as their fields are known
+func migrate(state State) State {
+	// there is no version 0 state to convert, it only exists as loose attributes
+	state.Version = Version
+	return state
+}
+
+// fromLegacy returns the state held by the loose attributes written before
+// the state was versioned
+func fromLegacy(attributes map[string]interface{}) State {
+	state := State{Version: Version}
+	state.LastGuess.Speech, _ = attributes[legacyLastSpeech].(string)
+	state.LastGuess.Country, _ = attributes[legacyLastCountry].(string)
+	state.Dialog.State, _ = attributes[legacyDialogState].(string)
+	state.Dialog.Country, _ = attributes[legacyDialogCountry].(string)
+	if attempts, ok := attributes[legacyNameAttempts].(float64); ok {
+		state.NameAttempts = int(attempts)
+	}
+	if saved, ok := attributes[legacyQuiz]; ok && saved != nil {
+		var progress quiz.State
+		if data, err := json.Marshal(saved); err == nil && json.Unmarshal(data, &progress) == nil && progress.Name != "" {
+			state.Quiz = &progress
+		}
+	}
+	return state
+}
+
+// update applies change to the state and writes it to the attributes,
+// replacing the loose attributes it may have come from
+func (store *Store) update(change func(state *State)) {
+	state := store.state()
+	change(&state)
+	for _, name := range []string{legacyLastSpeech, legacyLastCountry, legacyDialogState, legacyDialogCountry, legacyQuiz, legacyNameAttempts} {
+		delete(store.attributes, name)
+	}
+	store.attributes[attribute] = state
+}
+
+// LastGuess returns the last guess of the session, if there was one
+func (store *Store) LastGuess() (LastGuess, bool) {
+	last := store.state().LastGuess
+	return last, last.Speech != ""
+}
+
+// SetLastGuess records guess as the last guess of the session
+func (store *Store) SetLastGuess(guess LastGuess) {
+	store.update(func(state *State) { state.LastGuess = guess })
+}
+
+// DialogState returns the question the user was asked last
+func (store *Store) DialogState() Dialog {
+	return store.state().Dialog
+}
+
+// SetDialogState records dialog as the question the user was asked last
+func (store *Store) SetDialogState(dialog Dialog) {
+	store.update(func(state *State) { state.Dialog = dialog })
+}
+
+// Quiz returns the progress of the quiz in progress, if any
+func (store *Store) Quiz() (quiz.State, bool) {
+	progress := store.state().Quiz
+	if progress == nil || progress.Name == "" {
+		return quiz.State{}, false
+	}
+	return *progress, true
+}
+
+// SetQuiz records progress as the progress of the quiz in progress
+func (store *Store) SetQuiz(progress quiz.State) {
+	store.update(func(state *State) { state.Quiz = &progress })
+}
+
+// ClearQuiz forgets the quiz in progress
+func (store *Store) ClearQuiz() {
+	store.update(func(state *State) { state.Quiz = nil })
+}
+
+// QuizScore returns the number of questions of the quiz in progress
+// answered correctly and the number answered so far, zero without a quiz
+func (store *Store) QuizScore() (score int, round int) {
+	progress, _ := store.Quiz()
+	return progress.Score, progress.Round
+}
+
+// Party returns the party round in progress, if any
+func (store *Store) Party() (party.State, bool) {
+	round := store.state().Party
+	if round == nil {
+		return party.State{}, false
+	}
+	return *round, true
+}
+
+// SetParty records round as the party round in progress
+func (store *Store) SetParty(round party.State) {
+	store.update(func(state *State) { state.Party = &round })
+}
+
+// ClearParty forgets the party round in progress
+func (store *Store) ClearParty() {
+	store.update(func(state *State) { state.Party = nil })
+}
+
+// NameAttempts returns how many times in a row a name was asked for
+func (store *Store) NameAttempts() int {
+	return store.state().NameAttempts
+}
+
+// SetNameAttempts records how many times in a row a name was asked for
+func (store *Store) SetNameAttempts(attempts int) {
+	store.update(func(state *State) { state.NameAttempts = attempts })
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"alexa-skill-test/src/erase"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Dynamo keeps the attributes in a DynamoDB table having the string partition
+// key "id" and the index erase.AccountIndex, as a map in the "attributes"
+// attribute, like the DynamoDB persistence adapter of the ASK SDK does by default
+type Dynamo struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// Get returns the attributes saved for id, empty when none were saved
+func (store Dynamo) Get(ctx context.Context, id string) (map[string]interface{}, error) {
+	output, err := store.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(store.Table),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	saved, ok := output.Item["attributes"].(*types.AttributeValueMemberM)
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	return fromAttributeValue(saved).(map[string]interface{}), nil
+}
+
+// Save replaces the attributes saved for id
+func (store Dynamo) Save(ctx context.Context, id string, attributes map[string]interface{}) error {
+	value, err := toAttributeValue(attributes)
+	if err != nil {
+		return err
+	}
+	_, err = store.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item: map[string]types.AttributeValue{
+			"id":                   &types.AttributeValueMemberS{Value: id},
+			erase.AccountAttribute: &types.AttributeValueMemberS{Value: erase.AccountOf(id)},
+			"attributes":           value,
+		},
+	})
+	return err
+}
+
+// Delete forgets the attributes saved for id
+func (store Dynamo) Delete(ctx context.Context, id string) error {
+	_, err := store.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(store.Table),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	return err
+}
+
+// Forget deletes the attributes of every person of the account userID
+func (store Dynamo) Forget(ctx context.Context, userID string) error {
+	return erase.Account(ctx, store.Client, store.Table, []string{"id"}, userID)
+}
+
+// toAttributeValue converts a generic json value to its DynamoDB attribute value
+func toAttributeValue(value interface{}) (types.AttributeValue, error) {
+	switch value := value.(type) {
+	case nil:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case string:
+		return &types.AttributeValueMemberS{Value: value}, nil
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: value}, nil
+	case float64:
+		return &types.AttributeValueMemberN{Value: strconv.FormatFloat(value, 'f', -1, 64)}, nil
+	case []interface{}:
+		list := make([]types.AttributeValue, 0, len(value))
+		for _, item := range value {
+			converted, err := toAttributeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case map[string]interface{}:
+		fields := make(map[string]types.AttributeValue, len(value))
+		for key, item := range value {
+			converted, err := toAttributeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = converted
+		}
+		return &types.AttributeValueMemberM{Value: fields}, nil
+	}
+	return nil, fmt.Errorf("storage: unsupported attribute of type %T", value)
+}
+
+// fromAttributeValue converts a DynamoDB attribute value back to a generic json value
+func fromAttributeValue(value types.AttributeValue) interface{} {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return value.Value
+	case *types.AttributeValueMemberBOOL:
+		return value.Value
+	case *types.AttributeValueMemberN:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *types.AttributeValueMemberL:
+		list := make([]interface{}, 0, len(value.Value))
+		for _, item := range value.Value {
+			list = append(list, fromAttributeValue(item))
+		}
+		return list
+	case *types.AttributeValueMemberM:
+		fields := make(map[string]interface{}, len(value.Value))
+		for key, item := range value.Value {
+			fields[key] = fromAttributeValue(item)
+		}
+		return fields
+	}
+	return nil
+}
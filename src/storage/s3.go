@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 keeps the attributes of each id as a json object of a bucket, its key
+// being the id under Prefix, like the S3 persistence adapter of the ASK SDK
+type S3 struct {
+	Client *s3.Client
+	Bucket string
+	// Prefix is the path the objects are kept under, the root of the bucket when empty
+	Prefix string
+}
+
+// Get returns the attributes saved for id, empty when none were saved
+func (store S3) Get(ctx context.Context, id string) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	output, err := store.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.key(id)),
+	})
+	var missing *types.NoSuchKey
+	if errors.As(err, &missing) {
+		return attributes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	err = json.NewDecoder(output.Body).Decode(&attributes)
+	return attributes, err
+}
+
+// Save replaces the attributes saved for id
+func (store S3) Save(ctx context.Context, id string, attributes map[string]interface{}) error {
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+	_, err = store.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(store.Bucket),
+		Key:         aws.String(store.key(id)),
+		Body:        strings.NewReader(string(data)),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// Delete forgets the attributes saved for id
+func (store S3) Delete(ctx context.Context, id string) error {
+	_, err := store.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.key(id)),
+	})
+	return err
+}
+
+// Forget deletes the attributes of every person of the account userID,
+// whose ids start with userID as built by history.Key
+func (store S3) Forget(ctx context.Context, userID string) error {
+	account := store.key(userID)
+	paginator := s3.NewListObjectsV2Paginator(store.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(store.Bucket),
+		Prefix: aws.String(account),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			if key != account && !strings.HasPrefix(key, account+"#") {
+				continue
+			}
+			if _, err := store.Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(store.Bucket), Key: object.Key}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// key returns the key of the object keeping the attributes of id
+func (store S3) key(id string) string {
+	return path.Join(store.Prefix, id)
+}
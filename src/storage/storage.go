@@ -0,0 +1,67 @@
+// Package storage keeps the persistent attributes of every user, a document
+// per user holding the data of each feature under its own key, the way the
+// persistence adapters of the ASK SDK do, in DynamoDB or in S3
+package storage
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PersistentAttributes keeps the attributes of every user across sessions
+type PersistentAttributes interface {
+	// Get returns the attributes saved for id, empty when none were saved
+	Get(ctx context.Context, id string) (map[string]interface{}, error)
+	// Save replaces the attributes saved for id
+	Save(ctx context.Context, id string, attributes map[string]interface{}) error
+	// Delete forgets the attributes saved for id
+	Delete(ctx context.Context, id string) error
+	// Forget deletes the attributes of every person of the account userID
+	Forget(ctx context.Context, userID string) error
+}
+
+// Load decodes the attribute key saved for id into target,
+// reporting false when there is none
+func Load(ctx context.Context, store PersistentAttributes, id string, key string, target interface{}) (bool, error) {
+	attributes, err := store.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	saved, ok := attributes[key]
+	if !ok || saved == nil {
+		return false, nil
+	}
+	// the attributes are generic json values, so they are converted back through json
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, target)
+}
+
+// Update replaces the attribute key saved for id with value, keeping the
+// other attributes of id. A nil value removes the attribute
+func Update(ctx context.Context, store PersistentAttributes, id string, key string, value interface{}) error {
+	attributes, err := store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		delete(attributes, key)
+	} else {
+		// saved as generic json values, like they are read back
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		attributes[key] = generic
+	}
+	if len(attributes) == 0 {
+		return store.Delete(ctx, id)
+	}
+	return store.Save(ctx, id, attributes)
+}
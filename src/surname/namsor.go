@@ -0,0 +1,74 @@
+// Package surname finds where family names come from, which unlike the
+// nationality of a first name describes the origin of a whole family
+package surname
+
+import (
+	"alexa-skill-test/src/upstream"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNoAPIKey means the provider can't be queried because no key was configured
+var ErrNoAPIKey = errors.New("surname: no api key configured")
+
+// Origin is the most likely country a surname comes from, along with the runner up
+type Origin struct {
+	Surname                  string  `json:"lastName"`
+	CountryOrigin            string  `json:"countryOrigin"`
+	CountryOriginAlt         string  `json:"countryOriginAlt"`
+	Region                   string  `json:"regionOrigin"`
+	ProbabilityCalibrated    float64 `json:"probabilityCalibrated"`
+	ProbabilityAltCalibrated float64 `json:"probabilityAltCalibrated"`
+}
+
+// NamSor queries the origin api of NamSor, which infers the origin
+// of a full name but works best on the surname
+type NamSor struct {
+	// BaseURL is the origin endpoint, names are appended to it as path segments
+	BaseURL string
+	// APIKey authenticates the requests
+	APIKey string
+	HTTP   *http.Client
+}
+
+// unknownName stands in for the first name or surname when only the other
+// is known, since the api expects both
+const unknownName = "-"
+
+// Origin returns where surname most likely comes from. The first name
+// refines the guess when it is known and may be left empty otherwise,
+// and so may the surname when the origin of a first name is wanted
+func (namsor NamSor) Origin(ctx context.Context, firstName string, surname string) (Origin, error) {
+	var origin Origin
+	if namsor.APIKey == "" {
+		return origin, ErrNoAPIKey
+	}
+	if firstName == "" {
+		firstName = unknownName
+	}
+	if surname == "" {
+		surname = unknownName
+	}
+	endpoint := strings.TrimSuffix(namsor.BaseURL, "/") + "/" + url.PathEscape(firstName) + "/" + url.PathEscape(surname)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return origin, err
+	}
+	req.Header.Set("X-API-KEY", namsor.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	response, err := namsor.HTTP.Do(req)
+	if err != nil {
+		return origin, upstream.Transport("namsor", err)
+	}
+	defer response.Body.Close()
+	if err := upstream.Check("namsor", response); err != nil {
+		return origin, err
+	}
+	err = json.NewDecoder(response.Body).Decode(&origin)
+	return origin, upstream.Malformed("namsor", err)
+}
@@ -0,0 +1,103 @@
+// Package upstream describes how the apis the skill depends on failed,
+// so callers can tell the failures worth retrying from those worth reporting
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// StatusError is returned when an api answers with a status other than 200 OK
+type StatusError struct {
+	// Service names the api, e.g. "nationalize"
+	Service string
+	// StatusCode is the http status the api answered with
+	StatusCode int
+}
+
+// Error describes the status and the api that answered with it
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", err.Service, err.StatusCode, http.StatusText(err.StatusCode))
+}
+
+// Throttled reports whether the api is refusing requests until later
+func (err *StatusError) Throttled() bool {
+	return err.StatusCode == http.StatusTooManyRequests
+}
+
+// Temporary reports whether the same request may succeed when sent again
+func (err *StatusError) Temporary() bool {
+	return Temporary(err.StatusCode)
+}
+
+// Temporary reports whether a request answered with status may succeed when
+// sent again. Throttling and server errors pass, while any other status means
+// the request itself is wrong and would be refused again
+func Temporary(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// Check returns a StatusError unless response, sent to service, is 200 OK
+func Check(service string, response *http.Response) error {
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+	return &StatusError{Service: service, StatusCode: response.StatusCode}
+}
+
+// MalformedError is returned when the body of a 200 OK response can't be decoded
+type MalformedError struct {
+	Service string
+	Err     error
+}
+
+// Error describes the api and why its response couldn't be decoded
+func (err *MalformedError) Error() string {
+	return fmt.Sprintf("%s: malformed response: %v", err.Service, err.Err)
+}
+
+// Unwrap returns the decoding error
+func (err *MalformedError) Unwrap() error {
+	return err.Err
+}
+
+// Malformed wraps the error decoding the response of service, nil staying nil
+func Malformed(service string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &MalformedError{Service: service, Err: err}
+}
+
+// TransportError is returned when a request couldn't be sent to an api or its
+// response couldn't be received. It leaves out the url of the request, whose
+// query carries the names users ask about, so it can be logged as it is
+type TransportError struct {
+	Service string
+	Err     error
+}
+
+// Error describes the api and why it couldn't be reached
+func (err *TransportError) Error() string {
+	return fmt.Sprintf("%s: request failed: %v", err.Service, err.Err)
+}
+
+// Unwrap returns the cause of the failure, such as a timeout
+func (err *TransportError) Unwrap() error {
+	return err.Err
+}
+
+// Transport wraps the error sending a request to service, nil staying nil.
+// The url an http.Client adds to its errors is stripped, keeping only the cause
+func Transport(service string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return &TransportError{Service: service, Err: err}
+}
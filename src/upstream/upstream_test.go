@@ -0,0 +1,69 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransportLeavesOutTheURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/?name=Zoë+Secret", nil)
+	_, sent := http.DefaultClient.Do(req)
+	if sent == nil || !strings.Contains(sent.Error(), "Secret") {
+		t.Fatalf("expected the client error to carry the url, got %v", sent)
+	}
+
+	err := Transport("nationalize", sent)
+	if strings.Contains(err.Error(), "Secret") || strings.Contains(err.Error(), server.URL) {
+		t.Errorf("error %q carries the url of the request", err)
+	}
+	if !strings.HasPrefix(err.Error(), "nationalize: ") {
+		t.Errorf("error %q doesn't name the service", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error %q doesn't unwrap to its cause", err)
+	}
+}
+
+func TestTransportKeepsNil(t *testing.T) {
+	if err := Transport("nationalize", nil); err != nil {
+		t.Errorf("Transport(nil) = %v, want nil", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		throttled bool
+		temporary bool
+	}{
+		{http.StatusOK, false, false, false},
+		{http.StatusBadRequest, true, false, false},
+		{http.StatusTooManyRequests, true, true, true},
+		{http.StatusBadGateway, true, false, true},
+	}
+	for _, test := range tests {
+		err := Check("countries", &http.Response{StatusCode: test.status})
+		if (err != nil) != test.wantErr {
+			t.Errorf("Check(%d) = %v, want error %v", test.status, err, test.wantErr)
+			continue
+		}
+		var statusErr *StatusError
+		if !test.wantErr || !errors.As(err, &statusErr) {
+			continue
+		}
+		if statusErr.Throttled() != test.throttled || statusErr.Temporary() != test.temporary {
+			t.Errorf("Check(%d): throttled %v temporary %v, want %v %v",
+				test.status, statusErr.Throttled(), statusErr.Temporary(), test.throttled, test.temporary)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package validation checks the slots of an intent against the
+// slots that intent declares, so handlers receive usable values
+// and users hear a consistent prompt when something is missing
+package validation
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/names"
+	"fmt"
+	"strconv"
+)
+
+// Kind is the type of value a slot holds
+type Kind int
+
+const (
+	// Name slots hold a person's name
+	Name Kind = iota
+	// Number slots hold a whole number
+	Number
+	// Text slots hold any non empty value, such as a country
+	Text
+)
+
+// Slot declares a slot that an intent reads
+type Slot struct {
+	Name     string
+	Kind     Kind
+	Required bool
+	// Description is how the slot is referred to when prompting the user, e.g. "first name"
+	Description string
+}
+
+// Error reports a required slot that was missing or a slot holding an invalid value
+type Error struct {
+	Slot    Slot
+	Value   string
+	Missing bool
+}
+
+func (err *Error) Error() string {
+	if err.Missing {
+		return fmt.Sprintf("missing required slot %s", err.Slot.Name)
+	}
+	return fmt.Sprintf("invalid value %q for slot %s", err.Value, err.Slot.Name)
+}
+
+// Prompt is what the user hears when the slot needs to be provided again
+func (err *Error) Prompt() string {
+	return fmt.Sprintf("Sorry, I didn't catch the %s. Could you say it again?", err.Slot.Description)
+}
+
+// Validate checks the slots of intent against the declared slots and
+// returns the values of the valid ones keyed by slot name. The error,
+// if any, is an *Error for the first slot failing validation
+func Validate(intent alexa.Intent, slots []Slot) (map[string]string, error) {
+	values := map[string]string{}
+	for _, slot := range slots {
+		value := intent.Slots[slot.Name].Value
+		if value == "" {
+			if slot.Required {
+				return values, &Error{Slot: slot, Missing: true}
+			}
+			continue
+		}
+		if !valid(slot.Kind, value) {
+			return values, &Error{Slot: slot, Value: value}
+		}
+		values[slot.Name] = value
+	}
+	return values, nil
+}
+
+// valid reports whether value can be used as a slot of the given kind
+func valid(kind Kind, value string) bool {
+	switch kind {
+	case Name:
+		return names.Sanitize(value) != ""
+	case Number:
+		_, err := strconv.Atoi(value)
+		return err == nil
+	default:
+		return true
+	}
+}
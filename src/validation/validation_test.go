@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"alexa-skill-test/src/alexa"
+	"errors"
+	"testing"
+)
+
+// intent returns an intent whose slots have values
+func intent(values map[string]string) alexa.Intent {
+	slots := map[string]alexa.Slot{}
+	for name, value := range values {
+		slots[name] = alexa.Slot{Name: name, Value: value}
+	}
+	return alexa.Intent{Name: "TestIntent", Slots: slots}
+}
+
+var declared = []Slot{
+	{Name: "first_name", Kind: Name, Required: true, Description: "name"},
+	{Name: "count", Kind: Number, Description: "number"},
+	{Name: "country", Kind: Text, Description: "country"},
+}
+
+func TestValidateValidSlots(t *testing.T) {
+	values, err := Validate(intent(map[string]string{"first_name": "Hans", "count": "3", "country": "France"}), declared)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if values["first_name"] != "Hans" || values["count"] != "3" || values["country"] != "France" {
+		t.Errorf("Validate = %v", values)
+	}
+}
+
+func TestValidateSkipsMissingOptionalSlots(t *testing.T) {
+	values, err := Validate(intent(map[string]string{"first_name": "Hans"}), declared)
+	if err != nil || len(values) != 1 {
+		t.Errorf("Validate = %v, %v, want only the name", values, err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		slot    string
+		missing bool
+	}{
+		{"missing required", map[string]string{"count": "3"}, "first_name", true},
+		{"name without letters", map[string]string{"first_name": "123"}, "first_name", false},
+		{"not a number", map[string]string{"first_name": "Hans", "count": "three"}, "count", false},
+	}
+	for _, test := range tests {
+		_, err := Validate(intent(test.values), declared)
+		var slotErr *Error
+		if !errors.As(err, &slotErr) {
+			t.Errorf("%s: Validate error = %v, want an *Error", test.name, err)
+			continue
+		}
+		if slotErr.Slot.Name != test.slot || slotErr.Missing != test.missing {
+			t.Errorf("%s: error about %s, missing %v, want %s, missing %v", test.name, slotErr.Slot.Name, slotErr.Missing, test.slot, test.missing)
+		}
+	}
+}
+
+func TestErrorPrompt(t *testing.T) {
+	err := &Error{Slot: declared[0], Missing: true}
+	if got := err.Prompt(); got != "Sorry, I didn't catch the name. Could you say it again?" {
+		t.Errorf("Prompt() = %q", got)
+	}
+	if got := err.Error(); got != "missing required slot first_name" {
+		t.Errorf("Error() = %q", got)
+	}
+}
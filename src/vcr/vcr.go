@@ -0,0 +1,136 @@
+// Package vcr records the responses of the upstream apis to fixture files
+// and replays them later, so the skill can be run against real payloads
+// without the network and without their flakiness
+package vcr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Mode is what a Transport does with the requests it receives
+type Mode string
+
+const (
+	// Record sends the requests and saves their responses, replacing older ones
+	Record Mode = "record"
+	// Replay answers from the saved responses only, never sending anything
+	Replay Mode = "replay"
+)
+
+// ErrNotRecorded is returned when replaying a request no response was recorded for
+var ErrNotRecorded = errors.New("vcr: no recorded response")
+
+// Transport records or replays the GET requests it receives. Other requests
+// are only sent when recording, since they carry user data such as tokens
+type Transport struct {
+	// Base sends the requests being recorded, http.DefaultTransport when nil
+	Base http.RoundTripper
+	Mode Mode
+	// Dir holds a fixture file per request
+	Dir string
+}
+
+// fixture is a response saved to a file, along with the request it answered
+type fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// RoundTrip answers req as the mode of the transport says
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport.Mode == Replay {
+		return transport.replay(req)
+	}
+	base := transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	response, err := base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet {
+		return response, err
+	}
+	return transport.record(req, response)
+}
+
+// replay returns the response recorded for req
+func (transport *Transport) replay(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w for %s %s, only GET requests are recorded", ErrNotRecorded, req.Method, req.URL.Host)
+	}
+	data, err := os.ReadFile(transport.path(req))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s", ErrNotRecorded, req.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved fixture
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("vcr: malformed fixture of %s: %w", req.URL, err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", saved.Status, http.StatusText(saved.Status)),
+		StatusCode:    saved.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        saved.Header,
+		Body:          io.NopCloser(strings.NewReader(saved.Body)),
+		ContentLength: int64(len(saved.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record saves response to the fixture of req and returns a copy of it,
+// since its body can only be read once. Failing to save it fails the request,
+// so a recording session can't silently miss fixtures
+func (transport *Transport) record(req *http.Request, response *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	// headers changing on every response would make re-recorded fixtures differ for nothing
+	header := response.Header.Clone()
+	header.Del("Set-Cookie")
+	header.Del("Date")
+	data, err := json.MarshalIndent(fixture{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: response.StatusCode,
+		Header: header,
+		Body:   string(body),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(transport.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(transport.path(req), append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// path returns the fixture file of req, named after the host it
+// was sent to and a hash of the request telling requests apart
+func (transport *Transport) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return filepath.Join(transport.Dir, req.URL.Hostname()+"-"+hex.EncodeToString(sum[:8])+".json")
+}
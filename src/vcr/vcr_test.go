@@ -0,0 +1,163 @@
+package vcr
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// get sends a GET of url through transport, returning the status and body of its response
+func get(t *testing.T, transport *Transport, url string) (int, string, error) {
+	client := &http.Client{Transport: transport}
+	response, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body), nil
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Query().Get("name") == "Xyzzy" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		w.Write([]byte(`{"name":"` + r.URL.Query().Get("name") + `"}`))
+	}))
+	urls := map[string]int{
+		server.URL + "/?name=Hans":  http.StatusOK,
+		server.URL + "/?name=Anna":  http.StatusOK,
+		server.URL + "/?name=Xyzzy": http.StatusUnprocessableEntity,
+	}
+
+	recorder := &Transport{Mode: Record, Dir: dir}
+	recorded := map[string]string{}
+	for url, want := range urls {
+		status, body, err := get(t, recorder, url)
+		if err != nil || status != want {
+			t.Fatalf("recording %s: %d, %v", url, status, err)
+		}
+		recorded[url] = body
+	}
+	server.Close()
+
+	player := &Transport{Mode: Replay, Dir: dir}
+	for url, want := range urls {
+		status, body, err := get(t, player, url)
+		if err != nil {
+			t.Errorf("replaying %s failed: %v", url, err)
+			continue
+		}
+		if status != want || body != recorded[url] {
+			t.Errorf("replaying %s: %d %q, want %d %q", url, status, body, want, recorded[url])
+		}
+	}
+	if got := requests.Load(); got != int32(len(urls)) {
+		t.Errorf("server got %d requests, want one per url", got)
+	}
+}
+
+func TestReplayKeepsHeaders(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Rate-Limit-Remaining", "41")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	if _, _, err := get(t, &Transport{Mode: Record, Dir: dir}, server.URL+"/?name=Hans"); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := (&http.Client{Transport: &Transport{Mode: Replay, Dir: dir}}).Get(server.URL + "/?name=Hans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if got := response.Header.Get("X-Rate-Limit-Remaining"); got != "41" {
+		t.Errorf("replayed rate limit header %q, want 41", got)
+	}
+	if got := response.Header.Get("Set-Cookie"); got != "" {
+		t.Errorf("cookie %q was recorded", got)
+	}
+}
+
+func TestReplayMatchesTheWholeURL(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	if _, _, err := get(t, &Transport{Mode: Record, Dir: dir}, server.URL+"/?name=Hans"); err != nil {
+		t.Fatal(err)
+	}
+
+	player := &Transport{Mode: Replay, Dir: dir}
+	for _, url := range []string{server.URL + "/?name=Anna", server.URL + "/other?name=Hans", server.URL + "/?name=Hans&apikey=k"} {
+		if _, _, err := get(t, player, url); !errors.Is(err, ErrNotRecorded) {
+			t.Errorf("replaying %s: error %v, want ErrNotRecorded", url, err)
+		}
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	for name, dir := range map[string]string{
+		"empty directory":   t.TempDir(),
+		"missing directory": filepath.Join(t.TempDir(), "missing"),
+	} {
+		_, _, err := get(t, &Transport{Mode: Replay, Dir: dir}, "https://api.nationalize.io/?name=Hans")
+		if !errors.Is(err, ErrNotRecorded) {
+			t.Errorf("%s: error %v, want ErrNotRecorded", name, err)
+		}
+	}
+}
+
+func TestReplayMalformedCassette(t *testing.T) {
+	dir := t.TempDir()
+	player := &Transport{Mode: Replay, Dir: dir}
+	request, _ := http.NewRequest(http.MethodGet, "https://api.nationalize.io/?name=Hans", nil)
+	if err := os.WriteFile(player.path(request), []byte(`{"status":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := get(t, player, "https://api.nationalize.io/?name=Hans")
+	if err == nil || errors.Is(err, ErrNotRecorded) || !strings.Contains(err.Error(), "malformed fixture") {
+		t.Errorf("error %v, want a malformed fixture", err)
+	}
+}
+
+func TestOnlyGetRequestsAreRecorded(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"secret"}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Mode: Record, Dir: dir}}
+	response, err := client.Post(server.URL+"/token", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("a POST was recorded to %v", entries)
+	}
+
+	client.Transport = &Transport{Mode: Replay, Dir: dir}
+	if _, err := client.Post(server.URL+"/token", "application/json", strings.NewReader(`{}`)); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("replaying a POST: error %v, want ErrNotRecorded", err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package verifier checks that requests received over plain https were
+// really sent by Alexa, as required for skills not hosted on lambda:
+// https://developer.amazon.com/docs/custom-skills/host-a-custom-skill-as-a-web-service.html
+package verifier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// alexaSAN is the domain the signing certificate must be issued for
+const alexaSAN = "echo-api.amazon.com"
+
+const (
+	// maxBodySize bounds the requests read by Middleware, far above the
+	// size of any Alexa request
+	maxBodySize = 1 << 20
+	// maxChainSize bounds the certificate chains downloaded
+	maxChainSize = 64 << 10
+	// maxCerts bounds the certificates cached. Alexa signs with a handful
+	// of chains at once, so more only happen when something is wrong
+	maxCerts = 16
+)
+
+// Verifier validates the signature and timestamp of Alexa requests.
+// The certificates of downloaded chains are cached by their cleaned url,
+// up to maxCerts of them
+type Verifier struct {
+	// Tolerance is how old a request timestamp can be before it is rejected
+	Tolerance time.Duration
+	// Client downloads certificate chains
+	Client *http.Client
+	// Roots are the certificate authorities chains must lead to, the ones of the system when nil
+	Roots *x509.CertPool
+	// Now returns the current time, it can be replaced to verify old requests
+	Now func() time.Time
+
+	mutex sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// New returns a verifier accepting requests up to 150 seconds old, as Alexa requires
+func New() *Verifier {
+	return &Verifier{
+		Tolerance: 150 * time.Second,
+		Client:    &http.Client{Timeout: 5 * time.Second},
+		Now:       time.Now,
+		certs:     map[string]*x509.Certificate{},
+	}
+}
+
+// Verify returns an error unless body was signed by Alexa according to the
+// Signature-256 (or legacy Signature) and SignatureCertChainUrl headers and
+// its timestamp is recent enough
+func (verifier *Verifier) Verify(header http.Header, body []byte) error {
+	chainURL, err := cleanCertURL(header.Get("SignatureCertChainUrl"))
+	if err != nil {
+		return err
+	}
+	cert, err := verifier.certificate(chainURL)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(cert, header, body); err != nil {
+		return err
+	}
+	return verifier.verifyTimestamp(body)
+}
+
+// Middleware rejects requests that fail verification with 400 Bad Request,
+// and those larger than maxBodySize with 413 Request Entity Too Large,
+// before they reach next
+func (verifier *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+		if err := verifier.Verify(r.Header, body); err != nil {
+			http.Error(w, "request verification failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateCertURL checks that the certificate chain url points to Amazon's
+// bucket, e.g. https://s3.amazonaws.com/echo.api/echo-api-cert.pem
+func ValidateCertURL(chainURL string) error {
+	_, err := cleanCertURL(chainURL)
+	return err
+}
+
+// cleanCertURL validates the certificate chain url and returns it cleaned, so
+// that every spelling of the same url, such as with an uppercase host or an
+// explicit port, is downloaded and cached once
+func cleanCertURL(chainURL string) (string, error) {
+	if chainURL == "" {
+		return "", errors.New("missing SignatureCertChainUrl header")
+	}
+	u, err := url.Parse(chainURL)
+	if err != nil {
+		return "", fmt.Errorf("malformed certificate url: %v", err)
+	}
+	if !strings.EqualFold(u.Scheme, "https") {
+		return "", errors.New("certificate url must use https")
+	}
+	if !strings.EqualFold(u.Hostname(), "s3.amazonaws.com") {
+		return "", errors.New("certificate url must be hosted on s3.amazonaws.com")
+	}
+	if port := u.Port(); port != "" && port != "443" {
+		return "", errors.New("certificate url must use port 443")
+	}
+	cleaned := path.Clean(u.Path)
+	if !strings.HasPrefix(cleaned, "/echo.api/") {
+		return "", errors.New("certificate url must be under /echo.api/")
+	}
+	return (&url.URL{Scheme: "https", Host: "s3.amazonaws.com", Path: cleaned}).String(), nil
+}
+
+// certificate returns the validated signing certificate of the chain at chainURL
+func (verifier *Verifier) certificate(chainURL string) (*x509.Certificate, error) {
+	verifier.mutex.Lock()
+	cert, ok := verifier.certs[chainURL]
+	verifier.mutex.Unlock()
+
+	if !ok {
+		chain, err := verifier.download(chainURL)
+		if err != nil {
+			return nil, err
+		}
+		if cert, err = validateChain(chain, verifier.Roots, verifier.Now()); err != nil {
+			return nil, err
+		}
+		verifier.mutex.Lock()
+		if len(verifier.certs) >= maxCerts {
+			// any certificate evicted is downloaded again when used
+			for evicted := range verifier.certs {
+				delete(verifier.certs, evicted)
+				break
+			}
+		}
+		verifier.certs[chainURL] = cert
+		verifier.mutex.Unlock()
+	}
+
+	// cached certificates may have expired since they were downloaded
+	if now := verifier.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, errors.New("signing certificate is expired")
+	}
+	return cert, nil
+}
+
+// download fetches the pem encoded certificate chain at chainURL
+func (verifier *Verifier) download(chainURL string) ([]byte, error) {
+	response, err := verifier.Client.Get(chainURL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading certificate chain: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading certificate chain: unexpected status %s", response.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(response.Body, maxChainSize))
+}
+
+// validateChain checks that the leaf of the pem encoded chain is currently
+// valid, issued for Alexa and chains up to one of roots, and returns it
+func validateChain(chain []byte, roots *x509.CertPool, now time.Time) (*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, chain = pem.Decode(chain)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate chain: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       alexaSAN,
+		Intermediates: intermediates,
+		Roots:         roots,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate chain: %v", err)
+	}
+	return leaf, nil
+}
+
+// verifySignature checks the signature of body against the certificate,
+// preferring the sha-256 signature when Alexa sent one
+func verifySignature(cert *x509.Certificate, header http.Header, body []byte) error {
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate doesn't hold an rsa key")
+	}
+
+	encoded, hash := header.Get("Signature-256"), crypto.SHA256
+	if encoded == "" {
+		encoded, hash = header.Get("Signature"), crypto.SHA1
+	}
+	if encoded == "" {
+		return errors.New("missing Signature header")
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA256 {
+		sum := sha256.Sum256(body)
+		digest = sum[:]
+	} else {
+		sum := sha1.Sum(body)
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return errors.New("signature doesn't match the request body")
+	}
+	return nil
+}
+
+// verifyTimestamp checks that the request in body was sent recently,
+// which protects against replayed requests
+func (verifier *Verifier) verifyTimestamp(body []byte) error {
+	var request struct {
+		Request struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"request"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return fmt.Errorf("malformed request: %v", err)
+	}
+	age := verifier.Now().Sub(request.Request.Timestamp)
+	if age < -verifier.Tolerance || age > verifier.Tolerance {
+		return errors.New("request timestamp is outside the allowed tolerance")
+	}
+	return nil
+}
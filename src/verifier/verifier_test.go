@@ -0,0 +1,389 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// now is the time the test certificates and requests are checked at
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// authority is a self-signed certificate authority issuing test certificates
+type authority struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+// newAuthority returns a certificate authority valid for a year around now
+func newAuthority(t *testing.T) authority {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             now.AddDate(0, -6, 0),
+		NotAfter:              now.AddDate(0, 6, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authority{cert: cert, key: key}
+}
+
+// pool returns a pool trusting only the authority
+func (ca authority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// issue returns the pem encoded certificate issued for san, valid from
+// notBefore to notAfter, along with its key
+func (ca authority) issue(t *testing.T, san string, notBefore, notAfter time.Time) ([]byte, *rsa.PrivateKey) {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: san},
+		DNSNames:     []string{san},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key
+}
+
+// newKey returns a new rsa key
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sign returns the base64 encoded signature of body with key
+func sign(t *testing.T, key *rsa.PrivateKey, hash crypto.Hash, body []byte) string {
+	t.Helper()
+	var digest []byte
+	if hash == crypto.SHA256 {
+		sum := sha256.Sum256(body)
+		digest = sum[:]
+	} else {
+		sum := sha1.Sum(body)
+		digest = sum[:]
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// requestAt returns the body of an alexa request sent at timestamp
+func requestAt(timestamp time.Time) []byte {
+	return []byte(fmt.Sprintf(`{"version":"1.0","request":{"type":"LaunchRequest","timestamp":%q}}`, timestamp.Format(time.RFC3339)))
+}
+
+// roundTripper serves every download with the same chain, counting them
+type roundTripper struct {
+	chain     []byte
+	downloads atomic.Int32
+}
+
+func (transport *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.downloads.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(bytes.NewReader(transport.chain)),
+		Request:    request,
+	}, nil
+}
+
+// signedVerifier returns a verifier trusting a test authority, serving the chain
+// of an Alexa certificate from any url, and the key of that certificate
+func signedVerifier(t *testing.T) (*Verifier, *roundTripper, *rsa.PrivateKey) {
+	t.Helper()
+	ca := newAuthority(t)
+	chain, key := ca.issue(t, alexaSAN, now.AddDate(0, -1, 0), now.AddDate(0, 1, 0))
+	transport := &roundTripper{chain: chain}
+	verifier := New()
+	verifier.Client = &http.Client{Transport: transport}
+	verifier.Roots = ca.pool()
+	verifier.Now = func() time.Time { return now }
+	return verifier, transport, key
+}
+
+func TestValidateCertURL(t *testing.T) {
+	tests := map[string]struct {
+		url   string
+		valid bool
+	}{
+		"amazon bucket":        {url: "https://s3.amazonaws.com/echo.api/echo-api-cert.pem", valid: true},
+		"uppercase scheme":     {url: "HTTPS://s3.amazonaws.com/echo.api/echo-api-cert.pem", valid: true},
+		"uppercase host":       {url: "https://S3.AMAZONAWS.COM/echo.api/echo-api-cert.pem", valid: true},
+		"port 443":             {url: "https://s3.amazonaws.com:443/echo.api/echo-api-cert.pem", valid: true},
+		"dots within the path": {url: "https://s3.amazonaws.com/echo.api/../echo.api/echo-api-cert.pem", valid: true},
+		"empty":                {url: ""},
+		"http":                 {url: "http://s3.amazonaws.com/echo.api/echo-api-cert.pem"},
+		"other host":           {url: "https://notamazon.com/echo.api/echo-api-cert.pem"},
+		"other port":           {url: "https://s3.amazonaws.com:563/echo.api/echo-api-cert.pem"},
+		"uppercase path":       {url: "https://s3.amazonaws.com/EcHo.aPi/echo-api-cert.pem"},
+		"other path":           {url: "https://s3.amazonaws.com/invalid.path/echo-api-cert.pem"},
+		"path traversal":       {url: "https://s3.amazonaws.com/echo.api/../invalid.path/echo-api-cert.pem"},
+		"escaped traversal":    {url: "https://s3.amazonaws.com/echo.api/%2e%2e/invalid.path/echo-api-cert.pem"},
+		"malformed":            {url: "https://s3.amazonaws.com:port/echo.api/echo-api-cert.pem"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateCertURL(test.url)
+			if test.valid && err != nil {
+				t.Errorf("ValidateCertURL(%q) = %v, want valid", test.url, err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("ValidateCertURL(%q) is valid, want an error", test.url)
+			}
+		})
+	}
+}
+
+func TestCleanCertURL(t *testing.T) {
+	want := "https://s3.amazonaws.com/echo.api/echo-api-cert.pem"
+	for _, spelling := range []string{
+		want,
+		"HTTPS://S3.amazonaws.com/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com:443/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com/echo.api/../echo.api/./echo-api-cert.pem",
+	} {
+		if got, err := cleanCertURL(spelling); err != nil || got != want {
+			t.Errorf("cleanCertURL(%q) = %q, %v, want %q", spelling, got, err, want)
+		}
+	}
+}
+
+func TestValidateChain(t *testing.T) {
+	ca := newAuthority(t)
+	valid, _ := ca.issue(t, alexaSAN, now.AddDate(0, -1, 0), now.AddDate(0, 1, 0))
+	wrongSAN, _ := ca.issue(t, "echo-api.example.com", now.AddDate(0, -1, 0), now.AddDate(0, 1, 0))
+	expired, _ := ca.issue(t, alexaSAN, now.AddDate(0, -2, 0), now.AddDate(0, -1, 0))
+
+	tests := map[string]struct {
+		chain []byte
+		roots *x509.CertPool
+		valid bool
+	}{
+		"valid":           {chain: valid, roots: ca.pool(), valid: true},
+		"wrong san":       {chain: wrongSAN, roots: ca.pool()},
+		"expired":         {chain: expired, roots: ca.pool()},
+		"untrusted root":  {chain: valid, roots: newAuthority(t).pool()},
+		"empty chain":     {chain: nil, roots: ca.pool()},
+		"malformed chain": {chain: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), roots: ca.pool()},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cert, err := validateChain(test.chain, test.roots, now)
+			if test.valid && (err != nil || cert == nil) {
+				t.Errorf("validateChain = %v, want valid", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("validateChain is valid, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	ca := newAuthority(t)
+	chain, key := ca.issue(t, alexaSAN, now.AddDate(0, -1, 0), now.AddDate(0, 1, 0))
+	cert, err := validateChain(chain, ca.pool(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := requestAt(now)
+	other := newKey(t)
+
+	tests := map[string]struct {
+		header http.Header
+		body   []byte
+		valid  bool
+	}{
+		"sha-256": {
+			header: http.Header{"Signature-256": {sign(t, key, crypto.SHA256, body)}},
+			body:   body, valid: true,
+		},
+		"legacy sha-1": {
+			header: http.Header{"Signature": {sign(t, key, crypto.SHA1, body)}},
+			body:   body, valid: true,
+		},
+		"sha-256 preferred": {
+			header: http.Header{"Signature-256": {sign(t, key, crypto.SHA256, body)}, "Signature": {"bm90IGEgc2lnbmF0dXJl"}},
+			body:   body, valid: true,
+		},
+		"tampered body": {
+			header: http.Header{"Signature-256": {sign(t, key, crypto.SHA256, body)}},
+			body:   requestAt(now.Add(time.Second)),
+		},
+		"other key": {
+			header: http.Header{"Signature-256": {sign(t, other, crypto.SHA256, body)}},
+			body:   body,
+		},
+		"sha-1 signed as sha-256": {
+			header: http.Header{"Signature-256": {sign(t, key, crypto.SHA1, body)}},
+			body:   body,
+		},
+		"malformed": {
+			header: http.Header{"Signature-256": {"not base64!"}},
+			body:   body,
+		},
+		"missing": {header: http.Header{}, body: body},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := verifySignature(cert, test.header, test.body)
+			if test.valid && err != nil {
+				t.Errorf("verifySignature = %v, want valid", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("verifySignature is valid, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifyTimestamp(t *testing.T) {
+	verifier := New()
+	verifier.Now = func() time.Time { return now }
+	tests := map[string]struct {
+		body  []byte
+		valid bool
+	}{
+		"now":                 {body: requestAt(now), valid: true},
+		"at the tolerance":    {body: requestAt(now.Add(-150 * time.Second)), valid: true},
+		"past the tolerance":  {body: requestAt(now.Add(-151 * time.Second))},
+		"ahead within":        {body: requestAt(now.Add(100 * time.Second)), valid: true},
+		"ahead past":          {body: requestAt(now.Add(151 * time.Second))},
+		"without a timestamp": {body: []byte(`{"request":{}}`)},
+		"malformed":           {body: []byte(`{`)},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := verifier.verifyTimestamp(test.body)
+			if test.valid && err != nil {
+				t.Errorf("verifyTimestamp = %v, want valid", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("verifyTimestamp is valid, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifyCachesCertificatesByCleanedURL(t *testing.T) {
+	verifier, transport, key := signedVerifier(t)
+	body := requestAt(now)
+	for _, url := range []string{
+		"https://s3.amazonaws.com/echo.api/echo-api-cert.pem",
+		"HTTPS://S3.AMAZONAWS.COM:443/echo.api/echo-api-cert.pem",
+		"https://s3.amazonaws.com/echo.api/../echo.api/echo-api-cert.pem",
+	} {
+		header := http.Header{"Signaturecertchainurl": {url}, "Signature-256": {sign(t, key, crypto.SHA256, body)}}
+		if err := verifier.Verify(header, body); err != nil {
+			t.Fatalf("Verify with %s = %v", url, err)
+		}
+	}
+	if downloads := transport.downloads.Load(); downloads != 1 {
+		t.Errorf("chain downloaded %d times, want once", downloads)
+	}
+}
+
+func TestCertificateCacheIsBounded(t *testing.T) {
+	verifier, transport, key := signedVerifier(t)
+	body := requestAt(now)
+	for i := 0; i < 3*maxCerts; i++ {
+		url := fmt.Sprintf("https://s3.amazonaws.com/echo.api/cert-%d.pem", i)
+		header := http.Header{"Signaturecertchainurl": {url}, "Signature-256": {sign(t, key, crypto.SHA256, body)}}
+		if err := verifier.Verify(header, body); err != nil {
+			t.Fatalf("Verify with %s = %v", url, err)
+		}
+	}
+	if cached := len(verifier.certs); cached > maxCerts {
+		t.Errorf("%d certificates cached, want at most %d", cached, maxCerts)
+	}
+	if downloads := transport.downloads.Load(); downloads != 3*maxCerts {
+		t.Errorf("chain downloaded %d times, want %d", downloads, 3*maxCerts)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier, _, key := signedVerifier(t)
+	body := requestAt(now)
+	signed := sign(t, key, crypto.SHA256, body)
+
+	tests := map[string]struct {
+		body      []byte
+		signature string
+		status    int
+	}{
+		"signed":   {body: body, signature: signed, status: http.StatusOK},
+		"unsigned": {body: body, status: http.StatusBadRequest},
+		"too large": {
+			body:      append(body, strings.Repeat(" ", maxBodySize)...),
+			signature: signed,
+			status:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var received []byte
+			handler := verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ = io.ReadAll(r.Body)
+			}))
+			request := httptest.NewRequest(http.MethodPost, "/alexa", bytes.NewReader(test.body))
+			request.Header.Set("SignatureCertChainUrl", "https://s3.amazonaws.com/echo.api/echo-api-cert.pem")
+			if test.signature != "" {
+				request.Header.Set("Signature-256", test.signature)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("status %d, want %d", recorder.Code, test.status)
+			}
+			if test.status == http.StatusOK && !bytes.Equal(received, test.body) {
+				t.Errorf("handler received %q, want the request body", received)
+			}
+		})
+	}
+}
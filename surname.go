@@ -0,0 +1,88 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/logging"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/surname"
+	"context"
+	"errors"
+	"strconv"
+)
+
+// surnameProvider finds where surnames come from
+var surnameProvider = surname.NamSor{BaseURL: cfg.SurnameURL, APIKey: cfg.NamSorAPIKey, HTTP: httpClient}
+
+// surnameIntentModel declares SurnameIntent in the interaction model
+var surnameIntentModel = model.Intent{
+	Name: "SurnameIntent",
+	Slots: []model.Slot{
+		{Name: "last_name", Type: "Surname"},
+		{Name: "first_name", Type: "FirstName"},
+	},
+	Samples: []string{
+		"where the surname {last_name} is from",
+		"where does the surname {last_name} come from",
+		"where is {first_name} {last_name} from",
+	},
+}
+
+// surnameSlotType declares the type of surname slots. There is no built-in
+// type for family names, so any value is accepted and these are only examples
+var surnameSlotType = model.SlotType{
+	Name: "Surname",
+	Values: []model.Value{
+		{Name: "Kowalski"}, {Name: "Smith"}, {Name: "Garcia"}, {Name: "Nguyen"}, {Name: "Okafor"}, {Name: "Müller"},
+	},
+}
+
+// HandleSurnameIntent tells where a family name comes from, which is
+// phrased differently from first name guesses since it describes the
+// origin of the family rather than the nationality of a person.
+// A user can say:
+// Alexa, ask nationality guesser where the surname Kowalski is from
+func HandleSurnameIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	lastName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "last_name"))
+	firstName := names.Sanitize(getValueOfName(request.Body.Intent.Slots, "first_name"))
+	if blocklist.Contains(lastName) {
+		logging.FromContext(ctx).Info("refusing to find origin of blocked surname", logging.NameKey, lastName)
+		return alexa.NewSimpleResponse(templates.Render("title.surname"), templates.Render("surname.blocked"))
+	}
+
+	logging.FromContext(ctx).Info("finding origin of surname", logging.NameKey, lastName)
+	ctx, cancel := context.WithTimeout(ctx, cfg.UpstreamTimeout)
+	defer cancel()
+	origin, err := surnameProvider.Origin(ctx, names.Romanize(firstName), names.Romanize(lastName))
+	if errors.Is(err, surname.ErrNoAPIKey) {
+		return alexa.NewSimpleResponse(templates.Render("title.surname"), templates.Render("surname.unavailable"))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("surname origin failed", "error", err)
+		return respondWithError(ctx, templates, "title.surname", err)
+	}
+	return alexa.NewSSMLResponse(templates.Render("title.surname"), buildSurnameResponse(templates, lastName, origin)).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// buildSurnameResponse speaks the country lastName most likely comes from,
+// the runner up when it is close, and a reminder that it isn't a nationality
+func buildSurnameResponse(templates messages.Set, lastName string, origin surname.Origin) string {
+	var builder alexa.SSMLBuilder
+	if origin.CountryOrigin == "" {
+		builder.Say(templates.Render("surname.unknown", "surname", lastName))
+		return builder.Build()
+	}
+
+	percent := strconv.Itoa(int(origin.ProbabilityCalibrated * 100))
+	builder.Say(templates.Render("surname.result", "surname", lastName, "country", findCountryName(templates, nil, origin.CountryOrigin), "percent", percent))
+	if origin.CountryOriginAlt != "" && origin.CountryOriginAlt != origin.CountryOrigin && origin.ProbabilityAltCalibrated >= nonTrivialProbability {
+		builder.Say(templates.Render("surname.alternative", "country", findCountryName(templates, nil, origin.CountryOriginAlt)))
+	}
+	builder.Pause("300")
+	builder.Say(templates.Render("surname.note"))
+	return builder.Build()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"alexa-skill-test/src/facts"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/quiz"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// surpriseRand picks the names used by SurpriseIntent and the quiz, along with the
+// phrasing of responses. It is seeded from the configuration so the picks are
+// reproducible when a seed is set
+var surpriseRand = newSurpriseRand(cfg.SurpriseSeed)
+
+// surpriseMutex guards surpriseRand, which isn't safe for concurrent use
+var surpriseMutex sync.Mutex
+
+// newSurpriseRand returns a random source seeded with seed, or with the clock if seed is zero
+func newSurpriseRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// pickSurpriseName returns a random name from the embedded list of common names
+func pickSurpriseName() string {
+	surpriseMutex.Lock()
+	defer surpriseMutex.Unlock()
+	return names.RandomCommon(surpriseRand)
+}
+
+// pickQuizName returns a random name from the quiz pool that wasn't asked yet
+func pickQuizName(asked []string) string {
+	surpriseMutex.Lock()
+	defer surpriseMutex.Unlock()
+	return quiz.Pick(surpriseRand, asked)
+}
+
+// pickFact returns a random curated fact in language about the country having code, if there is any
+func pickFact(language string, code string) (string, bool) {
+	surpriseMutex.Lock()
+	defer surpriseMutex.Unlock()
+	return facts.Random(surpriseRand, language, code)
+}
+
+// pickPhrasings returns templates with one phrasing chosen for every message having a pool of them
+func pickPhrasings(templates messages.Set) messages.Set {
+	surpriseMutex.Lock()
+	defer surpriseMutex.Unlock()
+	return templates.Pick(surpriseRand)
+}
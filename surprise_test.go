@@ -0,0 +1,25 @@
+package main
+
+import (
+	"alexa-skill-test/src/names"
+	"alexa-skill-test/src/nationality"
+	"strings"
+	"testing"
+)
+
+func TestSurpriseGuessesSeededName(t *testing.T) {
+	saved := surpriseRand
+	t.Cleanup(func() { surpriseRand = saved })
+	const seed = 42
+	want := names.RandomCommon(newSurpriseRand(seed))
+	surpriseRand = newSurpriseRand(seed)
+	provider := &fakeNationality{responses: map[string]nationality.Response{want: hansGuess}}
+
+	speech := spoken(dispatchIntent(testContext(provider), intentRequest("SurpriseIntent", nil)))
+	if !strings.Contains(speech, strings.ToLower(want)) {
+		t.Errorf("speech %q doesn't tell the chosen name %s", speech, want)
+	}
+	if !strings.Contains(speech, "german") || provider.lookups.Load() != 1 {
+		t.Errorf("speech %q doesn't guess the chosen name", speech)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/countries"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/names"
+	"context"
+)
+
+// typicalNamesCount is how many names HandleTypicalNamesIntent lists
+const typicalNamesCount = 6
+
+// typicalNamesIntentModel declares TypicalNamesIntent in the interaction model
+var typicalNamesIntentModel = model.Intent{
+	Name:  "TypicalNamesIntent",
+	Slots: []model.Slot{{Name: "country", Type: "AMAZON.Country"}},
+	Samples: []string{
+		"what are typical {country} names",
+		"what are typical names in {country}",
+		"what are common names in {country}",
+		"which names are popular in {country}",
+		"give me some {country} names",
+	},
+}
+
+// HandleTypicalNamesIntent lists a handful of the most popular first names
+// of a country, the reverse of a guess. The country can be said by its
+// name or its demonym.
+// A user can say:
+// Alexa, ask nationality guesser what are typical Brazilian names
+func HandleTypicalNamesIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	answer := getValueOfName(request.Body.Intent.Slots, "country")
+	if answer == "" {
+		return alexa.NewElicitSlotResponse("country", templates.Render("typical.ask"))
+	}
+	code, ok := countries.Embedded.Match(answer)
+	if !ok {
+		return alexa.NewSimpleResponse(templates.Render("title.typical"), templates.Render("typical.unknown_country", "country", answer)).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+
+	country := findCountryName(templates, nil, code)
+	typical := names.Typical(code, typicalNamesCount)
+	var builder alexa.SSMLBuilder
+	if len(typical) == 0 {
+		builder.Say(templates.Render("typical.none", "country", country))
+	} else {
+		builder.Say(templates.Render("typical.names", "country", country, "names", formatsFor(request).List(typical)))
+	}
+	builder.Pause("500")
+	builder.Say(templates.Render("guess.followup"))
+	return alexa.NewSSMLResponse(templates.Render("title.typical"), builder.Build()).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
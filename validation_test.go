@@ -0,0 +1,64 @@
+package main
+
+import (
+	"alexa-skill-test/src/validation"
+	"strings"
+	"testing"
+)
+
+// validSlotValues are usable values of each kind of slot
+var validSlotValues = map[validation.Kind]string{
+	validation.Name:   "Hans",
+	validation.Number: "3",
+	validation.Text:   "Swiss",
+}
+
+func TestDispatchElicitsInvalidSlots(t *testing.T) {
+	for intent, slots := range intentSlots {
+		for _, slot := range slots {
+			cases := map[string]string{"invalid": "123"}
+			if slot.Required {
+				cases["missing"] = ""
+			}
+			if slot.Kind == validation.Text {
+				// any text is a valid value
+				delete(cases, "invalid")
+			}
+			for problem, value := range cases {
+				values := map[string]string{}
+				for _, other := range slots {
+					if other.Required {
+						values[other.Name] = validSlotValues[other.Kind]
+					}
+				}
+				values[slot.Name] = value
+
+				response := dispatchIntent(testContext(&fakeNationality{}), intentRequest(intent, values))
+				directives := response.Body.Directives
+				if len(directives) != 1 || directives[0].Type != "Dialog.ElicitSlot" || directives[0].SlotToElicit != slot.Name {
+					t.Errorf("%s with %s %s: directives %+v, want %s elicited", intent, problem, slot.Name, directives, slot.Name)
+					continue
+				}
+				speech := spoken(response)
+				if speech == "" || strings.Contains(speech, "{") || strings.Contains(speech, "slot.") {
+					t.Errorf("%s with %s %s: prompt %q", intent, problem, slot.Name, speech)
+				}
+			}
+		}
+	}
+}
+
+func TestDispatchAcceptsValidSlots(t *testing.T) {
+	for _, intent := range []string{"NameIntent", "DemonymIntent"} {
+		values := map[string]string{}
+		for _, slot := range intentSlots[intent] {
+			values[slot.Name] = validSlotValues[slot.Kind]
+		}
+		response := dispatchIntent(testContext(&fakeNationality{}), intentRequest(intent, values))
+		for _, directive := range response.Body.Directives {
+			if directive.Type == "Dialog.ElicitSlot" {
+				t.Errorf("%s with valid slots elicited %s", intent, directive.SlotToElicit)
+			}
+		}
+	}
+}
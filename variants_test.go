@@ -0,0 +1,65 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/messages"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// userInVariant returns a user id bucketed into variant
+func userInVariant(t *testing.T, variant string) string {
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("amzn1.ask.account.%d", i)
+		if messages.Variants[messages.Bucket(userID, len(messages.Variants))] == variant {
+			return userID
+		}
+	}
+	t.Fatalf("no user bucketed into %s", variant)
+	return ""
+}
+
+// templatesOf returns the templates messagesFor phrases the responses to userID with, in locale
+func templatesOf(userID, locale string) messages.Set {
+	var request alexa.Request
+	request.Session.User.UserID = userID
+	request.Body.Locale = locale
+	return messagesFor(context.Background(), request)
+}
+
+func TestMessagesForBucketsUsersIntoVariants(t *testing.T) {
+	keepConfig(t)
+	cfg.TemplateVariant = ""
+
+	playful := templatesOf(userInVariant(t, "playful"), "en-US")
+	if playful["guess.lead"] != messages.Playful["guess.lead"] {
+		t.Errorf("playful user got %q", playful["guess.lead"])
+	}
+	control := templatesOf(userInVariant(t, "control"), "en-US")
+	if control["guess.lead"] == messages.Playful["guess.lead"] || control["guess.lead"] == "" {
+		t.Errorf("control user got %q", control["guess.lead"])
+	}
+	// the same user stays in the same variant
+	if again := templatesOf(userInVariant(t, "playful"), "en-US"); again["guess.lead"] != playful["guess.lead"] {
+		t.Errorf("playful user got %q the second time", again["guess.lead"])
+	}
+}
+
+func TestMessagesForForcedVariant(t *testing.T) {
+	keepConfig(t)
+	cfg.TemplateVariant = "playful"
+
+	if got := templatesOf(userInVariant(t, "control"), "en-US")["guess.lead"]; got != messages.Playful["guess.lead"] {
+		t.Errorf("forced variant got %q", got)
+	}
+}
+
+func TestMessagesForLeavesOtherLanguagesAlone(t *testing.T) {
+	keepConfig(t)
+	cfg.TemplateVariant = "playful"
+
+	if got := templatesOf(userInVariant(t, "playful"), "de-DE")["guess.lead"]; got == messages.Playful["guess.lead"] {
+		t.Errorf("german user got the english variant %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"alexa-skill-test/src/alexa"
+	"alexa-skill-test/src/format"
+	"alexa-skill-test/src/messages"
+	"alexa-skill-test/src/model"
+	"alexa-skill-test/src/nationality"
+	"alexa-skill-test/src/session"
+	"context"
+	"strings"
+)
+
+// whyIntentModel declares WhyIntent in the interaction model
+var whyIntentModel = model.Intent{
+	Name: "WhyIntent",
+	Samples: []string{
+		"why do you think that",
+		"why",
+		"how did you guess that",
+		"how do you know",
+		"what is that based on",
+	},
+}
+
+// explainedSources are the providers WhyIntent has specific wording for,
+// the others are explained in general terms
+var explainedSources = map[string]bool{"nationalize": true, "namsor": true, nationality.OfflineSource: true}
+
+// HandleWhyIntent explains how the last guess was made, from the providers
+// it came from, the records behind it, when they were collected and the
+// country it leaned towards, which were kept in the session along with the guess.
+// A user can say:
+// Alexa, why do you think that?
+func HandleWhyIntent(ctx context.Context, request alexa.Request) alexa.Response {
+	templates := messagesFor(ctx, request)
+	last, _ := session.Load(request.Session.Attributes).LastGuess()
+	if last.Name == "" {
+		return alexa.NewSimpleResponse(templates.Render("title.why"), templates.Render("why.none")).
+			WithReprompt(templates.Render("guess.reprompt")).
+			WithShouldEndSession(false)
+	}
+
+	var builder alexa.SSMLBuilder
+	builder.Say(buildWhyExplanation(templates, formatsFor(request), last))
+	builder.Pause("500")
+	builder.Say(templates.Render("guess.followup"))
+	return alexa.NewSSMLResponse(templates.Render("title.why"), builder.Build()).
+		WithReprompt(templates.Render("guess.reprompt")).
+		WithShouldEndSession(false)
+}
+
+// guessSources returns the providers response comes from. A lone provider
+// that isn't wrapped doesn't name itself, so it is the configured one
+func guessSources(response nationality.Response) []string {
+	if len(response.Sources) > 0 {
+		return response.Sources
+	}
+	return []string{cfg.NationalityProvider}
+}
+
+// buildWhyExplanation explains how last was guessed, in the words of each of
+// its providers, followed by the number of records behind it and their
+// vintage when the providers told them, and by the country it was leaned
+// towards because the user is likely there
+func buildWhyExplanation(templates messages.Set, formats format.Formatter, last session.LastGuess) string {
+	var sentences []string
+	if len(last.Sources) > 1 {
+		var names []string
+		for _, source := range last.Sources {
+			names = append(names, sourceName(templates, source))
+		}
+		sentences = append(sentences, templates.Render("why.ensemble", "sources", formats.List(names)))
+	}
+	explained := map[string]bool{}
+	for _, source := range last.Sources {
+		if !explainedSources[source] {
+			source = "generic"
+		}
+		if !explained[source] {
+			explained[source] = true
+			sentences = append(sentences, templates.Render("why."+source, "name", last.Name))
+		}
+	}
+	if len(sentences) == 0 {
+		sentences = append(sentences, templates.Render("why.generic", "name", last.Name))
+	}
+
+	if last.Count > 0 {
+		sentences = append(sentences, templates.Render("why.count", "count", formats.Number(last.Count)))
+	}
+	if last.Vintage != "" {
+		sentences = append(sentences, templates.Render("why.vintage", "vintage", last.Vintage))
+	}
+	if last.Favoured != "" {
+		sentences = append(sentences, templates.Render("why.favoured", "country", findCountryName(templates, nil, last.Favoured)))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// sourceName returns how the provider called source is spoken
+func sourceName(templates messages.Set, source string) string {
+	if !explainedSources[source] {
+		return source
+	}
+	return templates.Render("source." + source)
+}